@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/collect"
+)
+
+func main() {
+	mode := flag.String("mode", "ssh", "Stats source: ssh (pull from workers) or local (read a directory)")
+	statsDir := flag.String("stats-dir", ".", "Directory with stats files (local mode)")
+	credsFile := flag.String("credentials", "credentials.txt", "Worker credentials in ip;user;pass format (ssh mode)")
+	remoteDir := flag.String("remote-dir", "/root/NAM/Servis", "Remote directory with stats files (ssh mode)")
+	generatedDir := flag.String("generated", "Generated", "Directory with part_*.txt used to compute progress")
+	output := flag.String("output", "", "Write aggregated JSON snapshot to this file")
+	interval := flag.Int("interval", 5, "Poll interval in seconds")
+	quiet := flag.Bool("quiet", false, "Disable the console status line")
+	hflag := flag.Int("human", -1, "Format seconds and exit")
+	flag.Parse()
+
+	if *hflag >= 0 {
+		fmt.Print(aggregator.HumanDuration(int64(*hflag)))
+		return
+	}
+
+	var src aggregator.Source
+	switch *mode {
+	case "local":
+		src = aggregator.DirSource{Dir: *statsDir}
+	case "ssh":
+		creds, err := collect.ParseCredentials(*credsFile)
+		if err != nil {
+			log.Fatalf("load creds: %v", err)
+		}
+		src = aggregator.SSHSource{Workers: creds, RemoteDir: *remoteDir}
+	default:
+		log.Fatalf("unknown mode %q", *mode)
+	}
+
+	opts := aggregator.RunOptions{
+		Interval:   time.Duration(*interval) * time.Second,
+		OutputFile: *output,
+	}
+	if !*quiet {
+		opts.Console = aggregator.NewConsole(os.Stdout, aggregator.CountGeneratedLines(*generatedDir))
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+	opts.Stop = stop
+
+	aggregator.Run(src, opts)
+	fmt.Println()
+}
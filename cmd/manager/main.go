@@ -1,148 +1,94 @@
+// Command manager supervises scanner processes. It is kept for existing
+// scripts; new setups should use `vpnctl manager`.
 package main
 
 import (
-	"encoding/json"
 	"flag"
-	"fmt"
+	"log"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
-	"strings"
-)
 
-type scannerCfg struct {
-	Script      string
-	ProcessName string
-	CredsFile   string
-	Args        []string
-}
+	"vpn-bruteforce-client/internal/cli"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/manager"
+)
 
-var scanners = map[string]scannerCfg{
-	"fortinet":   {Script: "sers1.go", ProcessName: "sers1", CredsFile: "creds/fortinet.txt"},
-	"paloalto":   {Script: "sers2.go", ProcessName: "sers2", CredsFile: "creds/paloalto.txt"},
-	"sonicwall":  {Script: "sers3.go", ProcessName: "sers3", CredsFile: "creds/sonicwall.txt"},
-	"cisco":      {Script: "sers4.go", ProcessName: "sers4", CredsFile: "creds/cisco.txt"},
-	"sophos":     {Script: "test_scanner.go", ProcessName: "test_scanner", CredsFile: "creds/sophos.txt", Args: []string{"--vpn-type", "sophos"}},
-	"watchguard": {Script: "test_scanner.go", ProcessName: "test_scanner", CredsFile: "creds/watchguard.txt", Args: []string{"--vpn-type", "watchguard"}},
-}
+func main() {
+	vpnType := flag.String("vpn-type", "", "VPN type or all")
+	stopFlag := flag.Bool("stop", false, "Stop scanners")
+	statusFlag := flag.Bool("status", false, "Show status")
+	configFile := flag.String("config", manager.DefaultConfigFile, "Scanner definitions (YAML)")
+	binDir := flag.String("bin-dir", "bin", "Directory for compiled scanner binaries")
+	runDir := flag.String("run-dir", "run", "Directory for PID files")
+	cgroupRoot := flag.String("cgroup-root", manager.DefaultCgroupRoot, "cgroup v2 directory for scanner resource limits")
+	logDir := flag.String("log-dir", "logs", "Directory for scanner logs")
+	logsFor := flag.String("logs", "", "Tail the newest log of a scanner")
+	lines := flag.Int("lines", 50, "Number of log lines to show with -logs")
+	follow := flag.Bool("follow", true, "Keep following the log with -logs")
+	maxRestarts := flag.Int("max-restarts", 10, "Give up on a crashing scanner after this many restarts (0 = unlimited)")
+	useDB := flag.Bool("db", false, "Record restart events in the database logs table")
+	appConfig := flag.String("app-config", "config.yaml", "Application config with database settings (used with -db)")
+	flag.Parse()
 
-func getPIDs(name string) []int {
-	out, err := exec.Command("pgrep", "-f", name).Output()
+	scanners, err := cli.LoadScanners(*configFile)
 	if err != nil {
-		return nil
-	}
-	fields := strings.Fields(string(out))
-	var pids []int
-	for _, f := range fields {
-		if pid, err := strconv.Atoi(f); err == nil {
-			pids = append(pids, pid)
-		}
+		log.Fatal(err)
 	}
-	return pids
-}
+	m := manager.New(scanners, *binDir, *runDir)
+	m.LogDir = *logDir
+	m.CgroupRoot = *cgroupRoot
+	m.MaxRestarts = *maxRestarts
 
-func startScanner(vpn string) {
-	cfg, ok := scanners[vpn]
-	if !ok {
-		fmt.Printf("unknown vpn %s\n", vpn)
-		return
-	}
-	if len(getPIDs(cfg.ProcessName)) > 0 {
-		fmt.Printf("scanner %s already running\n", vpn)
-		return
-	}
-	var cmdArgs []string
-	if filepath.Ext(cfg.Script) == ".py" {
-		cmdArgs = append([]string{"python3", cfg.Script}, cfg.Args...)
-	} else {
-		cmdArgs = append([]string{"go", "run", cfg.Script}, cfg.Args...)
-	}
-	if cfg.CredsFile != "" {
-		cmdArgs = append(cmdArgs, "--creds-file", cfg.CredsFile)
-	}
-	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Start(); err != nil {
-		fmt.Printf("failed to start %s: %v\n", vpn, err)
-		return
-	}
-	fmt.Printf("started %s (PID %d)\n", vpn, cmd.Process.Pid)
-}
-
-func stopScanner(vpn string) {
-	cfg, ok := scanners[vpn]
-	if !ok {
-		return
-	}
-	for _, pid := range getPIDs(cfg.ProcessName) {
-		proc, _ := os.FindProcess(pid)
-		proc.Signal(os.Interrupt)
-		fmt.Printf("stopped %s (PID %d)\n", vpn, pid)
-	}
-}
-
-func showStatus() {
-	fmt.Println("\nScanner status:")
-	for vpn, cfg := range scanners {
-		ids := getPIDs(cfg.ProcessName)
-		if len(ids) > 0 {
-			fmt.Printf("%-12s running %v\n", vpn, ids)
-		} else {
-			fmt.Printf("%-12s stopped\n", vpn)
-		}
-	}
-	files, _ := filepath.Glob("stats_*.json")
-	if len(files) == 0 {
-		return
-	}
-	total := map[string]int{}
-	for _, f := range files {
-		data, err := os.ReadFile(f)
-		if err != nil {
-			continue
+	if *logsFor != "" {
+		if _, err := m.Resolve(*logsFor); err != nil {
+			log.Fatal(err)
 		}
-		var m map[string]int
-		if json.Unmarshal(data, &m) == nil {
-			for k, v := range m {
-				total[k] += v
-			}
+		ctx, cancel := cli.SignalContext()
+		defer cancel()
+		if err := m.TailLog(ctx, os.Stdout, *logsFor, *lines, *follow); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
-	fmt.Printf("TOTAL goods:%d bads:%d errors:%d offline:%d ipblock:%d processed:%d\n",
-		total["goods"], total["bads"], total["errors"], total["offline"], total["ipblock"], total["processed"])
-}
-
-func main() {
-	vpnType := flag.String("vpn-type", "", "VPN type or all")
-	stopFlag := flag.Bool("stop", false, "Stop scanners")
-	statusFlag := flag.Bool("status", false, "Show status")
-	flag.Parse()
 
 	if *statusFlag {
-		showStatus()
+		cli.PrintStatus(os.Stdout, m.Status(), cli.StatsTotals("."))
 		return
 	}
 	if *stopFlag {
-		if *vpnType == "" || *vpnType == "all" {
-			for t := range scanners {
-				stopScanner(t)
-			}
-		} else {
-			stopScanner(*vpnType)
+		names, err := m.Resolve(*vpnType)
+		if err != nil {
+			log.Fatal(err)
 		}
+		m.Stop(names)
 		return
 	}
 	if *vpnType == "" {
 		flag.Usage()
 		return
 	}
-	if *vpnType == "all" {
-		for t := range scanners {
-			startScanner(t)
+	names, err := m.Resolve(*vpnType)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *useDB {
+		cfg, err := config.Load(*appConfig)
+		if err != nil {
+			log.Printf("config load error: %v", err)
+			cfg = config.Default()
+		}
+		database, err := db.ConnectFromApp(*cfg)
+		if err != nil {
+			log.Fatalf("failed to connect to database: %v", err)
 		}
-	} else {
-		startScanner(*vpnType)
+		defer database.Close()
+		m.Events = database
+	}
+
+	ctx, cancel := cli.SignalContext()
+	defer cancel()
+	if err := m.Supervise(ctx, names); err != nil {
+		log.Fatal(err)
 	}
 }
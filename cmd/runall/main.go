@@ -1,69 +1,63 @@
+// Command runall serves the dashboard and supervises scanners in a single
+// process. It is kept for existing scripts; new setups should use
+// `vpnctl runall`.
 package main
 
 import (
 	"flag"
-	"fmt"
-	"os"
-	"os/exec"
-	"os/signal"
-	"syscall"
-	"time"
-)
+	"log"
 
-var (
-	dashboardPort int
-	setupFlag     bool
-	vpnType       string
+	"vpn-bruteforce-client/internal/cli"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/manager"
 )
 
-func runCmd(args []string, wait bool) *exec.Cmd {
-	fmt.Printf("📋 Executing: %s\n", args)
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if wait {
-		cmd.Run()
-	} else {
-		cmd.Start()
-	}
-	return cmd
-}
-
-func setup() {
-	if setupFlag {
-		fmt.Println("🔧 Setting up environment...")
-		runCmd([]string{"python3", "dashboard_setup.py", "--setup"}, true)
-		os.MkdirAll("Generated", 0755)
-		os.MkdirAll("Valid", 0755)
-		fmt.Println("setup completed")
-	}
-}
-
 func main() {
-	flag.IntVar(&dashboardPort, "dashboard-port", 8080, "Dashboard port")
-	flag.BoolVar(&setupFlag, "setup", false, "Run setup")
-	flag.StringVar(&vpnType, "vpn-type", "", "VPN type")
+	var (
+		dashboardPort = flag.Int("dashboard-port", 8080, "Dashboard port")
+		setupFlag     = flag.Bool("setup", false, "Run setup")
+		vpnType       = flag.String("vpn-type", "", "VPN type")
+		configFile    = flag.String("config", "config.yaml", "Configuration file path")
+		scannersFile  = flag.String("scanners", manager.DefaultConfigFile, "Scanner definitions (YAML)")
+		binDir        = flag.String("bin-dir", "bin", "Directory for compiled scanner binaries")
+		runDir        = flag.String("run-dir", "run", "Directory for PID files")
+		logDir        = flag.String("log-dir", "logs", "Directory for scanner logs")
+		maxRestarts   = flag.Int("max-restarts", 10, "Give up on a crashing scanner after this many restarts (0 = unlimited)")
+	)
 	flag.Parse()
 
-	fmt.Println("🚀 Starting all components")
-	setup()
-
-	dash := runCmd([]string{"go", "run", "cmd/dashboard/main.go", fmt.Sprintf("-port=%d", dashboardPort)}, false)
-	time.Sleep(2 * time.Second)
-	if dash.ProcessState != nil && dash.ProcessState.Exited() {
-		fmt.Println("dashboard failed to start")
-		return
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Printf("config load error: %v", err)
+		cfg = config.Default()
+	}
+	if *setupFlag {
+		if err := cli.PrepareRun(cfg); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("setup completed")
 	}
 
-	if vpnType != "" {
-		runCmd([]string{"go", "run", "cmd/manager/main.go", "--vpn-type", vpnType}, true)
-	} else {
-		fmt.Println("scanners not started (no --vpn-type)")
+	var (
+		m     *manager.Manager
+		names []string
+	)
+	if *vpnType != "" {
+		scanners, err := cli.LoadScanners(*scannersFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m = manager.New(scanners, *binDir, *runDir)
+		m.LogDir = *logDir
+		m.MaxRestarts = *maxRestarts
+		if names, err = m.Resolve(*vpnType); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	fmt.Println("\nAll components started. Press Ctrl+C to exit.")
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
-	<-sig
-	dash.Process.Signal(os.Interrupt)
+	ctx, cancel := cli.SignalContext()
+	defer cancel()
+	if err := cli.RunAll(ctx, cfg, *dashboardPort, m, names); err != nil {
+		log.Fatal(err)
+	}
 }
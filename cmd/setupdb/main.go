@@ -1,58 +1,47 @@
+// Command setupdb creates the shared database schema and imports
+// creds/*.txt. It is kept for existing scripts; new setups should use
+// `vpnctl migrate --import-creds`.
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
-	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"vpn-bruteforce-client/internal/cli"
+	"vpn-bruteforce-client/internal/config"
 )
 
 func main() {
-	db, err := sql.Open("sqlite3", "vpn_scanner.db")
+	configFile := flag.String("config", "config.yaml", "Configuration file path")
+	driver := flag.String("driver", "", "Database driver: postgres or sqlite (default from config)")
+	dsn := flag.String("dsn", "", "Database DSN or SQLite file (default from config)")
+	creds := flag.String("creds", "creds/*.txt", "Credential files to import (empty to skip)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Printf("config load error: %v", err)
+		cfg = config.Default()
+	}
+	cli.OverrideDB(cfg, *driver, *dsn)
+
+	database, err := cli.Migrate(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer database.Close()
+	fmt.Printf("✅ Database initialized (%s)\n", cfg.DBDriver)
 
-	tables := []string{
-		`CREATE TABLE IF NOT EXISTS vendor_urls (id INTEGER PRIMARY KEY AUTOINCREMENT, url TEXT NOT NULL)`,
-		`CREATE TABLE IF NOT EXISTS credentials (id INTEGER PRIMARY KEY AUTOINCREMENT, ip TEXT NOT NULL, username TEXT NOT NULL, password TEXT NOT NULL)`,
-		`CREATE TABLE IF NOT EXISTS proxies (id INTEGER PRIMARY KEY AUTOINCREMENT, address TEXT NOT NULL, username TEXT, password TEXT)`,
-		`CREATE TABLE IF NOT EXISTS tasks (id INTEGER PRIMARY KEY AUTOINCREMENT, vpn_type TEXT, vendor_url_id INTEGER, server TEXT, status TEXT)`,
-		`CREATE TABLE IF NOT EXISTS logs (id INTEGER PRIMARY KEY AUTOINCREMENT, timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP, level TEXT, message TEXT, source TEXT)`,
+	if *creds == "" {
+		return
 	}
-	for _, t := range tables {
-		if _, err := db.Exec(t); err != nil {
-			log.Fatalf("create table: %v", err)
-		}
+	res, err := cli.ImportCredFiles(database, *creds)
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	fmt.Println("✅ Database initialized: vpn_scanner.db")
-
-	// load creds from creds/*.txt
-	files, _ := filepath.Glob(filepath.Join("creds", "*.txt"))
-	for _, f := range files {
-		fp := f
-		data, err := os.ReadFile(fp)
-		if err != nil {
-			continue
-		}
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			parts := strings.Split(line, ";")
-			if len(parts) >= 3 {
-				_, _ = db.Exec("INSERT INTO credentials (ip, username, password) VALUES (?, ?, ?)", parts[0], parts[1], parts[2])
-			}
-		}
+	for _, e := range res.Errors {
+		fmt.Println("  ", e)
 	}
-
-	fmt.Println("✅ Credentials imported")
+	fmt.Printf("✅ Credentials imported: %d, skipped: %d\n", res.Imported, res.Skipped)
 }
@@ -1,52 +1,62 @@
+// Command setupenv prepares working directories and credential files from
+// setup-data.json. It is kept for existing scripts; new setups should use
+// `vpnctl setup`.
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"io/fs"
+	"log"
 	"os"
-	"path/filepath"
-	"strings"
-)
 
-// Data mirrors setup-data.json structure.
-type Data struct {
-	SSHCredentials []string            `json:"sshCredentials"`
-	VPNCredentials map[string][]string `json:"vpnCredentials"`
-}
+	"vpn-bruteforce-client/internal/cli"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+)
 
 func main() {
 	dataFile := flag.String("data", "setup-data.json", "setup data file")
+	doctor := flag.Bool("doctor", false, "check the environment instead of writing files")
+	configFile := flag.String("config", "config.yaml", "configuration file used with -doctor and -db")
+	port := flag.Int("port", 8080, "dashboard port checked with -doctor")
+	mode := flag.String("mode", cli.ModeOverwrite, "how to treat existing files: overwrite, merge or append")
+	noBackup := flag.Bool("no-backup", false, "do not keep .bak copies of changed files")
+	importDB := flag.Bool("db", false, "import the setup data into the database configured in -config")
 	flag.Parse()
 
-	b, err := os.ReadFile(*dataFile)
-	if err != nil {
-		fmt.Printf("failed to read %s: %v\n", *dataFile, err)
-		os.Exit(1)
-	}
-	var d Data
-	if err := json.Unmarshal(b, &d); err != nil {
-		fmt.Printf("failed to parse %s: %v\n", *dataFile, err)
-		os.Exit(1)
+	if *doctor {
+		checks := cli.NewDoctor(*configFile, *port).Run(context.Background())
+		cli.PrintChecks(os.Stdout, checks)
+		for _, c := range checks {
+			if c.Status == cli.CheckFail {
+				os.Exit(1)
+			}
+		}
+		return
 	}
 
-	dirs := []string{"Generated", "Valid", filepath.Join("creds", "dictionaries")}
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			fmt.Printf("mkdir %s: %v\n", dir, err)
+	so := cli.SetupOptions{Mode: *mode, NoBackup: *noBackup}
+	if *importDB {
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			log.Printf("config load error: %v", err)
+			cfg = config.Default()
+		}
+		database, err := db.ConnectFromApp(*cfg)
+		if err != nil {
+			fmt.Printf("failed to connect to database: %v\n", err)
+			os.Exit(1)
 		}
+		so.DB = database
 	}
 
-	if err := os.WriteFile("credentials.txt", []byte(strings.Join(d.SSHCredentials, "\n")), 0o644); err != nil {
-		fmt.Printf("write credentials: %v\n", err)
+	err := cli.SetupEnv(*dataFile, so)
+	if so.DB != nil {
+		so.DB.Close()
 	}
-
-	os.MkdirAll("creds", 0o755)
-	for t, lines := range d.VPNCredentials {
-		path := filepath.Join("creds", t+".txt")
-		os.WriteFile(path, []byte(strings.Join(lines, "\n")), fs.FileMode(0o644))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
-
-	fmt.Println("✅ Environment setup complete")
 }
@@ -0,0 +1,7 @@
+package main
+
+import "vpn-bruteforce-client/internal/cli"
+
+func main() {
+	cli.Execute()
+}
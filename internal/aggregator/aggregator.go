@@ -1,9 +1,7 @@
 package aggregator
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -47,7 +45,8 @@ type ServerInfo struct {
 
 // Aggregator reads stats files from the provided directory.
 type Aggregator struct {
-	dir string
+	dir     string
+	history *History
 }
 
 // New returns Aggregator that looks for stats_*.json in dir.
@@ -55,53 +54,21 @@ func New(dir string) *Aggregator {
 	if dir == "" {
 		dir = "."
 	}
-	return &Aggregator{dir: dir}
+	return &Aggregator{dir: dir, history: NewHistory(DefaultHistoryRetention)}
+}
+
+// History returns the rolling time series filled by GetServerInfo.
+func (a *Aggregator) History() *History {
+	return a.history
 }
 
 // GetServerInfo aggregates metrics from all stats_*.json files.
 func (a *Aggregator) GetServerInfo() ([]ServerInfo, error) {
-	var total StatsFile
-	err := walkDir(a.dir, func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			var name string
-			if d != nil {
-				name = d.Name()
-			} else {
-				name = filepath.Base(path)
-			}
-			if strings.HasPrefix(name, "stats_") && strings.HasSuffix(name, ".json") {
-				log.Printf("stats walk error for %s: %v", path, walkErr)
-				return nil
-			}
-			return walkErr
-		}
-		if d.IsDir() {
-			return nil
-		}
-		name := d.Name()
-		if strings.HasPrefix(name, "stats_") && strings.HasSuffix(name, ".json") {
-			data, err := os.ReadFile(path)
-			if err != nil {
-				log.Printf("stats read error for %s: %v", path, err)
-				return nil
-			}
-			var s StatsFile
-			if err := json.Unmarshal(data, &s); err != nil {
-				log.Printf("stats parse error for %s: %v", path, err)
-				return nil
-			}
-			total.Goods += s.Goods
-			total.Bads += s.Bads
-			total.Errors += s.Errors
-			total.Offline += s.Offline
-			total.IPBlock += s.IPBlock
-			total.Processed += s.Processed
-		}
-		return nil
-	})
+	workers, err := DirSource{Dir: a.dir}.Collect()
 	if err != nil {
 		return nil, err
 	}
+	total := Sum(workers, time.Now())
 
 	// System metrics using gopsutil
 	cpuPercent, errCPU := cpu.Percent(0, false)
@@ -134,9 +101,21 @@ func (a *Aggregator) GetServerInfo() ([]ServerInfo, error) {
 		Task:      "",
 	}
 
+	a.history.Add(info, time.Now())
+
 	return []ServerInfo{info}, nil
 }
 
+// isStatsFile reports whether name looks like a stats file written by workers.
+func isStatsFile(name string) bool {
+	return strings.HasPrefix(name, "stats_") && strings.HasSuffix(name, ".json")
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
 func getUptime() uint64 {
 	data, err := os.ReadFile("/proc/uptime")
 	if err != nil {
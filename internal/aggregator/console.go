@@ -0,0 +1,156 @@
+package aggregator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HumanDuration formats seconds as HH:MM:SS.
+func HumanDuration(sec int64) string {
+	m := sec / 60
+	s := sec % 60
+	h := m / 60
+	m = m % 60
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
+
+// CountLines returns the number of newline characters in the file.
+func CountLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	buf := make([]byte, 32*1024)
+	count := 0
+	for {
+		n, err := f.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// CountGeneratedLines sums the lines of all part_*.txt files in dir. These are
+// the credential chunks distributed to workers and define 100% progress.
+func CountGeneratedLines(dir string) int {
+	files, _ := filepath.Glob(filepath.Join(dir, "part_*.txt"))
+	total := 0
+	for _, f := range files {
+		if n, err := CountLines(f); err == nil {
+			total += n
+		}
+	}
+	return total
+}
+
+// Console renders a single self-overwriting status line.
+type Console struct {
+	out        io.Writer
+	totalLines int
+	start      time.Time
+	lastLen    int
+}
+
+// NewConsole returns Console writing to out. totalLines is used to compute
+// the progress percentage; zero disables the percentage.
+func NewConsole(out io.Writer, totalLines int) *Console {
+	return &Console{out: out, totalLines: totalLines, start: time.Now()}
+}
+
+// Render prints totals over the previous line.
+func (c *Console) Render(t Totals) {
+	elapsed := time.Since(c.start)
+	speed := float64(t.Processed) / (elapsed.Seconds() + 1e-3)
+
+	progress := fmt.Sprintf("\x1b[92m%d\x1b[0m", t.Processed)
+	if c.totalLines > 0 {
+		percent := float64(t.Processed) / float64(c.totalLines) * 100
+		progress = fmt.Sprintf("\x1b[92m%d/%d\x1b[0m %6.2f%%", t.Processed, c.totalLines, percent)
+	}
+
+	line := fmt.Sprintf("[Stat] G:%d B:%d E:%d Off:%d Blk:%d | %s | S:%6.1f/s | Uptime %s | Servers: %d/%d",
+		t.Goods, t.Bads, t.Errors, t.Offline, t.IPBlock,
+		progress, speed, HumanDuration(int64(elapsed.Seconds())),
+		t.ActiveServers, t.Servers)
+
+	fmt.Fprint(c.out, "\r"+strings.Repeat(" ", c.lastLen)+"\r")
+	fmt.Fprint(c.out, line)
+	c.lastLen = len(line)
+}
+
+// RunOptions configures Run.
+type RunOptions struct {
+	Interval   time.Duration
+	OutputFile string   // optional aggregated JSON snapshot
+	Console    *Console // optional console UI
+	Stop       <-chan struct{}
+}
+
+// Run polls src until opts.Stop is closed, writing the aggregated totals to
+// the console and the output file on every iteration.
+func Run(src Source, opts RunOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	start := time.Now()
+	for {
+		stats, err := src.Collect()
+		if err != nil {
+			log.Printf("collect error: %v", err)
+		}
+		now := time.Now()
+		totals := Sum(stats, now)
+
+		if opts.OutputFile != "" {
+			if err := writeSnapshot(opts.OutputFile, totals, start, now); err != nil {
+				log.Printf("write json: %v", err)
+			}
+		}
+		if opts.Console != nil {
+			opts.Console.Render(totals)
+		}
+
+		select {
+		case <-time.After(opts.Interval):
+		case <-opts.Stop:
+			return
+		}
+	}
+}
+
+func writeSnapshot(path string, t Totals, start, now time.Time) error {
+	var rps float64
+	if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+		rps = float64(t.Processed) / elapsed
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"goods":          t.Goods,
+		"bads":           t.Bads,
+		"errors":         t.Errors,
+		"offline":        t.Offline,
+		"ipblock":        t.IPBlock,
+		"processed":      t.Processed,
+		"rps":            rps,
+		"servers":        t.Servers,
+		"active_servers": t.ActiveServers,
+		"timestamp":      now.Unix(),
+		"uptime":         int(now.Sub(start).Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
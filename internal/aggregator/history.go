@@ -0,0 +1,103 @@
+package aggregator
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistoryRetention is how long samples are kept when no explicit
+// retention is configured.
+const DefaultHistoryRetention = 24 * time.Hour
+
+// Sample is a single point of the per-server time series.
+type Sample struct {
+	Timestamp int64   `json:"timestamp"`
+	RPS       float64 `json:"rps"`
+	Processed int     `json:"processed"`
+	Goods     int     `json:"goods"`
+	Errors    int     `json:"errors"`
+}
+
+// History keeps a rolling time series of samples for every server.
+type History struct {
+	mu        sync.RWMutex
+	retention time.Duration
+	series    map[string][]Sample
+}
+
+// NewHistory returns History that drops samples older than retention.
+func NewHistory(retention time.Duration) *History {
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+	return &History{
+		retention: retention,
+		series:    make(map[string][]Sample),
+	}
+}
+
+// Retention reports how far back samples are kept.
+func (h *History) Retention() time.Duration {
+	return h.retention
+}
+
+// Add records info as a sample taken at the given time. RPS is derived from
+// the processed counter of the previous sample for the same server.
+func (h *History) Add(info ServerInfo, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Sample{
+		Timestamp: at.Unix(),
+		Processed: info.Processed,
+		Goods:     info.Goods,
+		Errors:    info.Errors,
+	}
+	points := h.series[info.IP]
+	if n := len(points); n > 0 {
+		prev := points[n-1]
+		if elapsed := s.Timestamp - prev.Timestamp; elapsed > 0 && s.Processed >= prev.Processed {
+			s.RPS = float64(s.Processed-prev.Processed) / float64(elapsed)
+		}
+	}
+	points = append(points, s)
+
+	// Trim samples that fell out of the retention window.
+	cutoff := at.Add(-h.retention).Unix()
+	drop := 0
+	for drop < len(points) && points[drop].Timestamp < cutoff {
+		drop++
+	}
+	if drop > 0 {
+		points = append([]Sample(nil), points[drop:]...)
+	}
+	h.series[info.IP] = points
+}
+
+// Get returns samples for ip recorded at or after since, oldest first.
+func (h *History) Get(ip string, since time.Time) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	points := h.series[ip]
+	cutoff := since.Unix()
+	out := make([]Sample, 0, len(points))
+	for _, p := range points {
+		if p.Timestamp >= cutoff {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Servers lists the IPs that have at least one recorded sample.
+func (h *History) Servers() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ips := make([]string, 0, len(h.series))
+	for ip := range h.series {
+		ips = append(ips, ip)
+	}
+	return ips
+}
@@ -0,0 +1,55 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryComputesRPS(t *testing.T) {
+	h := NewHistory(time.Hour)
+	base := time.Unix(1_700_000_000, 0)
+
+	h.Add(ServerInfo{IP: "10.0.0.1", Processed: 100, Goods: 1}, base)
+	h.Add(ServerInfo{IP: "10.0.0.1", Processed: 400, Goods: 3, Errors: 2}, base.Add(10*time.Second))
+
+	samples := h.Get("10.0.0.1", base)
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(samples))
+	}
+	if samples[0].RPS != 0 {
+		t.Fatalf("first sample should have zero RPS, got %v", samples[0].RPS)
+	}
+	if samples[1].RPS != 30 {
+		t.Fatalf("expected RPS 30, got %v", samples[1].RPS)
+	}
+	if samples[1].Goods != 3 || samples[1].Errors != 2 {
+		t.Fatalf("unexpected sample: %+v", samples[1])
+	}
+}
+
+func TestHistoryRetentionAndSince(t *testing.T) {
+	h := NewHistory(time.Hour)
+	base := time.Unix(1_700_000_000, 0)
+
+	h.Add(ServerInfo{IP: "a", Processed: 1}, base)
+	h.Add(ServerInfo{IP: "a", Processed: 2}, base.Add(30*time.Minute))
+	h.Add(ServerInfo{IP: "a", Processed: 3}, base.Add(90*time.Minute))
+	h.Add(ServerInfo{IP: "b", Processed: 5}, base.Add(90*time.Minute))
+
+	all := h.Get("a", time.Unix(0, 0))
+	if len(all) != 2 || all[0].Processed != 2 {
+		t.Fatalf("expected oldest sample to be trimmed, got %+v", all)
+	}
+
+	recent := h.Get("a", base.Add(time.Hour))
+	if len(recent) != 1 || recent[0].Processed != 3 {
+		t.Fatalf("unexpected recent samples: %+v", recent)
+	}
+
+	if got := h.Get("missing", time.Unix(0, 0)); len(got) != 0 {
+		t.Fatalf("expected no samples for unknown server, got %+v", got)
+	}
+	if n := len(h.Servers()); n != 2 {
+		t.Fatalf("expected 2 servers, got %d", n)
+	}
+}
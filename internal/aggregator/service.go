@@ -0,0 +1,215 @@
+package aggregator
+
+import (
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultRefreshInterval is how often the service re-aggregates even when no
+// stats file has changed, so that system metrics and history stay fresh.
+const DefaultRefreshInterval = 30 * time.Second
+
+// debounceDelay groups bursts of file events into a single refresh. Workers
+// rewrite their stats files every second.
+const debounceDelay = 250 * time.Millisecond
+
+// Service keeps the aggregated server state up to date in the background by
+// watching the stats directory and notifies subscribers about changes.
+type Service struct {
+	aggr     *Aggregator
+	interval time.Duration
+
+	mu          sync.RWMutex
+	current     []ServerInfo
+	subscribers []func([]ServerInfo)
+
+	watcher  *fsnotify.Watcher
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewService returns Service that refreshes a every interval and whenever a
+// file in its directory changes.
+func NewService(a *Aggregator, interval time.Duration) *Service {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Service{
+		aggr:     a,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Aggregator returns the underlying aggregator.
+func (s *Service) Aggregator() *Aggregator {
+	return s.aggr
+}
+
+// Subscribe registers fn to be called with the servers whose info changed
+// after each refresh. fn is called from the service goroutine.
+func (s *Service) Subscribe(fn func([]ServerInfo)) {
+	s.mu.Lock()
+	s.subscribers = append(s.subscribers, fn)
+	s.mu.Unlock()
+}
+
+// Current returns the latest aggregated state. When the service has not
+// refreshed yet it aggregates on demand.
+func (s *Service) Current() []ServerInfo {
+	s.mu.RLock()
+	cur := s.current
+	s.mu.RUnlock()
+	if cur != nil {
+		return append([]ServerInfo(nil), cur...)
+	}
+	if _, err := s.Refresh(); err != nil {
+		log.Printf("aggregator refresh error: %v", err)
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ServerInfo(nil), s.current...)
+}
+
+// Refresh re-aggregates the stats directory, stores the result and notifies
+// subscribers about changed servers. It returns the changed entries.
+func (s *Service) Refresh() ([]ServerInfo, error) {
+	infos, err := s.aggr.GetServerInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	changed := diffServerInfo(s.current, infos)
+	s.current = infos
+	subs := make([]func([]ServerInfo), len(s.subscribers))
+	copy(subs, s.subscribers)
+	s.mu.Unlock()
+
+	if len(changed) > 0 {
+		for _, fn := range subs {
+			fn(changed)
+		}
+	}
+	return changed, nil
+}
+
+// Start sets up the directory watcher and launches the refresh loop. When the
+// watcher cannot be created the service falls back to periodic polling.
+func (s *Service) Start() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("stats watcher unavailable, polling every %v: %v", s.interval, err)
+	} else {
+		s.watcher = w
+		s.watchTree(s.aggr.dir)
+	}
+
+	if _, err := s.Refresh(); err != nil {
+		log.Printf("aggregator refresh error: %v", err)
+	}
+
+	go s.loop()
+	return err
+}
+
+// Stop terminates the refresh loop and closes the watcher.
+func (s *Service) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		if s.watcher != nil {
+			s.watcher.Close()
+		}
+	})
+}
+
+// watchTree adds root and all of its subdirectories to the watcher because
+// fsnotify does not watch recursively.
+func (s *Service) watchTree(root string) {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if err := s.watcher.Add(path); err != nil {
+				log.Printf("stats watch error for %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("stats watch walk error: %v", err)
+	}
+}
+
+func (s *Service) loop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if s.watcher != nil {
+		events = s.watcher.Events
+		errs = s.watcher.Errors
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 && isDir(ev.Name) {
+				s.watchTree(ev.Name)
+				continue
+			}
+			if !isStatsFile(filepath.Base(ev.Name)) {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.After(debounceDelay)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			log.Printf("stats watcher error: %v", err)
+		case <-debounce:
+			debounce = nil
+			if _, err := s.Refresh(); err != nil {
+				log.Printf("aggregator refresh error: %v", err)
+			}
+		case <-ticker.C:
+			if _, err := s.Refresh(); err != nil {
+				log.Printf("aggregator refresh error: %v", err)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// diffServerInfo returns the entries of next that are new or differ from the
+// entry with the same IP in prev.
+func diffServerInfo(prev, next []ServerInfo) []ServerInfo {
+	old := make(map[string]ServerInfo, len(prev))
+	for _, p := range prev {
+		old[p.IP] = p
+	}
+	var changed []ServerInfo
+	for _, n := range next {
+		if p, ok := old[n.IP]; !ok || p != n {
+			changed = append(changed, n)
+		}
+	}
+	return changed
+}
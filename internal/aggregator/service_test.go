@@ -0,0 +1,54 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffServerInfo(t *testing.T) {
+	prev := []ServerInfo{{IP: "a", Goods: 1}, {IP: "b", Goods: 2}}
+	next := []ServerInfo{{IP: "a", Goods: 1}, {IP: "b", Goods: 3}, {IP: "c"}}
+
+	changed := diffServerInfo(prev, next)
+	if len(changed) != 2 || changed[0].IP != "b" || changed[1].IP != "c" {
+		t.Fatalf("unexpected diff: %+v", changed)
+	}
+	if got := diffServerInfo(next, next); len(got) != 0 {
+		t.Fatalf("expected no changes, got %+v", got)
+	}
+}
+
+func TestServicePushesOnStatsChange(t *testing.T) {
+	dir := t.TempDir()
+	writeStatsFile(t, dir, "stats_1.json", StatsFile{Goods: 1, Processed: 1})
+
+	svc := NewService(New(dir), time.Hour)
+	updates := make(chan []ServerInfo, 16)
+	svc.Subscribe(func(changed []ServerInfo) { updates <- changed })
+
+	if err := svc.Start(); err != nil {
+		t.Skipf("fsnotify unavailable: %v", err)
+	}
+	defer svc.Stop()
+
+	if cur := svc.Current(); len(cur) != 1 || cur[0].Goods != 1 {
+		t.Fatalf("unexpected initial state: %+v", cur)
+	}
+
+	writeStatsFile(t, dir, "stats_2.json", StatsFile{Goods: 4, Processed: 4})
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case changed := <-updates:
+			if len(changed) == 1 && changed[0].Goods == 5 {
+				if cur := svc.Current(); cur[0].Goods != 5 {
+					t.Fatalf("current state not updated: %+v", cur)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("no update pushed after stats file change")
+		}
+	}
+}
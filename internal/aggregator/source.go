@@ -0,0 +1,241 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"vpn-bruteforce-client/internal/breaker"
+	"vpn-bruteforce-client/internal/collect"
+)
+
+// activeWindow is how recent a worker's stats timestamp must be for the
+// worker to be counted as active.
+const activeWindow = 30 * time.Second
+
+// WorkerStats holds statistics reported by a single worker.
+type WorkerStats struct {
+	IP        string  `json:"ip"`
+	RunID     string  `json:"run_id,omitempty"`
+	Goods     int64   `json:"goods"`
+	Bads      int64   `json:"bads"`
+	Errors    int64   `json:"errors"`
+	Offline   int64   `json:"offline"`
+	IPBlock   int64   `json:"ipblock"`
+	Processed int64   `json:"processed"`
+	RPS       float64 `json:"rps"`
+	Timestamp int64   `json:"timestamp"`
+
+	// Exclusions counts skipped targets per exclusion rule.
+	Exclusions map[string]int64 `json:"exclusions,omitempty"`
+
+	// Breakers lists the worker's open circuit breakers.
+	Breakers []breaker.Status `json:"breakers,omitempty"`
+}
+
+// Totals holds combined metrics from all workers.
+type Totals struct {
+	Goods         int64 `json:"goods"`
+	Bads          int64 `json:"bads"`
+	Errors        int64 `json:"errors"`
+	Offline       int64 `json:"offline"`
+	IPBlock       int64 `json:"ipblock"`
+	Processed     int64 `json:"processed"`
+	Servers       int   `json:"servers"`
+	ActiveServers int   `json:"active_servers"`
+}
+
+// Sum combines worker stats. A worker is active when it reported within the
+// last 30 seconds relative to now.
+func Sum(stats []WorkerStats, now time.Time) Totals {
+	var t Totals
+	for _, s := range stats {
+		t.Goods += s.Goods
+		t.Bads += s.Bads
+		t.Errors += s.Errors
+		t.Offline += s.Offline
+		t.IPBlock += s.IPBlock
+		t.Processed += s.Processed
+		if s.Timestamp > 0 && now.Sub(time.Unix(s.Timestamp, 0)) < activeWindow {
+			t.ActiveServers++
+		}
+	}
+	t.Servers = len(stats)
+	return t
+}
+
+// Source provides stats of one or more workers.
+type Source interface {
+	Collect() ([]WorkerStats, error)
+}
+
+// DirSource reads stats_*.json files from a local directory tree.
+type DirSource struct {
+	Dir string
+}
+
+// Collect walks the directory and parses every stats file. Unreadable or
+// malformed files are logged and skipped.
+func (d DirSource) Collect() ([]WorkerStats, error) {
+	dir := d.Dir
+	if dir == "" {
+		dir = "."
+	}
+	var res []WorkerStats
+	err := walkDir(dir, func(path string, e fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			var name string
+			if e != nil {
+				name = e.Name()
+			} else {
+				name = filepath.Base(path)
+			}
+			if isStatsFile(name) {
+				log.Printf("stats walk error for %s: %v", path, walkErr)
+				return nil
+			}
+			return walkErr
+		}
+		if e.IsDir() || !isStatsFile(e.Name()) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("stats read error for %s: %v", path, err)
+			return nil
+		}
+		s, err := parseWorkerStats(e.Name(), data)
+		if err != nil {
+			log.Printf("stats parse error for %s: %v", path, err)
+			return nil
+		}
+		res = append(res, s)
+		return nil
+	})
+	return res, err
+}
+
+// SSHSource pulls stats files from remote workers over SSH.
+type SSHSource struct {
+	Workers   []collect.Credential
+	RemoteDir string
+	Timeout   time.Duration
+}
+
+// Collect fetches stats from every worker. Failing workers are logged and
+// skipped so one unreachable host does not hide the others.
+func (s SSHSource) Collect() ([]WorkerStats, error) {
+	var res []WorkerStats
+	for _, w := range s.Workers {
+		stats, err := s.collectWorker(w)
+		if err != nil {
+			log.Printf("stats pull error for %s: %v", w.IP, err)
+			continue
+		}
+		res = append(res, stats...)
+	}
+	return res, nil
+}
+
+func (s SSHSource) collectWorker(w collect.Credential) ([]WorkerStats, error) {
+	client, err := dialWorker(w, s.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	sc, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, err
+	}
+	defer sc.Close()
+
+	entries, err := sc.ReadDir(s.RemoteDir)
+	if err != nil {
+		return nil, err
+	}
+	var res []WorkerStats
+	for _, e := range entries {
+		if e.IsDir() || !isStatsFile(e.Name()) {
+			continue
+		}
+		f, err := sc.Open(path.Join(s.RemoteDir, e.Name()))
+		if err != nil {
+			log.Printf("stats read error for %s:%s: %v", w.IP, e.Name(), err)
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			log.Printf("stats read error for %s:%s: %v", w.IP, e.Name(), err)
+			continue
+		}
+		st, err := parseWorkerStats(e.Name(), data)
+		if err != nil {
+			log.Printf("stats parse error for %s:%s: %v", w.IP, e.Name(), err)
+			continue
+		}
+		st.IP = w.IP
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// dialWorker connects to a worker. The password field may also hold a path to
+// a private key file.
+func dialWorker(w collect.Credential, timeout time.Duration) (*ssh.Client, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	cfg := &ssh.ClientConfig{
+		User:            w.Username,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+	if key, err := os.ReadFile(expandHome(w.Password)); err == nil {
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("parse key: %w", err)
+		}
+		cfg.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	} else {
+		cfg.Auth = []ssh.AuthMethod{ssh.Password(w.Password)}
+	}
+	addr := w.IP
+	if !strings.Contains(addr, ":") {
+		addr += ":22"
+	}
+	return ssh.Dial("tcp", addr, cfg)
+}
+
+func expandHome(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if h, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(h, p[2:])
+		}
+	}
+	return p
+}
+
+// parseWorkerStats decodes a stats file. The worker IP defaults to the part of
+// the file name between "stats_" and ".json".
+func parseWorkerStats(name string, data []byte) (WorkerStats, error) {
+	var s WorkerStats
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+	if s.IP == "" {
+		s.IP = strings.TrimSuffix(strings.TrimPrefix(name, "stats_"), ".json")
+	}
+	return s, nil
+}
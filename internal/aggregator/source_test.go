@@ -0,0 +1,56 @@
+package aggregator
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDirSourceCollect(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "worker2")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	now := time.Now().Unix()
+	if err := os.WriteFile(filepath.Join(dir, "stats_10.0.0.1.json"), []byte(`{"goods":2,"processed":10,"timestamp":`+strconv.FormatInt(now, 10)+`}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "stats_10.0.0.2.json"), []byte(`{"goods":1,"bads":4,"processed":5,"timestamp":1}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stats_broken.json"), []byte(`{`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	stats, err := DirSource{Dir: dir}.Collect()
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 workers, got %+v", stats)
+	}
+	ips := map[string]bool{}
+	for _, s := range stats {
+		ips[s.IP] = true
+	}
+	if !ips["10.0.0.1"] || !ips["10.0.0.2"] {
+		t.Fatalf("worker IPs not derived from file names: %+v", stats)
+	}
+
+	totals := Sum(stats, time.Unix(now, 0))
+	if totals.Goods != 3 || totals.Bads != 4 || totals.Processed != 15 {
+		t.Fatalf("unexpected totals: %+v", totals)
+	}
+	if totals.Servers != 2 || totals.ActiveServers != 1 {
+		t.Fatalf("unexpected server counts: %+v", totals)
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	if got := HumanDuration(3725); got != "01:02:05" {
+		t.Fatalf("HumanDuration(3725) = %q", got)
+	}
+}
@@ -0,0 +1,258 @@
+// Package alerting evaluates threshold rules against the aggregated worker
+// stats and dispatches alerts when a rule starts or stops firing.
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// Alert states.
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Metrics understood by rules.
+const (
+	MetricErrorRate   = "error_rate"
+	MetricOfflineRate = "offline_rate"
+	MetricRPS         = "rps"
+	MetricIPBlock     = "ipblock"
+)
+
+// maxRecent is the number of alerts kept for Recent.
+const maxRecent = 100
+
+// notifyTimeout bounds a single notifier call.
+const notifyTimeout = 10 * time.Second
+
+// Sample is a snapshot of cumulative counters taken at Time. Active is the
+// number of workers that reported recently; rules are not evaluated while it
+// is zero.
+type Sample struct {
+	Time      time.Time
+	Errors    int64
+	Offline   int64
+	IPBlock   int64
+	Processed int64
+	Active    int
+}
+
+// Alert is a rule transition.
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Metric    string    `json:"metric"`
+	Op        string    `json:"op"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	Severity  string    `json:"severity"`
+	State     string    `json:"state"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+}
+
+// Notifier delivers alerts to an external system.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// ruleState tracks how long a rule's condition has held.
+type ruleState struct {
+	pendingSince time.Time
+	firing       bool
+}
+
+// Engine evaluates rules against consecutive samples. Alerts are passed to
+// the sinks synchronously and to the notifiers in the background.
+type Engine struct {
+	mu        sync.Mutex
+	rules     []config.AlertRule
+	states    []ruleState
+	prev      *Sample
+	recent    []Alert
+	sinks     []func(Alert)
+	notifiers []Notifier
+}
+
+// New validates rules and returns an engine for them.
+func New(rules []config.AlertRule) (*Engine, error) {
+	for i, r := range rules {
+		if err := Validate(r); err != nil {
+			return nil, fmt.Errorf("alert rule %d (%s): %w", i+1, r.Name, err)
+		}
+	}
+	return &Engine{rules: rules, states: make([]ruleState, len(rules))}, nil
+}
+
+// Validate checks a single rule.
+func Validate(r config.AlertRule) error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Metric {
+	case MetricErrorRate, MetricOfflineRate, MetricRPS, MetricIPBlock:
+	default:
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	switch r.Op {
+	case ">", ">=", "<", "<=":
+	default:
+		return fmt.Errorf("unknown operator %q", r.Op)
+	}
+	if r.For < 0 {
+		return fmt.Errorf("negative duration")
+	}
+	return nil
+}
+
+// AddSink registers fn to receive every alert.
+func (e *Engine) AddSink(fn func(Alert)) {
+	e.mu.Lock()
+	e.sinks = append(e.sinks, fn)
+	e.mu.Unlock()
+}
+
+// AddNotifier registers an external notifier.
+func (e *Engine) AddNotifier(n Notifier) {
+	e.mu.Lock()
+	e.notifiers = append(e.notifiers, n)
+	e.mu.Unlock()
+}
+
+// Rules returns the configured rules.
+func (e *Engine) Rules() []config.AlertRule {
+	return append([]config.AlertRule(nil), e.rules...)
+}
+
+// Recent returns the latest alerts, newest first.
+func (e *Engine) Recent() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Alert, len(e.recent))
+	for i, a := range e.recent {
+		out[len(e.recent)-1-i] = a
+	}
+	return out
+}
+
+// Evaluate computes the metrics between the previous sample and s and
+// returns the alerts of rules that started or stopped firing.
+func (e *Engine) Evaluate(s Sample) []Alert {
+	e.mu.Lock()
+	prev := e.prev
+	e.prev = &s
+	if prev == nil {
+		e.mu.Unlock()
+		return nil
+	}
+	metrics, ok := computeMetrics(*prev, s)
+
+	var alerts []Alert
+	for i, r := range e.rules {
+		st := &e.states[i]
+		value, known := metrics[r.Metric]
+		if !ok || !known || !compare(value, r.Op, r.Threshold) {
+			st.pendingSince = time.Time{}
+			if st.firing {
+				st.firing = false
+				alerts = append(alerts, newAlert(r, value, StateResolved, s.Time))
+			}
+			continue
+		}
+		if st.pendingSince.IsZero() {
+			st.pendingSince = prev.Time
+		}
+		if !st.firing && s.Time.Sub(st.pendingSince) >= r.For {
+			st.firing = true
+			alerts = append(alerts, newAlert(r, value, StateFiring, s.Time))
+		}
+	}
+	e.recent = append(e.recent, alerts...)
+	if len(e.recent) > maxRecent {
+		e.recent = e.recent[len(e.recent)-maxRecent:]
+	}
+	sinks := append([](func(Alert)){}, e.sinks...)
+	notifiers := append([]Notifier(nil), e.notifiers...)
+	e.mu.Unlock()
+
+	for _, a := range alerts {
+		for _, fn := range sinks {
+			fn(a)
+		}
+		for _, n := range notifiers {
+			go notify(n, a)
+		}
+	}
+	return alerts
+}
+
+func notify(n Notifier, a Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := n.Notify(ctx, a); err != nil {
+		log.Printf("alert notification error: %v", err)
+	}
+}
+
+// computeMetrics derives rates from two samples. ok is false when there is
+// nothing to evaluate: no active workers, no elapsed time or counters that
+// went backwards because workers restarted.
+func computeMetrics(prev, cur Sample) (map[string]float64, bool) {
+	dt := cur.Time.Sub(prev.Time).Seconds()
+	dProcessed := cur.Processed - prev.Processed
+	if cur.Active == 0 || dt <= 0 || dProcessed < 0 || cur.Errors < prev.Errors || cur.IPBlock < prev.IPBlock || cur.Offline < prev.Offline {
+		return nil, false
+	}
+	m := map[string]float64{
+		MetricRPS:     float64(dProcessed) / dt,
+		MetricIPBlock: float64(cur.IPBlock-prev.IPBlock) / dt * 60,
+	}
+	if dProcessed > 0 {
+		m[MetricErrorRate] = float64(cur.Errors-prev.Errors) / float64(dProcessed) * 100
+		m[MetricOfflineRate] = float64(cur.Offline-prev.Offline) / float64(dProcessed) * 100
+	}
+	return m, true
+}
+
+func compare(v float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	}
+	return false
+}
+
+func newAlert(r config.AlertRule, value float64, state string, t time.Time) Alert {
+	severity := r.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+	a := Alert{
+		Rule:      r.Name,
+		Metric:    r.Metric,
+		Op:        r.Op,
+		Threshold: r.Threshold,
+		Value:     value,
+		Severity:  severity,
+		State:     state,
+		Time:      t.UTC(),
+	}
+	if state == StateFiring {
+		a.Message = fmt.Sprintf("%s: %s %.2f %s %.2f", r.Name, r.Metric, value, r.Op, r.Threshold)
+	} else {
+		a.Message = fmt.Sprintf("%s resolved", r.Name)
+	}
+	return a
+}
@@ -0,0 +1,95 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+func TestErrorRateFiresAfterDurationAndResolves(t *testing.T) {
+	e, err := New([]config.AlertRule{{Name: "errors", Metric: MetricErrorRate, Op: ">", Threshold: 50, For: 20 * time.Second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Alert
+	e.AddSink(func(a Alert) { got = append(got, a) })
+
+	base := time.Unix(1000, 0)
+	// 80% errors from t=0 on; the rule needs 20s to fire.
+	e.Evaluate(Sample{Time: base, Active: 1})
+	e.Evaluate(Sample{Time: base.Add(10 * time.Second), Errors: 80, Processed: 100, Active: 1})
+	if len(got) != 0 {
+		t.Fatalf("fired too early: %+v", got)
+	}
+	e.Evaluate(Sample{Time: base.Add(20 * time.Second), Errors: 160, Processed: 200, Active: 1})
+	if len(got) != 1 || got[0].State != StateFiring || got[0].Value != 80 {
+		t.Fatalf("expected firing alert, got %+v", got)
+	}
+	// Still failing: no duplicate alert.
+	e.Evaluate(Sample{Time: base.Add(30 * time.Second), Errors: 240, Processed: 300, Active: 1})
+	// Recovered.
+	e.Evaluate(Sample{Time: base.Add(40 * time.Second), Errors: 240, Processed: 400, Active: 1})
+	if len(got) != 2 || got[1].State != StateResolved {
+		t.Fatalf("expected resolved alert, got %+v", got)
+	}
+	if r := e.Recent(); len(r) != 2 || r[0].State != StateResolved {
+		t.Fatalf("Recent() = %+v", r)
+	}
+}
+
+func TestNoEvaluationWithoutActiveWorkers(t *testing.T) {
+	e, _ := New([]config.AlertRule{{Name: "slow", Metric: MetricRPS, Op: "<", Threshold: 1}})
+	base := time.Unix(1000, 0)
+	e.Evaluate(Sample{Time: base})
+	if a := e.Evaluate(Sample{Time: base.Add(time.Minute)}); len(a) != 0 {
+		t.Fatalf("idle dashboard raised %+v", a)
+	}
+}
+
+func TestIPBlockSpike(t *testing.T) {
+	e, _ := New([]config.AlertRule{{Name: "blocks", Metric: MetricIPBlock, Op: ">", Threshold: 100}})
+	base := time.Unix(1000, 0)
+	e.Evaluate(Sample{Time: base, Active: 2})
+	a := e.Evaluate(Sample{Time: base.Add(30 * time.Second), IPBlock: 90, Processed: 500, Active: 2})
+	if len(a) != 1 || a[0].Value != 180 {
+		t.Fatalf("expected spike of 180/min, got %+v", a)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	bad := []config.AlertRule{
+		{Metric: MetricRPS, Op: "<"},
+		{Name: "x", Metric: "latency", Op: "<"},
+		{Name: "x", Metric: MetricRPS, Op: "=="},
+	}
+	for _, r := range bad {
+		if err := Validate(r); err == nil {
+			t.Errorf("Validate(%+v) succeeded", r)
+		}
+	}
+	for _, r := range config.DefaultAlertRules() {
+		if err := Validate(r); err != nil {
+			t.Errorf("default rule %s: %v", r.Name, err)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got Alert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	if err := (Webhook{URL: ts.URL}).Notify(context.Background(), Alert{Rule: "r", State: StateFiring}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rule != "r" {
+		t.Fatalf("webhook received %+v", got)
+	}
+}
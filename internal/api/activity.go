@@ -0,0 +1,90 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// activityPoll is how often new activity entries, including findings and
+// task changes written by scanners, are pushed to WebSocket clients.
+const activityPoll = 2 * time.Second
+
+// maxActivityPageSize bounds page_size of /api/activity.
+const maxActivityPageSize = 200
+
+// handleActivity returns the activity feed, newest first, paginated with
+// page and page_size and optionally restricted to a comma separated list
+// of kinds (?kind=finding,task,config,alert).
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	page, pageSize := getPaginationParams(r)
+	if pageSize > maxActivityPageSize {
+		pageSize = maxActivityPageSize
+	}
+	var kinds []string
+	if v := r.URL.Query().Get("kind"); v != "" {
+		kinds = strings.Split(v, ",")
+	}
+	entries, total, err := s.db.ListActivity(kinds, page, pageSize)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: entries, Meta: &MetaData{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}})
+}
+
+// recordActivity adds an entry to the activity feed; errors are logged.
+func (s *Server) recordActivity(kind, level, message string, ref int) {
+	if s.db == nil {
+		return
+	}
+	if err := s.db.RecordActivity(kind, level, message, ref); err != nil {
+		log.Printf("activity error: %v", err)
+	}
+}
+
+// runActivity broadcasts every new activity entry as an activity message
+// until stop is closed.
+func (s *Server) runActivity(stop <-chan struct{}) {
+	since := time.Now()
+	t := time.NewTicker(activityPoll)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			since = s.pollActivity(since)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// pollActivity broadcasts the entries newer than since and returns the
+// time of the newest one.
+func (s *Server) pollActivity(since time.Time) time.Time {
+	entries, err := s.db.ActivitySince(since)
+	if err != nil {
+		log.Printf("activity error: %v", err)
+		return since
+	}
+	for _, a := range entries {
+		s.wsServer.BroadcastMessage("activity", a)
+		if a.At.After(since) {
+			since = a.At
+		}
+	}
+	return since
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/alerting"
+	"vpn-bruteforce-client/internal/config"
+)
+
+// EnableAlerts evaluates cfg's rules against the worker stats in STATS_DIR
+// every cfg.Interval once the server is started. Alerts go to the logs
+// table, to WebSocket clients as "alert" messages and to the webhooks.
+func (s *Server) EnableAlerts(cfg config.AlertsConfig) error {
+	engine, err := alerting.New(cfg.Rules)
+	if err != nil {
+		return err
+	}
+	engine.AddSink(func(a alerting.Alert) {
+		level := "warning"
+		switch {
+		case a.State == alerting.StateResolved:
+			level = "info"
+		case a.Severity == "critical":
+			level = "error"
+		}
+		log.Printf("🚨 alert %s: %s", a.State, a.Message)
+		s.logEvent(level, a.Message, "alerts")
+		s.wsServer.BroadcastMessage("alert", a)
+	})
+	for _, url := range cfg.Webhooks {
+		engine.AddNotifier(alerting.Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}})
+	}
+	s.alerts = engine
+	s.alertInterval = cfg.Interval
+	if s.alertInterval <= 0 {
+		s.alertInterval = 15 * time.Second
+	}
+	s.alertsStop = make(chan struct{})
+	return nil
+}
+
+// runAlerts samples the worker stats until stop is closed.
+func (s *Server) runAlerts(stop <-chan struct{}) {
+	src := aggregator.DirSource{Dir: os.Getenv("STATS_DIR")}
+	ticker := time.NewTicker(s.alertInterval)
+	defer ticker.Stop()
+	for {
+		stats, err := src.Collect()
+		if err != nil {
+			log.Printf("alert stats error: %v", err)
+		} else {
+			now := time.Now()
+			t := aggregator.Sum(stats, now)
+			s.alerts.Evaluate(alerting.Sample{
+				Time:      now,
+				Errors:    t.Errors,
+				Offline:   t.Offline,
+				IPBlock:   t.IPBlock,
+				Processed: t.Processed,
+				Active:    t.ActiveServers,
+			})
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// handleAlerts returns the configured rules and the latest alerts.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.alerts == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "alerting is not enabled"})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"rules":  s.alerts.Rules(),
+		"recent": s.alerts.Recent(),
+	}})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/report"
+)
+
+// analyticsTopCredentials is the number of credentials listed per vendor.
+const analyticsTopCredentials = 10
+
+// VendorAnalytics is the success rate summary of one VPN type.
+type VendorAnalytics struct {
+	VPNType      string           `json:"vpn_type"`
+	Runs         int              `json:"runs"`
+	Processed    int64            `json:"processed"`
+	Goods        int64            `json:"goods"`
+	Bads         int64            `json:"bads"`
+	Errors       int64            `json:"errors"`
+	Offline      int64            `json:"offline"`
+	IPBlock      int64            `json:"ipblock"`
+	HitRate      float64          `json:"hit_rate"`
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+	ErrorClasses map[string]int64 `json:"error_classes"`
+	Findings     int              `json:"findings"`
+	// TopCredentials are the credentials found most often in the range.
+	TopCredentials []db.CredentialCount `json:"top_credentials"`
+
+	latencyMs, timed int64
+}
+
+// handleVendorAnalytics reports hit rate, average check latency, error
+// distribution and the most found credentials per VPN type. Counters come
+// from the run reports started in the range and findings from the
+// findings table. The range is ?from= and ?to= (RFC 3339) or the last
+// ?hours= (default 24).
+func (s *Server) handleVendorAnalytics(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	from, to, err := analyticsRange(r)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+
+	vendors := make(map[string]*VendorAnalytics)
+	get := func(name string) *VendorAnalytics {
+		v := vendors[name]
+		if v == nil {
+			v = &VendorAnalytics{VPNType: name, ErrorClasses: map[string]int64{}, TopCredentials: []db.CredentialCount{}}
+			vendors[name] = v
+		}
+		return v
+	}
+
+	summaries, err := report.List(reportsDir())
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	for _, sum := range summaries {
+		if sum.StartedAt.Before(from) || !sum.StartedAt.Before(to) {
+			continue
+		}
+		rep, err := report.Load(reportsDir(), sum.RunID)
+		if err != nil {
+			continue
+		}
+		for name, c := range rep.Vendors {
+			v := get(name)
+			v.Runs++
+			v.Processed += c.Processed
+			v.Goods += c.Goods
+			v.Bads += c.Bads
+			v.Errors += c.Errors
+			v.Offline += c.Offline
+			v.IPBlock += c.IPBlock
+			v.latencyMs += c.LatencyMs
+			v.timed += c.Timed
+		}
+		if rep.VPNType != "" {
+			v := get(rep.VPNType)
+			for _, ec := range rep.TopErrors {
+				v.ErrorClasses[ec.Class] += ec.Count
+			}
+		}
+	}
+
+	if s.db != nil {
+		found, err := s.db.FindingsByVendor(from, to, analyticsTopCredentials)
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		for name, f := range found {
+			v := get(name)
+			v.Findings = f.Findings
+			v.TopCredentials = f.TopCredentials
+		}
+	}
+
+	out := make([]*VendorAnalytics, 0, len(vendors))
+	for _, v := range vendors {
+		if v.Processed > 0 {
+			v.HitRate = float64(v.Goods) / float64(v.Processed) * 100
+		}
+		if v.timed > 0 {
+			v.AvgLatencyMs = float64(v.latencyMs) / float64(v.timed)
+		}
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].VPNType < out[j].VPNType })
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"vendors": out,
+	}})
+}
+
+// analyticsRange parses the time range of an analytics request.
+func analyticsRange(r *http.Request) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	to = time.Now()
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid to")
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid from")
+		}
+	} else {
+		hours := 24
+		if v := q.Get("hours"); v != "" {
+			if hours, err = strconv.Atoi(v); err != nil || hours <= 0 {
+				return from, to, fmt.Errorf("invalid hours")
+			}
+		}
+		from = to.Add(-time.Duration(hours) * time.Hour)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("invalid range")
+	}
+	return from, to, nil
+}
+
+// handleCredentialAnalytics ranks credentials by the hits they produced
+// across runs, so dictionaries can be ordered by effectiveness. ?limit=
+// (default 100, at most 1000) bounds the list and ?min_attempts= skips
+// credentials tried fewer times.
+func (s *Server) handleCredentialAnalytics(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not initialized"})
+		return
+	}
+	q := r.URL.Query()
+	limit, minAttempts := 100, 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			s.sendJSON(w, APIResponse{Success: false, Error: "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	if v := q.Get("min_attempts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendJSON(w, APIResponse{Success: false, Error: "invalid min_attempts"})
+			return
+		}
+		minAttempts = n
+	}
+	ranked, err := s.db.RankCredentials(limit, minAttempts)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: ranked})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dbpkg "vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/report"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+func TestVendorAnalytics(t *testing.T) {
+	dir := t.TempDir()
+	os.Setenv("REPORTS_DIR", dir)
+	defer os.Unsetenv("REPORTS_DIR")
+
+	now := time.Now().UTC()
+	reports := []report.Report{
+		{RunID: "r1", VPNType: "fortinet", StartedAt: now.Add(-time.Hour),
+			TopErrors: []stats.ErrorClass{{Class: "timeout", Count: 3}},
+			Vendors:   map[string]stats.VendorCounters{"fortinet": {Goods: 2, Bads: 5, Errors: 3, Processed: 10, LatencyMs: 900, Timed: 9}}},
+		{RunID: "r2", VPNType: "fortinet", StartedAt: now.Add(-2 * time.Hour),
+			TopErrors: []stats.ErrorClass{{Class: "timeout", Count: 1}, {Class: "refused", Count: 1}},
+			Vendors:   map[string]stats.VendorCounters{"fortinet": {Goods: 3, Bads: 5, Errors: 2, Processed: 10, LatencyMs: 300, Timed: 3}}},
+		{RunID: "old", VPNType: "cisco", StartedAt: now.Add(-72 * time.Hour),
+			Vendors: map[string]stats.VendorCounters{"cisco": {Goods: 1, Processed: 1}}},
+	}
+	for _, r := range reports {
+		if _, err := r.Write(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := dbpkg.Connect(dbpkg.Config{Driver: dbpkg.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+	for _, f := range []dbpkg.Finding{
+		{VPNType: "fortinet", IP: "10.0.0.1", Username: "admin", Password: "admin"},
+		{VPNType: "fortinet", IP: "10.0.0.2", Username: "admin", Password: "admin"},
+		{VPNType: "fortinet", IP: "10.0.0.3", Username: "vpn", Password: "vpn123"},
+		{VPNType: "cisco", IP: "10.0.0.4", Username: "cisco", Password: "cisco", FoundAt: now.Add(-72 * time.Hour)},
+	} {
+		if _, err := d.InsertFinding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := NewServer(stats.New(), 0, d)
+	rec := httptest.NewRecorder()
+	srv.handleVendorAnalytics(rec, httptest.NewRequest("GET", "/api/analytics/vendors?hours=24", nil))
+	var resp struct {
+		Success bool
+		Error   string
+		Data    struct {
+			Vendors []VendorAnalytics
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+		t.Fatalf("response %s: %v", rec.Body, err)
+	}
+	if len(resp.Data.Vendors) != 1 {
+		t.Fatalf("vendors = %+v", resp.Data.Vendors)
+	}
+	v := resp.Data.Vendors[0]
+	if v.VPNType != "fortinet" || v.Runs != 2 || v.Processed != 20 || v.HitRate != 25 || v.AvgLatencyMs != 100 {
+		t.Fatalf("fortinet = %+v", v)
+	}
+	if v.ErrorClasses["timeout"] != 4 || v.ErrorClasses["refused"] != 1 {
+		t.Fatalf("error classes = %v", v.ErrorClasses)
+	}
+	if v.Findings != 3 || len(v.TopCredentials) != 2 || v.TopCredentials[0] != (dbpkg.CredentialCount{Username: "admin", Password: "admin", Count: 2}) {
+		t.Fatalf("findings = %d, top = %+v", v.Findings, v.TopCredentials)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleVendorAnalytics(rec, httptest.NewRequest("GET", "/api/analytics/vendors?hours=-1", nil))
+	if json.Unmarshal(rec.Body.Bytes(), &resp); resp.Success {
+		t.Fatal("negative hours accepted")
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"sort"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/breaker"
+)
+
+// WorkerBreaker is an open circuit breaker of a worker.
+type WorkerBreaker struct {
+	Worker string `json:"worker"`
+	breaker.Status
+}
+
+// handleBreakers lists the open and half-open circuit breakers of target
+// hosts and proxies reported in the workers' stats files, optionally only
+// those of ?kind=host or ?kind=proxy.
+func (s *Server) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	stats, err := (aggregator.DirSource{Dir: os.Getenv("STATS_DIR")}).Collect()
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	list := []WorkerBreaker{}
+	counts := map[string]int{}
+	for _, st := range stats {
+		for _, b := range st.Breakers {
+			if kind != "" && b.Kind != kind {
+				continue
+			}
+			list = append(list, WorkerBreaker{Worker: st.IP, Status: b})
+			counts[string(b.State)]++
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind > list[j].Kind // proxies first
+		}
+		return list[i].OpenedAt.After(list[j].OpenedAt)
+	})
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"breakers": list,
+		"counts":   counts,
+	}})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vpn-bruteforce-client/internal/stats"
+)
+
+func TestBreakers(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("STATS_DIR", dir)
+	os.WriteFile(filepath.Join(dir, "stats_10.0.0.1.json"), []byte(`{"processed":5,"breakers":[
+		{"kind":"host","key":"1.2.3.4","state":"open","failures":5,"trips":1,"opened_at":"2026-01-01T10:00:00Z"},
+		{"kind":"proxy","key":"socks5://5.6.7.8:1080","state":"half-open","failures":11,"trips":2,"opened_at":"2026-01-01T09:00:00Z"}]}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "stats_10.0.0.2.json"), []byte(`{"processed":5}`), 0o644)
+
+	s := NewServer(stats.New(), 0, nil)
+	var resp struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Breakers []WorkerBreaker `json:"breakers"`
+			Counts   map[string]int  `json:"counts"`
+		} `json:"data"`
+	}
+	rr := httptest.NewRecorder()
+	s.handleBreakers(rr, httptest.NewRequest("GET", "/api/breakers", nil))
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("response %s: %v", rr.Body, err)
+	}
+	b := resp.Data.Breakers
+	if len(b) != 2 || b[0].Kind != "proxy" || b[1].Key != "1.2.3.4" || b[1].Worker != "10.0.0.1" {
+		t.Fatalf("breakers = %+v", b)
+	}
+	if resp.Data.Counts["open"] != 1 || resp.Data.Counts["half-open"] != 1 {
+		t.Fatalf("counts = %v", resp.Data.Counts)
+	}
+
+	rr = httptest.NewRecorder()
+	s.handleBreakers(rr, httptest.NewRequest("GET", "/api/breakers?kind=host", nil))
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data.Breakers) != 1 || resp.Data.Breakers[0].State != "open" {
+		t.Fatalf("host breakers = %+v", resp.Data.Breakers)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"vpn-bruteforce-client/internal/credlint"
+)
+
+// credsDir returns the directory with creds/<vendor>.txt files (CREDS_DIR,
+// default "creds").
+func credsDir() string {
+	if dir := os.Getenv("CREDS_DIR"); dir != "" {
+		return dir
+	}
+	return "creds"
+}
+
+// handleCredentialsLint returns the report written by `vpnctl lint-creds`.
+// Without a report, or with ?refresh=1, the files are linted on demand
+// without modifying them.
+func (s *Server) handleCredentialsLint(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	dir := credsDir()
+	if r.URL.Query().Get("refresh") == "" {
+		if data, err := os.ReadFile(filepath.Join(dir, credlint.ReportFile)); err == nil {
+			var rep credlint.Report
+			if err := json.Unmarshal(data, &rep); err == nil {
+				s.sendJSON(w, APIResponse{Success: true, Data: rep})
+				return
+			}
+		}
+	}
+	rep, err := credlint.LintDir(dir)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: rep})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/discover"
+)
+
+// discoveryTimeout bounds one search engine query, all pages included.
+const discoveryTimeout = 2 * time.Minute
+
+// EnableDiscovery sets up the search engines of cfg that have an account
+// configured for /api/targets/discover.
+func (s *Server) EnableDiscovery(cfg config.DiscoveryConfig) {
+	s.discovery = cfg
+	s.searchers = make(map[string]discover.Searcher)
+	if cfg.Shodan.APIKey != "" {
+		s.searchers[discover.EngineShodan] = &discover.Shodan{APIKey: cfg.Shodan.APIKey, BaseURL: cfg.Shodan.APIURL}
+	}
+	if cfg.Censys.APIID != "" && cfg.Censys.APISecret != "" {
+		s.searchers[discover.EngineCensys] = &discover.Censys{APIID: cfg.Censys.APIID, APISecret: cfg.Censys.APISecret, BaseURL: cfg.Censys.APIURL}
+	}
+}
+
+// handleTargetsDiscover queries a search engine for the portals of a VPN
+// type and imports the endpoints found as targets tagged with the vendor,
+// the engine as source and the query as source reference. The query is
+// the request's, the configured dork or the built-in one, in this order.
+func (s *Server) handleTargetsDiscover(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	var req struct {
+		Engine   string `json:"engine"`
+		Vendor   string `json:"vendor"`
+		Query    string `json:"query"`
+		MaxPages int    `json:"max_pages"`
+		Customer string `json:"customer"`
+		Region   string `json:"region"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+		return
+	}
+	searcher, ok := s.searchers[req.Engine]
+	if !ok {
+		s.sendJSON(w, APIResponse{Success: false, Error: fmt.Sprintf("search engine %q not configured", req.Engine)})
+		return
+	}
+	if req.Vendor == "" {
+		s.sendJSON(w, APIResponse{Success: false, Error: "vendor required"})
+		return
+	}
+	query := req.Query
+	if query == "" {
+		query = s.discovery.Dorks[req.Engine+":"+req.Vendor]
+	}
+	if query == "" {
+		query = discover.Dorks[req.Engine][req.Vendor]
+	}
+	if query == "" {
+		s.sendJSON(w, APIResponse{Success: false, Error: fmt.Sprintf("no %s query for vendor %q", req.Engine, req.Vendor)})
+		return
+	}
+	pages := s.discovery.MaxPages
+	if req.MaxPages > 0 && (pages <= 0 || req.MaxPages < pages) {
+		pages = req.MaxPages
+	}
+	if pages <= 0 {
+		pages = 1
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), discoveryTimeout)
+	defer cancel()
+	hosts, err := searcher.Search(ctx, query, pages)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	for i := range hosts {
+		hosts[i].Vendor = req.Vendor
+	}
+	added, urls, _, err := s.importHosts(hosts, db.Target{Customer: req.Customer, Region: req.Region,
+		Source: req.Engine, SourceRef: query})
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.logEvent("info", fmt.Sprintf("%s discovery for %s: %d endpoint(s), %d target(s) and %d vendor URL(s) added",
+		req.Engine, req.Vendor, len(hosts), added, urls), "api")
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"query":       query,
+		"hosts":       len(hosts),
+		"added":       added,
+		"vendor_urls": urls,
+	}})
+}
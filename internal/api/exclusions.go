@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/exclude"
+)
+
+// handleExclusions lists the deny-list stored in the database together
+// with the hit counts reported by workers (GET), or adds an entry (POST).
+func (s *Server) handleExclusions(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	if r.Method == http.MethodPost {
+		var req db.Exclusion
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+			return
+		}
+		rule, err := exclude.Normalize(exclude.Rule{Kind: req.Kind, Value: req.Value})
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		req.Kind, req.Value = rule.Kind, rule.Value
+		id, err := s.db.InsertExclusion(req)
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.logEvent("info", "exclusion added: "+rule.String(), "api")
+		s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"id": id}})
+		return
+	}
+
+	list, err := s.db.ListExclusions()
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	hits := make(map[string]int64)
+	if stats, err := (aggregator.DirSource{Dir: os.Getenv("STATS_DIR")}).Collect(); err == nil {
+		for _, st := range stats {
+			for rule, n := range st.Exclusions {
+				hits[rule] += n
+			}
+		}
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"exclusions": list,
+		"hits":       hits,
+	}})
+}
+
+// handleExclusion deletes a deny-list entry.
+func (s *Server) handleExclusion(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid id"})
+		return
+	}
+	if err := s.db.DeleteExclusion(id); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/export"
+)
+
+// handleFindings lists valid credentials found by scan runs, newest first,
+// optionally filtered by ?run_id=, ?country= (ISO code) and ?asn=.
+func (s *Server) handleFindings(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	filter, err := findingFilter(r)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	findings, err := s.db.ListFindings(filter, limit)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: findings})
+}
+
+// handleFindingsExport downloads the findings matching the filters of
+// handleFindings as ?format=csv, xlsx or json (the default).
+func (s *Server) handleFindingsExport(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatJSON
+	}
+	if !slices.Contains(export.Formats, format) {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid format"})
+		return
+	}
+	filter, err := findingFilter(r)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	findings, err := s.db.ListFindings(filter, export.MaxFindings)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, findings); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.logEvent("info", fmt.Sprintf("%d finding(s) exported as %s", len(findings), format), "api")
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="findings.%s"`, format))
+	w.Write(buf.Bytes())
+}
+
+// findingFilter reads the ?run_id=, ?country= and ?asn= filters.
+func findingFilter(r *http.Request) (db.FindingFilter, error) {
+	q := r.URL.Query()
+	filter := db.FindingFilter{RunID: q.Get("run_id"), Country: q.Get("country")}
+	if v := q.Get("asn"); v != "" {
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(v), "AS"), 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid asn")
+		}
+		filter.ASN = uint(asn)
+	}
+	return filter, nil
+}
+
+// handleFindingsByCountry counts findings per country.
+func (s *Server) handleFindingsByCountry(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	counts, err := s.db.FindingsByCountry()
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: counts})
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handleServerHistory returns the time series for a single server covering
+// the last N hours (query parameter "hours", default 1).
+func (s *Server) handleServerHistory(w http.ResponseWriter, r *http.Request) {
+	ip := mux.Vars(r)["ip"]
+
+	hours := 1
+	if h := r.URL.Query().Get("hours"); h != "" {
+		v, err := strconv.Atoi(h)
+		if err != nil || v <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			s.sendJSON(w, APIResponse{Success: false, Error: "invalid hours"})
+			return
+		}
+		hours = v
+	}
+
+	history := s.aggr.Aggregator().History()
+	window := time.Duration(hours) * time.Hour
+	if window > history.Retention() {
+		window = history.Retention()
+	}
+
+	samples := history.Get(ip, time.Now().Add(-window))
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"ip":      ip,
+		"hours":   hours,
+		"samples": samples,
+	}})
+}
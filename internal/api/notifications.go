@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/notify"
+	"vpn-bruteforce-client/internal/report"
+)
+
+// notifyPollInterval is how often new findings and run reports are turned
+// into notification events.
+const notifyPollInterval = 30 * time.Second
+
+// EnableNotifications sets up the configured notification channels. New
+// findings, newly written run reports and alerts are passed to them once
+// the server is started, which also starts the Telegram bot. Call it after
+// EnableAlerts.
+func (s *Server) EnableNotifications(cfg config.NotificationsConfig) error {
+	if cfg.Email.Enabled {
+		mailer, err := notify.NewMailer(cfg.Email)
+		if err != nil {
+			return err
+		}
+		s.mailer = mailer
+		s.digest = notify.NewDigest(mailer)
+		s.digestInterval = cfg.Email.DigestInterval
+		s.notifySinks = append(s.notifySinks, s.digest)
+	}
+	if cfg.Telegram.Enabled {
+		bot, err := notify.NewBot(cfg.Telegram, s)
+		if err != nil {
+			return err
+		}
+		s.bot = bot
+		s.notifySinks = append(s.notifySinks, bot)
+	}
+	if len(s.notifySinks) == 0 {
+		return nil
+	}
+	if s.alerts != nil {
+		for _, sink := range s.notifySinks {
+			s.alerts.AddNotifier(notify.AlertNotifier{Sink: sink})
+		}
+	}
+	s.notifyStop = make(chan struct{})
+	return nil
+}
+
+// runNotifications polls for new events, flushes the email digest and runs
+// the Telegram bot until stop is closed. Findings and reports that existed at start are skipped.
+func (s *Server) runNotifications(stop <-chan struct{}) {
+	lastFinding := 0
+	if s.db != nil {
+		lastFinding, _ = s.db.LastFindingID()
+	}
+	lastReport := time.Now()
+	if s.bot != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.bot.Run(ctx)
+	}
+
+	poll := time.NewTicker(notifyPollInterval)
+	defer poll.Stop()
+	var flush <-chan time.Time
+	if s.digest != nil {
+		t := time.NewTicker(s.digestInterval)
+		defer t.Stop()
+		flush = t.C
+	}
+	for {
+		select {
+		case <-poll.C:
+			lastFinding = s.pollFindings(lastFinding)
+			lastReport = s.pollReports(lastReport)
+		case <-flush:
+			if err := s.digest.Flush(); err != nil {
+				log.Printf("email digest error: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Server) emit(e notify.Event) {
+	for _, sink := range s.notifySinks {
+		sink.Add(e)
+	}
+}
+
+// pollFindings emits findings newer than after and returns the new high
+// water mark. Passwords are never included.
+func (s *Server) pollFindings(after int) int {
+	if s.db == nil {
+		return after
+	}
+	findings, err := s.db.FindingsSince(after, 1000)
+	if err != nil {
+		log.Printf("notification findings error: %v", err)
+		return after
+	}
+	for _, f := range findings {
+		s.emit(notify.Event{Kind: notify.KindFinding, Time: f.FoundAt,
+			Text: fmt.Sprintf("%s %s user %s (run %s)", f.VPNType, f.IP, f.Username, f.RunID)})
+		after = f.ID
+	}
+	return after
+}
+
+// pollReports emits runs that finished after since and returns the newest
+// finish time seen.
+func (s *Server) pollReports(since time.Time) time.Time {
+	list, err := report.List(reportsDir())
+	if err != nil {
+		log.Printf("notification reports error: %v", err)
+		return since
+	}
+	newest := since
+	for _, r := range list {
+		if !r.FinishedAt.After(since) {
+			continue
+		}
+		state := "finished"
+		if r.Interrupted {
+			state = "interrupted"
+		}
+		s.emit(notify.Event{Kind: notify.KindJob, Time: r.FinishedAt,
+			Text: fmt.Sprintf("%s run %s %s: %d valid of %d", r.VPNType, r.RunID, state, r.Goods, r.Processed)})
+		if r.FinishedAt.After(newest) {
+			newest = r.FinishedAt
+		}
+	}
+	return newest
+}
+
+// Status summarises the worker stats in STATS_DIR for the Telegram bot.
+func (s *Server) Status() string {
+	stats, err := aggregator.DirSource{Dir: os.Getenv("STATS_DIR")}.Collect()
+	if err != nil {
+		return fmt.Sprintf("stats unavailable: %v", err)
+	}
+	t := aggregator.Sum(stats, time.Now())
+	return fmt.Sprintf("Workers: %d active of %d\nProcessed: %d\nValid: %d  Invalid: %d\nErrors: %d  Offline: %d  IP blocks: %d",
+		t.ActiveServers, t.Servers, t.Processed, t.Goods, t.Bads, t.Errors, t.Offline, t.IPBlock)
+}
+
+// handleNotificationsTest sends a test email to check the SMTP settings.
+func (s *Server) handleNotificationsTest(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.mailer == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "email notifications are not configured"})
+		return
+	}
+	if err := s.mailer.Send("VPN scanner test notification", "Email notifications are working.\n"); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.logEvent("info", "test notification sent", "api")
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{"status": "sent"}})
+}
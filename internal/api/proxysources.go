@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/proxysrc"
+)
+
+// proxySourcePoll is how often sources are checked for a due refresh.
+const proxySourcePoll = time.Minute
+
+// proxyFetchTimeout bounds fetching and checking one source.
+const proxyFetchTimeout = 2 * time.Minute
+
+// EnableProxySources registers the configured proxy lists and makes the
+// server refresh every source in the proxy_sources table once per
+// interval after it is started.
+func (s *Server) EnableProxySources(cfg config.ProxySourcesConfig) error {
+	if s.db == nil {
+		return nil
+	}
+	for _, u := range cfg.URLs {
+		if err := checkSourceURL(u); err != nil {
+			return err
+		}
+		if _, err := s.db.EnsureProxySource(u); err != nil {
+			return err
+		}
+	}
+	s.proxySources = cfg
+	s.proxySourcesStop = make(chan struct{})
+	return nil
+}
+
+func checkSourceURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid proxy source url %q", raw)
+	}
+	return nil
+}
+
+// runProxySources refreshes due proxy sources until stop is closed.
+func (s *Server) runProxySources(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	t := time.NewTicker(proxySourcePoll)
+	defer t.Stop()
+	for {
+		s.refreshDueProxySources(ctx)
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Server) refreshDueProxySources(ctx context.Context) {
+	sources, err := s.db.ListProxySources()
+	if err != nil {
+		log.Printf("proxy sources error: %v", err)
+		return
+	}
+	for _, src := range sources {
+		if src.LastFetched != nil && time.Since(*src.LastFetched) < s.proxySources.Interval {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.RefreshProxySource(ctx, src)
+	}
+}
+
+// RefreshProxySource fetches src, checks its proxies if configured and
+// merges them into the proxies table. It returns the number of proxies
+// added; the outcome is also stored with the source.
+func (s *Server) RefreshProxySource(ctx context.Context, src db.ProxySource) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, proxyFetchTimeout)
+	defer cancel()
+
+	proxies, invalid, err := proxysrc.Fetch(ctx, &http.Client{Timeout: proxyFetchTimeout}, src.URL)
+	if err == nil && s.proxySources.CheckTimeout > 0 {
+		proxies = proxysrc.Check(ctx, proxies, s.proxySources.CheckTimeout)
+	}
+	added := 0
+	if err == nil {
+		list := make([]db.Proxy, len(proxies))
+		for i, p := range proxies {
+			list[i] = db.Proxy{Address: p.Address, Username: p.Username, Password: p.Password}
+		}
+		added, err = s.db.MergeProxies(src.URL, list)
+	}
+	if rerr := s.db.RecordProxyFetch(src.ID, time.Now(), len(proxies), err); rerr != nil {
+		log.Printf("proxy source %s: %v", src.URL, rerr)
+	}
+	if err != nil {
+		s.logEvent("warning", fmt.Sprintf("proxy source %s: %v", src.URL, err), "proxies")
+		return 0, err
+	}
+	if added > 0 {
+		clearCacheByPrefix("proxies")
+	}
+	s.logEvent("info", fmt.Sprintf("proxy source %s: %d valid, %d invalid, %d new", src.URL, len(proxies), invalid, added), "proxies")
+	return added, nil
+}
+
+// handleProxySources lists the proxy sources (GET) or adds one (POST). New
+// sources are fetched on the next refresh cycle.
+func (s *Server) handleProxySources(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	if r.Method == http.MethodPost {
+		var req struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+			return
+		}
+		if err := checkSourceURL(req.URL); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		id, err := s.db.EnsureProxySource(req.URL)
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.logEvent("info", "proxy source added: "+req.URL, "api")
+		s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"id": id}})
+		return
+	}
+
+	sources, err := s.db.ListProxySources()
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: sources})
+}
+
+// handleProxySource deletes a proxy source together with the proxies
+// imported from it, unless keep_proxies=true is given.
+func (s *Server) handleProxySource(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid id"})
+		return
+	}
+	keep := r.URL.Query().Get("keep_proxies") == "true"
+	if err := s.db.DeleteProxySource(id, keep); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	clearCacheByPrefix("proxies")
+	s.sendJSON(w, APIResponse{Success: true})
+}
+
+// handleProxySourceRefresh fetches a proxy source now.
+func (s *Server) handleProxySourceRefresh(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid id"})
+		return
+	}
+	sources, err := s.db.ListProxySources()
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	for _, src := range sources {
+		if src.ID != id {
+			continue
+		}
+		added, err := s.RefreshProxySource(r.Context(), src)
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"added": added}})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: false, Error: "proxy source not found"})
+}
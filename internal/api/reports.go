@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+
+	"vpn-bruteforce-client/internal/report"
+)
+
+// reportsDir returns the directory with run reports (REPORTS_DIR, default
+// "reports").
+func reportsDir() string {
+	if dir := os.Getenv("REPORTS_DIR"); dir != "" {
+		return dir
+	}
+	return report.DefaultDir
+}
+
+// handleReports lists stored run reports (GET) or registers a report sent
+// by a remote scanner (POST).
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if r.Method == http.MethodPost {
+		var rep report.Report
+		if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+			return
+		}
+		if !report.ValidRunID(rep.RunID) {
+			s.sendJSON(w, APIResponse{Success: false, Error: "invalid run_id"})
+			return
+		}
+		if _, err := rep.Write(reportsDir()); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.logEvent("info", "run report registered: "+rep.RunID, "api")
+		s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{"run_id": rep.RunID}})
+		return
+	}
+	list, err := report.List(reportsDir())
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: list})
+}
+
+// handleReport returns a single report as JSON.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	rep, err := report.Load(reportsDir(), mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "report not found"})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: rep})
+}
+
+// handleReportHTML serves the HTML rendering of a report.
+func (s *Server) handleReportHTML(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if !report.ValidRunID(id) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeFile(w, r, report.HTMLPath(reportsDir(), id))
+}
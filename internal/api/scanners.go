@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"vpn-bruteforce-client/internal/manager"
+)
+
+// SetManager makes StartScanner and StopScanner run scanner processes
+// through m. Scanners started this way are stopped on Shutdown.
+func (s *Server) SetManager(m *manager.Manager) {
+	s.manager = m
+	s.scanners = make(map[string]*scannerRun)
+}
+
+// scannerRun is a scanner supervised on behalf of the dashboard.
+type scannerRun struct {
+	cancel context.CancelFunc
+	done   <-chan struct{}
+}
+
+// launchScanners starts the scanners named by vpnType ("all" for every
+// one). It fails without starting anything when a scanner is unknown or
+// already running; a scanner that fails to start stops the ones before it.
+func (s *Server) launchScanners(vpnType string) error {
+	names, err := s.manager.Resolve(vpnType)
+	if err != nil {
+		return err
+	}
+	s.scannersMu.Lock()
+	defer s.scannersMu.Unlock()
+	s.pruneScanners()
+	for _, name := range names {
+		if _, ok := s.scanners[name]; ok {
+			return fmt.Errorf("scanner %s already running", name)
+		}
+	}
+	var started []string
+	for _, name := range names {
+		ctx, cancel := context.WithCancel(context.Background())
+		done, err := s.manager.Launch(ctx, name)
+		if err != nil {
+			cancel()
+			for _, n := range started {
+				s.scanners[n].cancel()
+				<-s.scanners[n].done
+				delete(s.scanners, n)
+			}
+			return err
+		}
+		s.scanners[name] = &scannerRun{cancel: cancel, done: done}
+		started = append(started, name)
+	}
+	return nil
+}
+
+// haltScanners stops the scanners named by vpnType, whether they were
+// started by the dashboard or by another manager.
+func (s *Server) haltScanners(vpnType string) error {
+	names, err := s.manager.Resolve(vpnType)
+	if err != nil {
+		return err
+	}
+	running := make(map[string]bool)
+	for _, st := range s.manager.Status() {
+		running[st.Name] = st.Running
+	}
+	s.scannersMu.Lock()
+	defer s.scannersMu.Unlock()
+	s.pruneScanners()
+	var external []string
+	stopped := 0
+	for _, name := range names {
+		if run, ok := s.scanners[name]; ok {
+			run.cancel()
+			<-run.done
+			delete(s.scanners, name)
+			stopped++
+			continue
+		}
+		if running[name] {
+			external = append(external, name)
+		}
+	}
+	if len(external) > 0 {
+		s.manager.Stop(external)
+		stopped += len(external)
+	}
+	if stopped == 0 {
+		return fmt.Errorf("scanner %s is not running", vpnType)
+	}
+	return nil
+}
+
+// pruneScanners forgets scanners whose supervision ended, e.g. after they
+// finished or gave up restarting. The caller holds scannersMu.
+func (s *Server) pruneScanners() {
+	for name, run := range s.scanners {
+		select {
+		case <-run.done:
+			delete(s.scanners, name)
+		default:
+		}
+	}
+}
+
+// stopScanners stops every scanner started by the dashboard, waiting for
+// them until ctx is done.
+func (s *Server) stopScanners(ctx context.Context) {
+	s.scannersMu.Lock()
+	defer s.scannersMu.Unlock()
+	for name, run := range s.scanners {
+		run.cancel()
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+		}
+		delete(s.scanners, name)
+	}
+}
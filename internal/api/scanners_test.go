@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"vpn-bruteforce-client/internal/manager"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+type wsReply struct {
+	Type    string                 `json:"type"`
+	Data    map[string]interface{} `json:"data"`
+	ReplyTo string                 `json:"reply_to"`
+}
+
+// readReplies returns the messages received up to the ack or error reply
+// to request id, which is the last one.
+func readReplies(t *testing.T, c *websocket.Conn, id string) []wsReply {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var out []wsReply
+	for {
+		var m wsReply
+		_, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if json.Unmarshal(data, &m) != nil {
+			continue
+		}
+		out = append(out, m)
+		if m.ReplyTo == id && (m.Type == "ack" || m.Type == "error") {
+			return out
+		}
+	}
+}
+
+func TestWebSocketScannerCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as scanner")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-scanner.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m := manager.New(map[string]manager.Scanner{"fortinet": {Binary: script}}, filepath.Join(dir, "bin"), filepath.Join(dir, "run"))
+	m.LogDir = filepath.Join(dir, "logs")
+
+	srv := NewServer(stats.New(), 0, nil)
+	srv.SetManager(m)
+	defer srv.stopScanners(context.Background())
+	ts := httptest.NewServer(http.HandlerFunc(srv.wsServer.HandleWebSocket))
+	defer ts.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+
+	send := func(id, typ, vpn string) (last wsReply, done bool) {
+		t.Helper()
+		msg := map[string]interface{}{"id": id, "type": typ, "data": map[string]string{"vpn_type": vpn}}
+		if err := c.WriteJSON(msg); err != nil {
+			t.Fatal(err)
+		}
+		replies := readReplies(t, c, id)
+		for _, r := range replies {
+			if (r.Type == "scanner_started" || r.Type == "scanner_stopped") && r.Data["id"] == id {
+				done = true
+			}
+		}
+		return replies[len(replies)-1], done
+	}
+
+	if r, done := send("1", "start_scanner", "fortinet"); r.Type != "ack" || !done {
+		t.Fatalf("start = %+v, broadcast %v", r, done)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for st := m.Status(); !st[0].Running; st = m.Status() {
+		if time.Now().After(deadline) {
+			t.Fatalf("scanner not running: %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r, done := send("2", "start_scanner", "fortinet"); r.Type != "error" || done {
+		t.Fatalf("second start = %+v", r)
+	}
+	if r, _ := send("3", "start_scanner", "nope"); r.Type != "error" || r.Data["message"] != "unknown vpn nope" {
+		t.Fatalf("unknown start = %+v", r)
+	}
+	if r, done := send("4", "stop_scanner", "fortinet"); r.Type != "ack" || !done {
+		t.Fatalf("stop = %+v, broadcast %v", r, done)
+	}
+	if st := m.Status(); st[0].Running {
+		t.Fatalf("scanner still running: %+v", st)
+	}
+	if r, _ := send("5", "stop_scanner", "fortinet"); r.Type != "error" {
+		t.Fatalf("stop of stopped scanner = %+v", r)
+	}
+	if r, _ := send("6", "bogus", ""); r.Type != "error" || r.Data["type"] != "bogus" {
+		t.Fatalf("unknown type = %+v", r)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
@@ -15,8 +17,13 @@ import (
 
 	"github.com/gorilla/mux"
 	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/alerting"
 	"vpn-bruteforce-client/internal/config"
 	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/discover"
+	"vpn-bruteforce-client/internal/geoip"
+	"vpn-bruteforce-client/internal/manager"
+	"vpn-bruteforce-client/internal/notify"
 	"vpn-bruteforce-client/internal/stats"
 	"vpn-bruteforce-client/internal/websocket"
 )
@@ -41,6 +48,12 @@ type Server struct {
 	wsServer *websocket.Server
 	router   *mux.Router
 	port     int
+	http     *http.Server
+
+	// aggr в фоне собирает метрики из stats_*.json, хранит историю по
+	// серверам для /api/servers/{ip}/history и рассылает изменения через
+	// WebSocket.
+	aggr *aggregator.Service
 
 	// allowedOrigins содержит список разрешенных источников для CORS. Когда
 	// пуст, разрешены любые источники, что соответствует предыдущему поведению.
@@ -54,6 +67,49 @@ type Server struct {
 	// ссылку вместо столбца vpn_type. Обработчики адаптируют свои SQL
 	// запросы на основе этого флага, чтобы API работал с обеими схемами.
 	useVendorTasks bool
+
+	// alerts проверяет правила оповещений по статистике воркеров, если
+	// включен через EnableAlerts; alertsStop останавливает цикл проверки.
+	alerts        *alerting.Engine
+	alertInterval time.Duration
+	alertsStop    chan struct{}
+
+	// Каналы уведомлений (EnableNotifications): почтовый дайджест и
+	// получатели событий о находках, завершенных запусках и оповещениях.
+	mailer         *notify.Mailer
+	digest         *notify.Digest
+	digestInterval time.Duration
+	notifySinks    []notify.Sink
+	notifyStop     chan struct{}
+
+	// bot - Telegram бот для уведомлений и управления сканерами.
+	bot *notify.Bot
+
+	// proxySources - настройки импорта списков прокси (EnableProxySources);
+	// proxySourcesStop останавливает цикл обновления источников.
+	proxySources     config.ProxySourcesConfig
+	proxySourcesStop chan struct{}
+
+	// tasksStop останавливает рассылку изменений состояния задач.
+	tasksStop chan struct{}
+
+	// activityStop останавливает рассылку ленты активности.
+	activityStop chan struct{}
+
+	// geo добавляет страну и ASN к данным серверов (SetGeoIP); nil
+	// отключает обогащение.
+	geo *geoip.Reader
+
+	// manager запускает процессы сканеров (SetManager); scanners хранит
+	// сканеры, запущенные дашбордом, под защитой scannersMu.
+	manager    *manager.Manager
+	scannersMu sync.Mutex
+	scanners   map[string]*scannerRun
+
+	// discovery - учётные записи Shodan и Censys для поиска целей
+	// (EnableDiscovery); searchers хранит клиенты по имени поисковика.
+	discovery config.DiscoveryConfig
+	searchers map[string]discover.Searcher
 }
 
 type APIResponse struct {
@@ -105,14 +161,19 @@ func (s *Server) InsertLog(level, message, source string) {
 
 func NewServer(stats *stats.Stats, port int, database *db.DB) *Server {
 	wsServer := websocket.NewServer(stats, database)
+	router := mux.NewRouter()
 
 	s := &Server{
 		stats:    stats,
 		db:       database,
 		wsServer: wsServer,
-		router:   mux.NewRouter(),
+		router:   router,
 		port:     port,
+		http:     &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: router},
+		aggr:     aggregator.NewService(aggregator.New(os.Getenv("STATS_DIR")), aggregator.DefaultRefreshInterval),
 	}
+	wsServer.SetAggregator(s.aggr)
+	wsServer.SetCommandHandler(s.scannerCommand)
 
 	// Загружаем разрешенные источники и токен аутентификации из окружения. Они
 	// опциональны, поэтому нулевое значение сохраняет предыдущее открытое поведение
@@ -153,6 +214,7 @@ func (s *Server) setupRoutes() {
 	api.Use(s.authMiddleware)
 	api.HandleFunc("/stats", s.handleStats).Methods("GET")
 	api.HandleFunc("/servers", s.handleServers).Methods("GET")
+	api.HandleFunc("/servers/{ip}/history", s.handleServerHistory).Methods("GET")
 	api.HandleFunc("/start", s.handleStart).Methods("POST")
 	api.HandleFunc("/stop", s.handleStop).Methods("POST")
 	api.HandleFunc("/logs", s.handleLogs).Methods("GET")
@@ -161,6 +223,7 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/vendor_urls/{id}", s.handleVendorURL).Methods("PUT", "DELETE")
 	api.HandleFunc("/vendor_urls/bulk_delete", s.handleVendorURLsBulkDelete).Methods("POST")
 	api.HandleFunc("/credentials", s.handleCredentials).Methods("GET", "POST")
+	api.HandleFunc("/credentials/lint", s.handleCredentialsLint).Methods("GET")
 	api.HandleFunc("/credentials/{id}", s.handleCredential).Methods("PUT", "DELETE")
 	api.HandleFunc("/credentials/bulk_delete", s.handleCredentialsBulkDelete).Methods("POST")
 	api.HandleFunc("/workers", s.handleWorkers).Methods("GET", "POST")
@@ -168,11 +231,35 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/proxies", s.handleProxies).Methods("GET", "POST")
 	api.HandleFunc("/proxies/{id}", s.handleProxy).Methods("PUT", "DELETE")
 	api.HandleFunc("/proxies/bulk_delete", s.handleProxiesBulkDelete).Methods("POST")
+	api.HandleFunc("/proxy_sources", s.handleProxySources).Methods("GET", "POST")
+	api.HandleFunc("/proxy_sources/{id}", s.handleProxySource).Methods("DELETE")
+	api.HandleFunc("/proxy_sources/{id}/refresh", s.handleProxySourceRefresh).Methods("POST")
 	api.HandleFunc("/tasks", s.handleTasks).Methods("GET", "POST")
 	api.HandleFunc("/tasks/{id}", s.handleTask).Methods("PUT", "DELETE")
 	api.HandleFunc("/tasks/bulk_delete", s.handleTasksBulkDelete).Methods("POST")
+	api.HandleFunc("/tasks/{id}/state", s.handleTaskState).Methods("POST")
 	api.HandleFunc("/scheduled_tasks", s.handleScheduledTasks).Methods("GET", "POST")
 	api.HandleFunc("/scheduled_tasks/{id}", s.handleScheduledTask).Methods("PUT", "DELETE")
+	api.HandleFunc("/targets", s.handleTargets).Methods("GET", "POST")
+	api.HandleFunc("/targets/discover", s.handleTargetsDiscover).Methods("POST")
+	api.HandleFunc("/targets/import", s.handleTargetsImport).Methods("POST")
+	api.HandleFunc("/targets/tag", s.handleTargetsTag).Methods("POST")
+	api.HandleFunc("/targets/tasks", s.handleTargetTasks).Methods("POST")
+	api.HandleFunc("/targets/{id}", s.handleTarget).Methods("PUT", "DELETE")
+	api.HandleFunc("/exclusions", s.handleExclusions).Methods("GET", "POST")
+	api.HandleFunc("/exclusions/{id}", s.handleExclusion).Methods("DELETE")
+	api.HandleFunc("/breakers", s.handleBreakers).Methods("GET")
+	api.HandleFunc("/alerts", s.handleAlerts).Methods("GET")
+	api.HandleFunc("/activity", s.handleActivity).Methods("GET")
+	api.HandleFunc("/analytics/vendors", s.handleVendorAnalytics).Methods("GET")
+	api.HandleFunc("/analytics/credentials", s.handleCredentialAnalytics).Methods("GET")
+	api.HandleFunc("/notifications/test", s.handleNotificationsTest).Methods("POST")
+	api.HandleFunc("/findings", s.handleFindings).Methods("GET")
+	api.HandleFunc("/findings/export", s.handleFindingsExport).Methods("GET")
+	api.HandleFunc("/findings/countries", s.handleFindingsByCountry).Methods("GET")
+	api.HandleFunc("/reports", s.handleReports).Methods("GET", "POST")
+	api.HandleFunc("/reports/{id}", s.handleReport).Methods("GET")
+	api.HandleFunc("/reports/{id}/html", s.handleReportHTML).Methods("GET")
 	api.HandleFunc("/health", s.handleHealth).Methods("GET")
 	api.HandleFunc("/login", s.handleLogin).Methods("POST")
 	api.HandleFunc("/cache", s.handleCache).Methods("GET", "DELETE")
@@ -294,13 +381,60 @@ func (s *Server) checkAuth(w http.ResponseWriter, r *http.Request) bool {
 
 func (s *Server) Start() error {
 	s.wsServer.Start()
+	if err := s.aggr.Start(); err != nil {
+		log.Printf("aggregator watcher error: %v", err)
+	}
+	if s.alerts != nil {
+		go s.runAlerts(s.alertsStop)
+	}
+	if s.notifyStop != nil {
+		go s.runNotifications(s.notifyStop)
+	}
+	if s.proxySourcesStop != nil {
+		go s.runProxySources(s.proxySourcesStop)
+	}
+	if s.db != nil {
+		s.tasksStop = make(chan struct{})
+		go s.runTaskStates(s.tasksStop)
+		s.activityStop = make(chan struct{})
+		go s.runActivity(s.activityStop)
+	}
 
 	log.Printf("🌐 API Server starting on port %d", s.port)
 	log.Printf("📊 Dashboard: http://localhost:%d", s.port)
 	log.Printf("🔌 WebSocket: ws://localhost:%d/ws", s.port)
 	log.Printf("🔗 API: http://localhost:%d/api/", s.port)
 
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), s.router)
+	if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown останавливает HTTP сервер, дожидаясь завершения активных
+// запросов, а также фоновые агрегатор и WebSocket рассылку.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.alerts != nil {
+		close(s.alertsStop)
+	}
+	if s.notifyStop != nil {
+		close(s.notifyStop)
+	}
+	if s.proxySourcesStop != nil {
+		close(s.proxySourcesStop)
+	}
+	if s.tasksStop != nil {
+		close(s.tasksStop)
+	}
+	if s.activityStop != nil {
+		close(s.activityStop)
+	}
+	if s.manager != nil {
+		s.stopScanners(ctx)
+	}
+	s.aggr.Stop()
+	s.wsServer.Stop()
+	return s.http.Shutdown(ctx)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -402,6 +536,13 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		"threads":      s.stats.GetThreads(),
 		"uptime":       s.stats.GetUptime(),
 		"success_rate": s.stats.GetSuccessRate(),
+		"memory_mb":    s.stats.GetMemory(),
+		"cpu_usage":    s.stats.GetCPUUsage(),
+	}
+	if s.db != nil {
+		if countries, err := s.db.FindingsByCountry(); err == nil {
+			stats["findings_by_country"] = countries
+		}
 	}
 
 	response := APIResponse{Success: true, Data: stats}
@@ -420,6 +561,23 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, response)
 }
 
+// SetGeoIP включает добавление страны и ASN к ответам /api/servers.
+func (s *Server) SetGeoIP(r *geoip.Reader) {
+	s.geo = r
+}
+
+// addGeo дополняет записи серверов полями country, asn и as_org.
+func (s *Server) addGeo(servers []map[string]interface{}) {
+	if s.geo == nil {
+		return
+	}
+	for _, srv := range servers {
+		ip, _ := srv["ip"].(string)
+		info := s.geo.Lookup(ip)
+		srv["country"], srv["asn"], srv["as_org"] = info.Country, info.ASN, info.ASOrg
+	}
+}
+
 func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
 	// Parse pagination parameters
 	page, pageSize := getPaginationParams(r)
@@ -443,6 +601,8 @@ func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		s.addGeo(servers)
+
 		// Calculate total pages
 		totalPages := (total + pageSize - 1) / pageSize
 
@@ -472,16 +632,16 @@ func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dir := os.Getenv("STATS_DIR")
-	if q := r.URL.Query().Get("dir"); q != "" {
-		dir = q
-	}
-
-	aggr := aggregator.New(dir)
-	infos, err := aggr.GetServerInfo()
-	if err != nil {
-		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
-		return
+	var infos []aggregator.ServerInfo
+	if dir := r.URL.Query().Get("dir"); dir != "" {
+		var err error
+		infos, err = aggregator.New(dir).GetServerInfo()
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+	} else {
+		infos = s.aggr.Current()
 	}
 
 	servers := make([]map[string]interface{}, len(infos))
@@ -502,6 +662,7 @@ func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
 			"task":      inf.Task,
 		}
 	}
+	s.addGeo(servers)
 
 	response := APIResponse{
 		Success: true,
@@ -541,15 +702,10 @@ func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Broadcast start command via WebSocket
-	s.wsServer.BroadcastMessage("scanner_command", map[string]interface{}{
-		"action":   "start",
-		"vpn_type": vpnType,
-		"status":   "starting",
-	})
-
-	log.Printf("🚀 Starting %s scanner via API", vpnType)
-	s.logEvent("info", fmt.Sprintf("start %s scanner", vpnType), "api")
+	if err := s.StartScanner(vpnType); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
 	s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{
 		"status":   "started",
 		"vpn_type": vpnType,
@@ -569,19 +725,66 @@ func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Broadcast stop command via WebSocket
+	if err := s.StopScanner(vpnType); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{
+		"status":   "stopped",
+		"vpn_type": vpnType,
+	}})
+}
+
+// StartScanner запускает сканер vpnType через менеджер, если он задан
+// (SetManager), и рассылает команду запуска через WebSocket.
+func (s *Server) StartScanner(vpnType string) error {
+	if vpnType == "" {
+		return fmt.Errorf("vpn_type required")
+	}
+	if s.manager != nil {
+		if err := s.launchScanners(vpnType); err != nil {
+			s.logEvent("error", fmt.Sprintf("start %s scanner: %v", vpnType, err), "api")
+			return err
+		}
+	}
+	s.wsServer.BroadcastMessage("scanner_command", map[string]interface{}{
+		"action":   "start",
+		"vpn_type": vpnType,
+		"status":   "starting",
+	})
+	log.Printf("🚀 Starting %s scanner via API", vpnType)
+	s.logEvent("info", fmt.Sprintf("start %s scanner", vpnType), "api")
+	return nil
+}
+
+// StopScanner останавливает сканер vpnType через менеджер, если он задан,
+// и рассылает команду остановки через WebSocket.
+func (s *Server) StopScanner(vpnType string) error {
+	if vpnType == "" {
+		return fmt.Errorf("vpn_type required")
+	}
+	if s.manager != nil {
+		if err := s.haltScanners(vpnType); err != nil {
+			s.logEvent("error", fmt.Sprintf("stop %s scanner: %v", vpnType, err), "api")
+			return err
+		}
+	}
 	s.wsServer.BroadcastMessage("scanner_command", map[string]interface{}{
 		"action":   "stop",
 		"vpn_type": vpnType,
 		"status":   "stopping",
 	})
-
 	log.Printf("🛑 Stopping %s scanner via API", vpnType)
 	s.logEvent("info", fmt.Sprintf("stop %s scanner", vpnType), "api")
-	s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{
-		"status":   "stopped",
-		"vpn_type": vpnType,
-	}})
+	return nil
+}
+
+// scannerCommand выполняет команду сканера, полученную по WebSocket.
+func (s *Server) scannerCommand(action, vpnType string) error {
+	if action == "stop" {
+		return s.StopScanner(vpnType)
+	}
+	return s.StartScanner(vpnType)
 }
 
 func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
@@ -592,10 +795,11 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	level := r.URL.Query().Get("level")
 	source := r.URL.Query().Get("source")
 	search := r.URL.Query().Get("search")
+	runID := r.URL.Query().Get("run_id")
 
 	// Check cache
-	cacheKey := fmt.Sprintf("logs_page%d_size%d_level%s_source%s_search%s",
-		page, pageSize, level, source, search)
+	cacheKey := fmt.Sprintf("logs_page%d_size%d_level%s_source%s_search%s_run%s",
+		page, pageSize, level, source, search, runID)
 	if cacheEnabled {
 		if cachedResponse, ok := responseCache[cacheKey]; ok && time.Now().Before(cachedResponse.expiresAt) {
 			w.Header().Set("Content-Type", "application/json")
@@ -614,8 +818,8 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 		if search != "" {
 			// Search in logs
 			logs, total, err = s.db.GetLogsWithSearch(search, page, pageSize)
-		} else if level != "" || source != "" {
-			// Filter by level and/or source
+		} else if level != "" || source != "" || runID != "" {
+			// Filter by level, source and/or run
 			filters := make(map[string]interface{})
 			if level != "" {
 				filters["level"] = level
@@ -623,6 +827,9 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 			if source != "" {
 				filters["source"] = source
 			}
+			if runID != "" {
+				filters["run_id"] = runID
+			}
 			logs, total, err = s.db.GetLogsWithFilters(filters, page, pageSize)
 		} else {
 			// Get all logs with pagination
@@ -697,6 +904,7 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		s.wsServer.BroadcastMessage("config_update", cfg)
 		log.Printf("⚙️ Configuration updated via API")
 		s.logEvent("info", "configuration updated", "api")
+		s.recordActivity(db.ActivityConfig, "info", "configuration updated", 0)
 		s.sendJSON(w, APIResponse{Success: true, Data: map[string]string{
 			"status": "updated",
 		}})
@@ -957,11 +1165,8 @@ func (s *Server) handleCredentials(w http.ResponseWriter, r *http.Request) {
 			s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
 			return
 		}
-		encIP, _ := encryptString(item.IP)
-		encU, _ := encryptString(item.Username)
-		encP, _ := encryptString(item.Password)
-		var id int
-		if err := s.db.QueryRow(`INSERT INTO credentials(ip, username, password) VALUES($1,$2,$3) RETURNING id`, encIP, encU, encP).Scan(&id); err != nil {
+		id, err := s.db.InsertCredential(db.Credential{IP: item.IP, Username: item.Username, Password: item.Password})
+		if err != nil {
 			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
 			return
 		}
@@ -1243,11 +1448,8 @@ func (s *Server) handleProxies(w http.ResponseWriter, r *http.Request) {
 			s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
 			return
 		}
-		encAddr, _ := encryptString(item.Address)
-		encU, _ := encryptString(item.Username)
-		encP, _ := encryptString(item.Password)
-		var id int
-		if err := s.db.QueryRow(`INSERT INTO proxies(address, username, password) VALUES($1,$2,$3) RETURNING id`, encAddr, encU, encP).Scan(&id); err != nil {
+		id, err := s.db.InsertProxy(db.Proxy{Address: item.Address, Username: item.Username, Password: item.Password})
+		if err != nil {
 			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
 			return
 		}
@@ -1273,10 +1475,7 @@ func (s *Server) handleProxy(w http.ResponseWriter, r *http.Request) {
 			s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
 			return
 		}
-		encAddr, _ := encryptString(item.Address)
-		encU, _ := encryptString(item.Username)
-		encP, _ := encryptString(item.Password)
-		if _, err := s.db.Exec(`UPDATE proxies SET address=$1,username=$2,password=$3 WHERE id=$4`, encAddr, encU, encP, id); err != nil {
+		if err := s.db.UpdateProxy(id, db.Proxy{Address: item.Address, Username: item.Username, Password: item.Password}); err != nil {
 			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
 			return
 		}
@@ -1424,6 +1623,13 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 				s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
 				return
 			}
+			if item.Status == "" {
+				item.Status = db.TaskPending
+			}
+			if item.Status != db.TaskPending {
+				s.sendJSON(w, APIResponse{Success: false, Error: "new tasks must be pending"})
+				return
+			}
 			var id int
 			err := s.db.QueryRow(`INSERT INTO tasks(vpn_type, vendor_url_id, server, status) VALUES($1,$2,$3,$4) RETURNING id`,
 				item.VPNType, item.VendorURLID, item.Server, item.Status).Scan(&id)
@@ -1495,8 +1701,11 @@ func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
 				s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
 				return
 			}
-			_, err := s.db.Exec(`UPDATE tasks SET vpn_type=$1, vendor_url_id=$2, server=$3, status=$4 WHERE id=$5`,
-				item.VPNType, item.VendorURLID, item.Server, item.Status, id)
+			_, err := s.db.Exec(`UPDATE tasks SET vpn_type=$1, vendor_url_id=$2, server=$3 WHERE id=$4`,
+				item.VPNType, item.VendorURLID, item.Server, id)
+			if err == nil && item.Status != "" {
+				err = s.db.SetTaskStatus(id, item.Status, "")
+			}
 			if err != nil {
 				s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
 				return
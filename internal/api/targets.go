@@ -0,0 +1,321 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/discover"
+)
+
+// maxTargetImport bounds the hosts one import may add, CIDR ranges
+// included.
+const maxTargetImport = 65536
+
+// handleTargets lists targets filtered by ?vendor=, ?customer= and
+// ?region= (GET), or imports targets (POST). An import is either a JSON
+// object with hosts, CIDR ranges and/or the text of a target file plus
+// the tags to set, or a text/plain target file with the tags in the query.
+func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	q := r.URL.Query()
+	if r.Method == http.MethodPost {
+		var req struct {
+			Hosts    []string `json:"hosts"`
+			CIDRs    []string `json:"cidrs"`
+			Text     string   `json:"text"`
+			Vendor   string   `json:"vendor"`
+			Customer string   `json:"customer"`
+			Region   string   `json:"region"`
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(io.LimitReader(r.Body, 16<<20))
+			if err != nil {
+				s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+				return
+			}
+			req.Text = string(body)
+			req.Vendor, req.Customer, req.Region = q.Get("vendor"), q.Get("customer"), q.Get("region")
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+			return
+		}
+		entries := append(req.Hosts, req.CIDRs...)
+		sc := bufio.NewScanner(strings.NewReader(req.Text))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		hosts, err := expandTargets(entries)
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		added, err := s.db.ImportTargets(hosts, db.Target{Vendor: req.Vendor, Customer: req.Customer, Region: req.Region, Source: "api"})
+		if err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.logEvent("info", fmt.Sprintf("targets imported: %d added, %d duplicate", added, len(hosts)-added), "api")
+		s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"added": added, "duplicates": len(hosts) - added}})
+		return
+	}
+
+	page, pageSize := getPaginationParams(r)
+	list, total, err := s.db.ListTargets(targetFilter(r), page, pageSize)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: list, Meta: &MetaData{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}})
+}
+
+// handleTargetsImport imports the hosts with an HTTPS or VPN port open
+// from Nmap XML or masscan JSON output in the request body. Hosts are
+// tagged with the vendor recognised in their banners and with ?customer=
+// and ?region=, and ?source_ref= names the scan file. The portal URL of
+// every host with a vendor is added to vendor_urls.
+func (s *Server) handleTargetsImport(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<20))
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	hosts, err := discover.Parse(body)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	if len(hosts) > maxTargetImport {
+		s.sendJSON(w, APIResponse{Success: false, Error: fmt.Sprintf("more than %d targets", maxTargetImport)})
+		return
+	}
+	q := r.URL.Query()
+	added, urls, vendors, err := s.importHosts(hosts, db.Target{Customer: q.Get("customer"), Region: q.Get("region"),
+		Source: "scan", SourceRef: q.Get("source_ref")})
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.logEvent("info", fmt.Sprintf("scan import: %d host(s), %d target(s) and %d vendor URL(s) added", len(hosts), added, urls), "api")
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]interface{}{
+		"hosts":       len(hosts),
+		"added":       added,
+		"vendor_urls": urls,
+		"vendors":     vendors,
+	}})
+}
+
+// importHosts stores hosts as targets with the tags and provenance of
+// tags and their own vendor, and adds the portal URL of every host with a
+// vendor to vendor_urls. It returns the number of targets and URLs added
+// and the number of hosts per vendor.
+func (s *Server) importHosts(hosts []discover.Host, tags db.Target) (added, urls int, vendors map[string]int, err error) {
+	byVendor := make(map[string][]string)
+	for _, h := range hosts {
+		byVendor[h.Vendor] = append(byVendor[h.Vendor], h.Target())
+	}
+	vendors = make(map[string]int)
+	for vendor, list := range byVendor {
+		tags.Vendor = vendor
+		n, err := s.db.ImportTargets(list, tags)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		added += n
+		if vendor != "" {
+			vendors[vendor] = len(list)
+		}
+	}
+	for _, h := range hosts {
+		if h.Vendor == "" {
+			continue
+		}
+		ok, err := s.db.EnsureVendorURL(h.URL())
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if ok {
+			urls++
+		}
+	}
+	if urls > 0 {
+		clearCacheByPrefix("vendor_urls")
+	}
+	return added, urls, vendors, nil
+}
+
+// handleTarget sets the tags of a target (PUT) or deletes it (DELETE).
+func (s *Server) handleTarget(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid id"})
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if err := s.db.DeleteTarget(id); err != nil {
+			s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+			return
+		}
+		s.sendJSON(w, APIResponse{Success: true})
+		return
+	}
+	var tags db.Target
+	if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+		return
+	}
+	if _, err := s.db.TagTargets([]int{id}, tags); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true})
+}
+
+// handleTargetsTag sets tags on several targets at once.
+func (s *Server) handleTargetsTag(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	var req struct {
+		IDs []int `json:"ids"`
+		db.Target
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+		return
+	}
+	n, err := s.db.TagTargets(req.IDs, req.Target)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"updated": n}})
+}
+
+// handleTargetTasks queues a task for every credential of the request
+// against every target matching its tag filter.
+func (s *Server) handleTargetTasks(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database not available"})
+		return
+	}
+	var req struct {
+		db.TargetFilter
+		Credentials []db.TaskCredential `json:"credentials"`
+		Proxy       string              `json:"proxy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "Invalid JSON"})
+		return
+	}
+	n, err := s.db.CreateTargetTasks(req.TargetFilter, req.Credentials, req.Proxy)
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	clearCacheByPrefix("tasks")
+	s.logEvent("info", fmt.Sprintf("%d task(s) generated from targets", n), "api")
+	s.sendJSON(w, APIResponse{Success: true, Data: map[string]int{"created": n}})
+}
+
+// targetFilter reads the tag filter of a request's query.
+func targetFilter(r *http.Request) db.TargetFilter {
+	q := r.URL.Query()
+	return db.TargetFilter{Vendor: q.Get("vendor"), Customer: q.Get("customer"), Region: q.Get("region")}
+}
+
+// expandTargets returns the hosts of entries, expanding CIDR ranges to
+// their addresses, without duplicates. Other entries (addresses, names,
+// host:port and URLs) are kept as they are.
+func expandTargets(entries []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var out []string
+	add := func(h string) error {
+		if h = db.NormalizeTargetHost(h); h == "" || seen[h] {
+			return nil
+		}
+		if len(out) == maxTargetImport {
+			return fmt.Errorf("more than %d targets", maxTargetImport)
+		}
+		seen[h] = true
+		out = append(out, h)
+		return nil
+	}
+	for _, e := range entries {
+		e = strings.TrimSuffix(strings.TrimSpace(e), "/")
+		if !strings.Contains(e, "/") || strings.Contains(e, "://") {
+			if err := add(e); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ip, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q", e)
+		}
+		if ones, bits := n.Mask.Size(); bits-ones > 16 {
+			return nil, fmt.Errorf("range %s is larger than /%d", e, bits-16)
+		}
+		for ip = ip.Mask(n.Mask); n.Contains(ip); ip = nextIP(ip) {
+			if err := add(ip.String()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// nextIP returns the address following ip.
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i]++; next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
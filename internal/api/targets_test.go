@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vpn-bruteforce-client/internal/config"
+	dbpkg "vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/discover"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+func TestTargets(t *testing.T) {
+	d, err := dbpkg.Connect(dbpkg.Config{Driver: dbpkg.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+	srv := NewServer(stats.New(), 0, d)
+
+	var resp struct {
+		Success bool
+		Error   string
+		Data    json.RawMessage
+		Meta    *MetaData
+	}
+	do := func(rec *httptest.ResponseRecorder) {
+		t.Helper()
+		resp.Error = ""
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+			t.Fatalf("response %s: %v", rec.Body, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.handleTargets(rec, httptest.NewRequest("POST", "/api/targets", strings.NewReader(
+		`{"hosts":["vpn.acme.example","VPN.acme.example/"],"cidrs":["10.0.0.0/30"],"vendor":"fortinet","customer":"acme"}`)))
+	do(rec)
+	var counts map[string]int
+	json.Unmarshal(resp.Data, &counts)
+	if counts["added"] != 5 {
+		t.Fatalf("import = %v", counts)
+	}
+
+	req := httptest.NewRequest("POST", "/api/targets?customer=globex&region=eu", strings.NewReader("# gateways\n10.0.0.1\nhttps://gw.globex.example\n"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	srv.handleTargets(rec, req)
+	do(rec)
+	json.Unmarshal(resp.Data, &counts)
+	if counts["added"] != 1 || counts["duplicates"] != 1 {
+		t.Fatalf("file import = %v", counts)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleTargets(rec, httptest.NewRequest("GET", "/api/targets?customer=acme&page_size=2", nil))
+	do(rec)
+	var list []dbpkg.Target
+	json.Unmarshal(resp.Data, &list)
+	if resp.Meta.TotalItems != 5 || len(list) != 2 || list[0].Host != "vpn.acme.example" || list[1].Host != "10.0.0.0" {
+		t.Fatalf("list = %+v, meta %+v", list, resp.Meta)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleTargetsTag(rec, httptest.NewRequest("POST", "/api/targets/tag", strings.NewReader(
+		`{"ids":[2,3],"region":"us"}`)))
+	do(rec)
+
+	rec = httptest.NewRecorder()
+	srv.handleTargetTasks(rec, httptest.NewRequest("POST", "/api/targets/tasks", strings.NewReader(
+		`{"region":"us","credentials":[{"login":"admin","password":"admin"},{"login":"vpn","password":"vpn"}]}`)))
+	do(rec)
+	json.Unmarshal(resp.Data, &counts)
+	if counts["created"] != 4 {
+		t.Fatalf("tasks = %v", counts)
+	}
+	var n int
+	d.QueryRow(`SELECT COUNT(*) FROM tasks WHERE vendor = 'fortinet' AND status = 'pending' AND url IN ('10.0.0.0', '10.0.0.1')`).Scan(&n)
+	if n != 4 {
+		t.Fatalf("%d tasks stored", n)
+	}
+
+	// gw.globex.example has no vendor tag.
+	rec = httptest.NewRecorder()
+	srv.handleTargetTasks(rec, httptest.NewRequest("POST", "/api/targets/tasks", strings.NewReader(
+		`{"customer":"globex","credentials":[{"login":"admin","password":"admin"}]}`)))
+	if json.Unmarshal(rec.Body.Bytes(), &resp); resp.Success {
+		t.Fatal("target without vendor accepted")
+	}
+
+	if _, err := expandTargets([]string{"10.0.0.0/8"}); err == nil {
+		t.Fatal("oversized range accepted")
+	}
+}
+
+func TestTargetsImportScan(t *testing.T) {
+	d, err := dbpkg.Connect(dbpkg.Config{Driver: dbpkg.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+	srv := NewServer(stats.New(), 0, d)
+
+	scan := `[{"ip": "203.0.113.5", "ports": [{"port": 10443, "proto": "tcp", "service": {"name": "http", "banner": "Server: FortiGate"}}]},
+{"ip": "203.0.113.6", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]}]`
+	for i, wantAdded := range []int{2, 0} {
+		rec := httptest.NewRecorder()
+		srv.handleTargetsImport(rec, httptest.NewRequest("POST", "/api/targets/import?customer=acme", strings.NewReader(scan)))
+		var resp struct {
+			Success bool
+			Data    struct {
+				Hosts, Added int
+				VendorURLs   int `json:"vendor_urls"`
+			}
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+			t.Fatalf("response %s: %v", rec.Body, err)
+		}
+		if resp.Data.Hosts != 2 || resp.Data.Added != wantAdded || resp.Data.VendorURLs != 1-i {
+			t.Fatalf("import %d = %+v", i, resp.Data)
+		}
+	}
+
+	list, _, err := d.ListTargets(dbpkg.TargetFilter{Customer: "acme", Vendor: "fortinet"}, 1, 10)
+	if err != nil || len(list) != 1 || list[0].Host != "203.0.113.5:10443" {
+		t.Fatalf("fortinet targets = %+v, %v", list, err)
+	}
+	var url string
+	if err := d.QueryRow(`SELECT url FROM vendor_urls`).Scan(&url); err != nil || url != "https://203.0.113.5:10443" {
+		t.Fatalf("vendor url = %q, %v", url, err)
+	}
+}
+
+func TestTargetsDiscover(t *testing.T) {
+	shodan := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total": 1, "matches": [{"ip_str": "198.51.100.7", "port": 443}]}`)
+	}))
+	defer shodan.Close()
+
+	d, err := dbpkg.Connect(dbpkg.Config{Driver: dbpkg.DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+	srv := NewServer(stats.New(), 0, d)
+	srv.EnableDiscovery(config.DiscoveryConfig{Shodan: config.ShodanConfig{APIKey: "k", APIURL: shodan.URL}, MaxPages: 2})
+
+	rec := httptest.NewRecorder()
+	srv.handleTargetsDiscover(rec, httptest.NewRequest("POST", "/api/targets/discover", strings.NewReader(
+		`{"engine":"shodan","vendor":"citrix","customer":"acme"}`)))
+	var resp struct {
+		Success bool
+		Error   string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+		t.Fatalf("response %s: %v", rec.Body, err)
+	}
+	list, _, err := d.ListTargets(dbpkg.TargetFilter{Vendor: "citrix"}, 1, 10)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("targets = %+v, %v", list, err)
+	}
+	if got := list[0]; got.Host != "198.51.100.7" || got.Customer != "acme" || got.Source != "shodan" || got.SourceRef != discover.Dorks["shodan"]["citrix"] {
+		t.Fatalf("target = %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.handleTargetsDiscover(rec, httptest.NewRequest("POST", "/api/targets/discover", strings.NewReader(
+		`{"engine":"censys","vendor":"citrix"}`)))
+	if json.Unmarshal(rec.Body.Bytes(), &resp); resp.Success {
+		t.Fatal("unconfigured engine accepted")
+	}
+}
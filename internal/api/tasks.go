@@ -0,0 +1,132 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"vpn-bruteforce-client/internal/db"
+)
+
+// taskStatePoll is how often task changes, made by the API or by scanners
+// writing to the database, are pushed to WebSocket clients.
+const taskStatePoll = 2 * time.Second
+
+// taskReclaimInterval is how often tasks whose worker lease expired are
+// returned to the queue; taskMaxAttempts claims of a task fail it.
+const (
+	taskReclaimInterval = 30 * time.Second
+	taskMaxAttempts     = 3
+)
+
+// handleTaskState moves a task to a new state and/or records its
+// progress. State changes the task state machine does not allow are
+// rejected.
+func (s *Server) handleTaskState(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAuth(w, r) {
+		return
+	}
+	if s.db == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "database unavailable"})
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid id"})
+		return
+	}
+	var req struct {
+		Status   string `json:"status"`
+		Progress *int   `json:"progress"`
+		Error    string `json:"error"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "invalid json"})
+		return
+	}
+	if req.Status == "" && req.Progress == nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: "status or progress required"})
+		return
+	}
+	if req.Status != "" {
+		err = s.db.SetTaskStatus(id, req.Status, req.Error)
+	}
+	if err == nil && req.Progress != nil {
+		err = s.db.SetTaskProgress(id, *req.Progress)
+	}
+	if err == sql.ErrNoRows {
+		s.sendJSON(w, APIResponse{Success: false, Error: "task not found"})
+		return
+	}
+	if err != nil {
+		s.sendJSON(w, APIResponse{Success: false, Error: err.Error()})
+		return
+	}
+	clearCacheByPrefix("tasks")
+	s.sendJSON(w, APIResponse{Success: true})
+}
+
+// runTaskStates broadcasts a task_state message for every task whose
+// state or progress changed and reclaims tasks of dead workers until stop
+// is closed.
+func (s *Server) runTaskStates(stop <-chan struct{}) {
+	since := time.Now()
+	seen := make(map[int]db.TaskState)
+	t := time.NewTicker(taskStatePoll)
+	defer t.Stop()
+	reclaim := time.NewTicker(taskReclaimInterval)
+	defer reclaim.Stop()
+	for {
+		select {
+		case <-t.C:
+			since = s.pollTaskStates(since, seen)
+		case <-reclaim.C:
+			s.reclaimTasks()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reclaimTasks requeues the tasks whose worker lease expired.
+func (s *Server) reclaimTasks() {
+	reclaimed, failed, err := s.db.ReclaimExpiredTasks(taskMaxAttempts)
+	if err != nil {
+		log.Printf("task reclaim error: %v", err)
+		return
+	}
+	if reclaimed > 0 || failed > 0 {
+		clearCacheByPrefix("tasks")
+		s.logEvent("warning", fmt.Sprintf("worker leases expired: %d task(s) requeued, %d failed after %d attempts",
+			reclaimed, failed, taskMaxAttempts), "tasks")
+	}
+}
+
+// pollTaskStates broadcasts the tasks changed since since and not yet
+// reported, and returns the next poll start. seen holds the last state
+// broadcast per task.
+func (s *Server) pollTaskStates(since time.Time, seen map[int]db.TaskState) time.Time {
+	states, err := s.db.TaskStatesSince(since)
+	if err != nil {
+		log.Printf("task states error: %v", err)
+		return since
+	}
+	for _, st := range states {
+		prev, ok := seen[st.ID]
+		if ok && prev.Status == st.Status && prev.Progress == st.Progress && prev.Error == st.Error && prev.Result == st.Result {
+			continue
+		}
+		seen[st.ID] = st
+		s.wsServer.BroadcastMessage("task_state", st)
+		if st.UpdatedAt.After(since) {
+			since = st.UpdatedAt
+		}
+	}
+	return since
+}
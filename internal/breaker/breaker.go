@@ -0,0 +1,176 @@
+// Package breaker implements circuit breakers keyed by target host or
+// proxy. A breaker opens after a number of consecutive connection errors
+// and rejects requests for a cooldown, then lets a single probe through
+// (half-open): the probe's success closes the breaker, its failure opens
+// it again.
+package breaker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the state of a breaker.
+type State string
+
+// Breaker states.
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half-open"
+)
+
+// ErrOpen is returned for requests shed by an open breaker.
+var ErrOpen = errors.New("circuit open")
+
+// maxKeys is the number of tracked keys above which closed breakers
+// without a recent failure are forgotten.
+const maxKeys = 100000
+
+// Status describes a breaker.
+type Status struct {
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key"`
+	State    State     `json:"state"`
+	Failures int       `json:"failures"`
+	Trips    int       `json:"trips"`
+	OpenedAt time.Time `json:"opened_at"`
+	RetryAt  time.Time `json:"retry_at"`
+}
+
+type entry struct {
+	state    State
+	failures int
+	trips    int
+	last     time.Time // last failure
+	openedAt time.Time
+	retryAt  time.Time // open: when the probe may go; half-open: when a lost probe is replaced
+}
+
+// Set holds the breakers of one kind of key. A nil Set allows everything.
+type Set struct {
+	kind     string
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewSet returns breakers of kind ("host", "proxy") opening after failures
+// consecutive errors for cooldown. It returns nil, allowing everything,
+// when failures is not positive.
+func NewSet(kind string, failures int, cooldown time.Duration) *Set {
+	if failures <= 0 {
+		return nil
+	}
+	return &Set{kind: kind, failures: failures, cooldown: cooldown, now: time.Now, entries: make(map[string]*entry)}
+}
+
+// Allow reports whether a request to key may be sent. Once the cooldown of
+// an open breaker has passed, one caller is let through as the probe.
+func (s *Set) Allow(key string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[key]
+	if e == nil || e.state == Closed {
+		return true
+	}
+	now := s.now()
+	if now.Before(e.retryAt) {
+		return false
+	}
+	e.state = HalfOpen
+	e.retryAt = now.Add(s.cooldown)
+	return true
+}
+
+// Success records a request to key that reached its peer and closes the
+// breaker.
+func (s *Set) Success(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+}
+
+// Failure records a connection error of key. It reports whether the
+// breaker opened because of it.
+func (s *Set) Failure(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	e := s.entries[key]
+	if e == nil {
+		if len(s.entries) >= maxKeys {
+			s.prune(now)
+		}
+		e = &entry{state: Closed}
+		s.entries[key] = e
+	}
+	e.failures++
+	e.last = now
+	switch e.state {
+	case Open:
+		// A request sent before the breaker opened.
+		return false
+	case Closed:
+		if e.failures < s.failures {
+			return false
+		}
+	}
+	e.state = Open
+	e.trips++
+	e.openedAt = now
+	e.retryAt = now.Add(s.cooldown)
+	return true
+}
+
+// prune forgets closed breakers without a failure in the last cooldown.
+// The caller holds s.mu.
+func (s *Set) prune(now time.Time) {
+	for k, e := range s.entries {
+		if e.state == Closed && now.Sub(e.last) > s.cooldown {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// Tripped returns the open and half-open breakers, most recently opened
+// first, at most limit of them unless limit is 0.
+func (s *Set) Tripped(limit int) []Status {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	var out []Status
+	for k, e := range s.entries {
+		if e.state == Closed {
+			continue
+		}
+		out = append(out, Status{Kind: s.kind, Key: k, State: e.state, Failures: e.failures,
+			Trips: e.trips, OpenedAt: e.openedAt, RetryAt: e.retryAt})
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].OpenedAt.Equal(out[j].OpenedAt) {
+			return out[i].OpenedAt.After(out[j].OpenedAt)
+		}
+		return out[i].Key < out[j].Key
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSet(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewSet("host", 3, time.Minute)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if s.Failure("a") {
+			t.Fatalf("opened after %d failures", i+1)
+		}
+	}
+	if !s.Allow("a") {
+		t.Fatal("closed breaker rejects")
+	}
+	if !s.Failure("a") {
+		t.Fatal("not opened after 3 failures")
+	}
+	if s.Allow("a") || !s.Allow("b") {
+		t.Fatal("open breaker allows or other key rejected")
+	}
+	if st := s.Tripped(0); len(st) != 1 || st[0].Kind != "host" || st[0].Key != "a" || st[0].State != Open {
+		t.Fatalf("tripped = %+v", st)
+	}
+
+	// After the cooldown one probe goes through; its failure reopens.
+	now = now.Add(time.Minute)
+	if !s.Allow("a") || s.Allow("a") {
+		t.Fatal("want exactly one probe")
+	}
+	if st := s.Tripped(0); st[0].State != HalfOpen {
+		t.Fatalf("state = %s", st[0].State)
+	}
+	if !s.Failure("a") || s.Allow("a") {
+		t.Fatal("failed probe does not reopen")
+	}
+
+	// A successful probe closes.
+	now = now.Add(time.Minute)
+	if !s.Allow("a") {
+		t.Fatal("no probe")
+	}
+	s.Success("a")
+	if !s.Allow("a") || len(s.Tripped(0)) != 0 {
+		t.Fatal("success does not close")
+	}
+
+	// A success resets the consecutive failures.
+	s.Failure("c")
+	s.Failure("c")
+	s.Success("c")
+	if s.Failure("c") {
+		t.Fatal("failures not reset by success")
+	}
+}
+
+func TestNilSet(t *testing.T) {
+	s := NewSet("proxy", 0, time.Minute)
+	if s != nil {
+		t.Fatal("disabled set not nil")
+	}
+	if s.Failure("a") || !s.Allow("a") || s.Tripped(0) != nil {
+		t.Fatal("nil set rejects")
+	}
+	s.Success("a")
+}
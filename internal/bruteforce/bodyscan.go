@@ -0,0 +1,162 @@
+package bruteforce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxBodyScan is the hard cap of response body bytes a checker reads.
+const maxBodyScan = 256 << 10
+
+// minScanBuffer is the smallest buffer scanned in; smaller worker buffers
+// are replaced.
+const minScanBuffer = 4096
+
+// bodyMatcher searches response bodies for the indicators of a checker
+// while streaming them through the worker's buffer, so the memory used
+// does not depend on the page size. A match of a final indicator ends the
+// scan early; the other indicators are only recorded.
+type bodyMatcher struct {
+	needles [][]byte
+	fold    []bool // needle is lowercase and matched ignoring ASCII case
+	final   []bool
+	index   map[string]int
+	overlap int // longest needle - 1, kept between chunks
+}
+
+// newBodyMatcher compiles the final indicators, the recorded ones and the
+// recorded ones matched ignoring case. An indicator must be listed once.
+func newBodyMatcher(final, seen, seenFold []string) *bodyMatcher {
+	m := &bodyMatcher{index: make(map[string]int)}
+	add := func(list []string, fold, final bool) {
+		for _, s := range list {
+			if fold {
+				s = string(bytes.ToLower([]byte(s)))
+			}
+			if _, dup := m.index[s]; dup || s == "" {
+				panic(fmt.Sprintf("bodyMatcher: bad indicator %q", s))
+			}
+			m.index[s] = len(m.needles)
+			m.needles = append(m.needles, []byte(s))
+			m.fold = append(m.fold, fold)
+			m.final = append(m.final, final)
+			if len(s)-1 > m.overlap {
+				m.overlap = len(s) - 1
+			}
+		}
+	}
+	add(final, false, true)
+	add(seen, false, false)
+	add(seenFold, true, false)
+	if len(m.needles) > 64 {
+		panic("bodyMatcher: more than 64 indicators")
+	}
+	return m
+}
+
+// bodyScan is the outcome of scanning a body.
+type bodyScan struct {
+	m     *bodyMatcher
+	found uint64 // bit i: needle i occurred
+	final bool   // a final indicator occurred
+	size  int    // body bytes read, at most maxBodyScan
+}
+
+// has reports whether indicator occurred in the body.
+func (s bodyScan) has(indicator string) bool {
+	i, ok := s.m.index[indicator]
+	return ok && s.found&(1<<uint(i)) != 0
+}
+
+// hasAny reports whether one of indicators occurred in the body.
+func (s bodyScan) hasAny(indicators ...string) bool {
+	for _, indicator := range indicators {
+		if s.has(indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan reads the body of hr in chunks of buf until a final indicator
+// matches, the body ends or maxBodyScan bytes were read. It stores the
+// status and the first chunk of the body in resp.
+func (m *bodyMatcher) scan(hr *http.Response, buf []byte, resp *Response) (bodyScan, error) {
+	s := bodyScan{m: m}
+	resp.StatusCode = hr.StatusCode
+	resp.Body = resp.Body[:0]
+	if len(buf) < minScanBuffer || len(buf) <= 2*m.overlap {
+		buf = make([]byte, max(minScanBuffer, 4*m.overlap))
+	}
+
+	keep := 0 // bytes of the previous chunk at the start of buf
+	for s.size < maxBodyScan {
+		n, err := io.ReadFull(hr.Body, buf[keep:min(len(buf), keep+maxBodyScan-s.size)])
+		if n > 0 {
+			if s.size == 0 {
+				resp.Body = append(resp.Body, buf[:n]...)
+			}
+			s.size += n
+			window := buf[:keep+n]
+			if m.match(window, &s) {
+				return s, nil
+			}
+			keep = min(m.overlap, len(window))
+			copy(buf, window[len(window)-keep:])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// match records the needles occurring in window and reports whether one
+// of them is final.
+func (m *bodyMatcher) match(window []byte, s *bodyScan) bool {
+	for i, needle := range m.needles {
+		if s.found&(1<<uint(i)) != 0 {
+			continue
+		}
+		var ok bool
+		if m.fold[i] {
+			ok = containsFold(window, needle)
+		} else {
+			ok = bytes.Contains(window, needle)
+		}
+		if ok {
+			s.found |= 1 << uint(i)
+			if m.final[i] {
+				s.final = true
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsFold reports whether the lowercase needle occurs in b ignoring
+// ASCII case, without allocating.
+func containsFold(b, needle []byte) bool {
+	for i := 0; i+len(needle) <= len(b); i++ {
+		j := 0
+		for ; j < len(needle); j++ {
+			c := b[i+j]
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			if c != needle[j] {
+				break
+			}
+		}
+		if j == len(needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package bruteforce
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func scanString(t *testing.T, m *bodyMatcher, body string, bufSize int) (bodyScan, int, *Response) {
+	t.Helper()
+	cr := &countingReader{r: strings.NewReader(body)}
+	resp := &Response{}
+	s, err := m.scan(&http.Response{StatusCode: 200, Body: io.NopCloser(cr)}, make([]byte, bufSize), resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, cr.n, resp
+}
+
+func TestBodyScan(t *testing.T) {
+	m := newBodyMatcher([]string{"vpn/tunnel"}, []string{"form"}, []string{"Error"})
+
+	// A final indicator across a chunk boundary ends the scan.
+	body := strings.Repeat("x", minScanBuffer-4) + "vpn/tunnel" + strings.Repeat("y", 3*minScanBuffer)
+	s, read, resp := scanString(t, m, body, minScanBuffer)
+	if !s.final || !s.has("vpn/tunnel") {
+		t.Fatal("indicator across chunks not found")
+	}
+	if read >= len(body) {
+		t.Fatalf("read %d of %d bytes after the match", read, len(body))
+	}
+	if len(resp.Body) != minScanBuffer || resp.StatusCode != 200 {
+		t.Fatalf("kept %d body bytes, status %d", len(resp.Body), resp.StatusCode)
+	}
+
+	// Other indicators are recorded, folded ones ignoring case.
+	s, _, _ = scanString(t, m, "<form>LOGIN ERROR</form>", minScanBuffer)
+	if s.final || !s.has("form") || !s.has("error") || s.hasAny("vpn/tunnel") || s.size != 24 {
+		t.Fatalf("scan = %+v", s)
+	}
+
+	// Bodies are read up to the cap.
+	s, read, _ = scanString(t, m, strings.Repeat("z", maxBodyScan+5000)+"vpn/tunnel", 8192)
+	if s.final || s.size != maxBodyScan || read > maxBodyScan+8192 {
+		t.Fatalf("size %d, read %d", s.size, read)
+	}
+}
+
+func TestCheckFortinetLargePage(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == fortinetStatusPath {
+			io.WriteString(w, `{"status":"success"}`)
+			return
+		}
+		io.WriteString(w, strings.Repeat("<!-- padding -->", 2000)+`<a href="/remote/logout">logout</a>`)
+	}))
+	defer srv.Close()
+
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	resp := &Response{}
+	ok, err := e.checkFortinetUltraFast(context.Background(), Credential{IP: srv.URL, Username: "u", Password: "p"}, resp, make([]byte, 8192))
+	if err != nil || !ok {
+		t.Fatalf("ok = %v, err = %v", ok, err)
+	}
+	if len(resp.Body) > 8192 {
+		t.Fatalf("kept %d body bytes", len(resp.Body))
+	}
+}
@@ -0,0 +1,58 @@
+package bruteforce
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ciscoLogonPage is the ASA's login page, fcadbadd skipping its
+// redirect that checks for cookies.
+const ciscoLogonPage = "/+CSCOE+/logon.html?fcadbadd=1"
+
+// maxCiscoGroups bounds the tunnel groups a credential is tried with.
+const maxCiscoGroups = 10
+
+// ciscoGroups returns the tunnel groups offered by the group_list of the
+// ASA's login page at base, once per host and port. It returns the empty
+// group when the page lists none, so the login goes to the default group.
+func (e *Engine) ciscoGroups(ctx context.Context, base string) ([]string, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if groups, ok := e.asaGroups.Load(host); ok {
+		return groups.([]string), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+ciscoLogonPage, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer hr.Body.Close()
+	page, err := io.ReadAll(io.LimitReader(hr.Body, maxBodyScan))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := parseGroupList(page)
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	e.asaGroups.Store(host, groups)
+	return groups, nil
+}
+
+// parseGroupList returns the option values of the group_list select of
+// page, at most maxCiscoGroups.
+func parseGroupList(page []byte) []string {
+	return selectOptions(page, "group_list", maxCiscoGroups)
+}
@@ -0,0 +1,144 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NetScaler nFactor endpoints. Gateways with the classic flow answer them
+// with an error page.
+const (
+	nfRequirementsPath = "/nf/auth/getAuthenticationRequirements.do"
+	nfAuthPath         = "/nf/auth/doAuthentication.do"
+	nfAccept           = "application/vnd.citrix.authenticateresponse-1+xml"
+)
+
+// nfResponse is an nFactor AuthenticateResponse: the outcome of the last
+// step and the credentials the next one requires.
+type nfResponse struct {
+	XMLName      xml.Name        `xml:"AuthenticateResponse"`
+	Status       string          `xml:"Status"`
+	Result       string          `xml:"Result"`
+	StateContext string          `xml:"StateContext"`
+	Requirements []nfRequirement `xml:"AuthenticationRequirements>Requirements>Requirement"`
+
+	cookies []*http.Cookie
+}
+
+type nfRequirement struct {
+	ID    string `xml:"Credential>ID"`
+	Type  string `xml:"Credential>Type"`
+	Label string `xml:"Label>Text"`
+}
+
+// field returns the ID of the first requirement of type typ.
+func (r *nfResponse) field(typ string) string {
+	for _, req := range r.Requirements {
+		if req.Type == typ {
+			return req.ID
+		}
+	}
+	return ""
+}
+
+// citrixRequirements starts an nFactor login at base and returns its
+// first requirements, nil when the gateway uses the classic flow. Which
+// flow a gateway uses is detected once per host and port.
+func (e *Engine) citrixRequirements(ctx context.Context, base string) (*nfResponse, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if nf, ok := e.nfactor.Load(host); ok && !nf.(bool) {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+nfRequirementsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", nfAccept)
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	nf, err := e.nfactorStep(req)
+	if err != nil {
+		return nil, err
+	}
+	e.nfactor.Store(host, nf != nil && nf.field("password") != "")
+	if nf == nil || nf.field("password") == "" {
+		return nil, nil
+	}
+	return nf, nil
+}
+
+// nfactorStep sends req and decodes its AuthenticateResponse, nil when the
+// answer is none.
+func (e *Engine) nfactorStep(req *http.Request) (*nfResponse, error) {
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer hr.Body.Close()
+	if hr.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	var nf nfResponse
+	if xml.NewDecoder(io.LimitReader(hr.Body, maxBodyScan)).Decode(&nf) != nil {
+		return nil, nil
+	}
+	nf.cookies = hr.Cookies()
+	return &nf, nil
+}
+
+// checkCitrixNFactor answers the requirements of start with the
+// credentials of cred. The login is valid when the gateway reports success
+// or asks for a further factor, such as a one-time password, instead of
+// the first one again.
+func (e *Engine) checkCitrixNFactor(ctx context.Context, base string, start *nfResponse, cred Credential, resp *Response) (bool, error) {
+	userField, passField := start.field("username"), start.field("password")
+	form := url.Values{
+		passField:         {cred.Password},
+		"StateContext":    {start.StateContext},
+		"savecredentials": {"false"},
+	}
+	if userField != "" {
+		form.Set(userField, cred.Username)
+	}
+	if button := start.field("button"); button != "" {
+		form.Set(button, "Log On")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+nfAuthPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", nfAccept)
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Connection", "close")
+	req.Close = true
+	for _, c := range start.cookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	nf, err := e.nfactorStep(req)
+	if err != nil {
+		return false, err
+	}
+	if nf == nil {
+		return false, nil
+	}
+	resp.StatusCode = http.StatusOK
+	switch strings.ToLower(nf.Result) {
+	case "success":
+		return true, nil
+	case "more-info":
+		next := nf.field("password")
+		return next != "" && next != passField, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,119 @@
+package bruteforce
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+func TestClientPool(t *testing.T) {
+	p := newClientPool(&config.Config{
+		Timeout:    3 * time.Second,
+		ProxyType:  "socks5",
+		Transports: map[string]config.TransportConfig{"cisco": {Timeout: 10 * time.Second, MinTLSVersion: "1.2"}},
+	})
+
+	a, err := p.get("user:secret@10.0.0.1:1080", "fortinet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := p.get("user:secret@10.0.0.1:1080", "FortiNet"); b != a {
+		t.Fatal("same proxy and vendor not shared")
+	}
+	if name := p.proxy(a); name != "socks5://10.0.0.1:1080" {
+		t.Fatalf("proxy name = %q", name)
+	}
+
+	cisco, _ := p.get("user:secret@10.0.0.1:1080", "cisco")
+	if cisco == a || cisco.Timeout != 10*time.Second {
+		t.Fatalf("cisco client timeout %v", cisco.Timeout)
+	}
+	if v := cisco.Transport.(*http.Transport).TLSClientConfig.MinVersion; v != tls.VersionTLS12 {
+		t.Fatalf("cisco min TLS %x", v)
+	}
+	citrix, _ := p.get("", "citrix")
+	if n := citrix.Transport.(*http.Transport).MaxResponseHeaderBytes; n != 16<<10 || p.proxy(citrix) != "" {
+		t.Fatalf("citrix header limit %d", n)
+	}
+	if p.timeout("fortinet") != 3*time.Second {
+		t.Fatalf("fortinet timeout %v", p.timeout("fortinet"))
+	}
+
+	p.cfg.Transports["sophos"] = config.TransportConfig{MinTLSVersion: "1.5"}
+	if _, err := p.get("", "sophos"); err == nil {
+		t.Fatal("bad TLS version accepted")
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/remote/login":
+			http.SetCookie(w, &http.Cookie{Name: "SVPNCOOKIE", Value: "s1"})
+			http.Redirect(w, r, "/step", http.StatusFound)
+		case "/step":
+			http.SetCookie(w, &http.Cookie{Name: "step", Value: "1"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		case "/home":
+			if c, err := r.Cookie("SVPNCOOKIE"); err == nil && c.Value == "s1" {
+				if _, err := r.Cookie("step"); err == nil {
+					w.Write([]byte("vpn/tunnel"))
+				}
+			}
+		case fortinetStatusPath:
+			if c, err := r.Cookie("SVPNCOOKIE"); err == nil && c.Value == "s1" {
+				w.Write([]byte(`{"status":"success"}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	check := func(tc config.TransportConfig) bool {
+		t.Helper()
+		cfg := &config.Config{Transports: map[string]config.TransportConfig{"fortinet": tc}}
+		e := &Engine{config: cfg, clients: newClientPool(cfg)}
+		client, err := e.clients.get("", "fortinet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Transport.(*http.Transport).TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+		e.client = client
+		ok, err := e.checkFortinetUltraFast(context.Background(), Credential{IP: srv.URL, Username: "u", Password: "p"}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if check(config.TransportConfig{}) {
+		t.Fatal("redirect followed by default")
+	}
+	if check(config.TransportConfig{MaxRedirects: 1}) {
+		t.Fatal("second redirect followed with max_redirects 1")
+	}
+	if !check(config.TransportConfig{MaxRedirects: 2}) {
+		t.Fatal("success page not reached with max_redirects 2")
+	}
+
+	for _, c := range []struct {
+		host  string
+		hosts []string
+		want  bool
+	}{
+		{"vpn.example.com", nil, true},
+		{"sso.example.com", nil, false},
+		{"sso.example.com", []string{"*.example.com"}, true},
+		{"evil.com", []string{"*.example.com", "SSO.example.com"}, false},
+		{"sso.example.com", []string{"SSO.example.com"}, true},
+	} {
+		if got := redirectAllowed(c.host, "VPN.example.com", c.hosts); got != c.want {
+			t.Errorf("redirectAllowed(%s, %v) = %v", c.host, c.hosts, got)
+		}
+	}
+}
@@ -3,23 +3,25 @@ package bruteforce
 import (
 	"bufio"
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"golang.org/x/net/proxy"
 	"golang.org/x/sync/semaphore"
 	"golang.org/x/time/rate"
+	"vpn-bruteforce-client/internal/breaker"
 	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/exclude"
+	"vpn-bruteforce-client/internal/geoip"
+	"vpn-bruteforce-client/internal/scope"
 	"vpn-bruteforce-client/internal/stats"
 )
 
@@ -52,56 +54,82 @@ type Engine struct {
 	actualRPS      int64
 	lastScaleTime  time.Time
 
-	taskBuilder *TaskBuilder
-
-	logger func(level, message, source string)
+	taskBuilder  *TaskBuilder
+	pinned       map[string]*http.Client // host -> client of its task's proxy
+	vendorClient *http.Client            // default proxy of the VPN type
+	clients      *clientPool
+	timeout      time.Duration // request timeout of the VPN type
+	auth         string        // authentication flow of the VPN type
+	authSchemes  sync.Map      // host:port -> scheme its challenge offers, with auth "auto"
+	gpPrelogins  sync.Map      // host:port -> *gpPrelogin, nil when not GlobalProtect
+	asaGroups    sync.Map      // host:port -> tunnel groups of the ASA's login page
+	nfactor      sync.Map      // host:port -> whether the NetScaler uses nFactor
+	swVariants   sync.Map      // host:port -> SonicWall portal variant
+	wgDomains    sync.Map      // host:port -> authentication servers of the Firebox
+	sophosPortal sync.Map      // host:port -> Sophos portal variant
+
+	// Circuit breakers of target hosts and proxies
+	hostBreakers  *breaker.Set
+	proxyBreakers *breaker.Set
+
+	logger     func(level, message, source string)
+	onFinding  func(cred Credential)
+	onResult   func(cred Credential, result string, err error)
+	source     Source
+	priority   func(username, password string) float64
+	userAgents *userAgentPool
+	pacer      *pacer
+	exclusions *exclude.List
+	scope      *scope.Scope
+	refused    sync.Map // host -> struct{}, out-of-scope hosts already logged
 }
 
+// Errors of targets that cannot take the credentials of the configured VPN
+// type; checkers return them without sending the credentials.
+var (
+	errWrongVendor     = errors.New("not a portal of this VPN type")
+	errNoPasswordLogin = errors.New("portal takes no password login")
+)
+
 type Credential struct {
 	IP       string
 	Username string
 	Password string
+	// TaskID is the tasks table row the credential came from, 0 for
+	// credentials read from the input file.
+	TaskID int
+	// Variant is the portal flavour a valid login matched, for vendors
+	// with several; set before the finding handler runs.
+	Variant string
 }
 
+// Source feeds credentials to the engine in place of the input file. It
+// must close out when it has no more credentials or ctx is done.
+type Source func(ctx context.Context, out chan<- Credential)
+
 type Response struct {
 	StatusCode int
 	Body       []byte
 	Headers    map[string]string
 	Duration   time.Duration
+	Variant    string // portal flavour the checker detected
 }
 
 func New(cfg *config.Config, statsManager *stats.Stats, builder *TaskBuilder) (*Engine, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create ultra-aggressive HTTP client
-	transport := &http.Transport{
-		MaxIdleConns:        cfg.MaxIdleConns * 2,
-		MaxConnsPerHost:     cfg.MaxConnsPerHost * 2,
-		IdleConnTimeout:     cfg.IdleConnTimeout / 2,
-		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout / 2,
-		DisableKeepAlives:   true,
-		DisableCompression:  true,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-			MinVersion:         tls.VersionTLS10,
-		},
-		DialContext: (&net.Dialer{
-			Timeout:   cfg.Timeout / 2,
-			KeepAlive: 0,
-			DualStack: true,
-		}).DialContext,
-		ForceAttemptHTTP2:      false,
-		MaxResponseHeaderBytes: 2048,
-		WriteBufferSize:        4096,
-		ReadBufferSize:         4096,
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   cfg.Timeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+	clients := newClientPool(cfg)
+	client, err := clients.get("", cfg.VPNType)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	timing, err := timingProfile(cfg.Timing)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
 
 	outputFile, err := os.OpenFile(cfg.OutputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -120,6 +148,9 @@ func New(cfg *config.Config, statsManager *stats.Stats, builder *TaskBuilder) (*
 		config:         cfg,
 		stats:          statsManager,
 		client:         client,
+		clients:        clients,
+		timeout:        clients.timeout(cfg.VPNType),
+		auth:           clients.auth(cfg.VPNType),
 		semaphore:      semaphore.NewWeighted(int64(cfg.Threads)),
 		rateLimiter:    rateLimiter,
 		outputFile:     outputFile,
@@ -130,7 +161,12 @@ func New(cfg *config.Config, statsManager *stats.Stats, builder *TaskBuilder) (*
 		lastScaleTime:  time.Now(),
 		taskBuilder:    builder,
 		logger:         nil,
+		userAgents:     newUserAgentPool(cfg.UserAgents, cfg.VPNType),
+		pacer:          newPacer(timing),
+		hostBreakers:   breaker.NewSet("host", cfg.Breaker.HostFailures, cfg.Breaker.Cooldown),
+		proxyBreakers:  breaker.NewSet("proxy", cfg.Breaker.ProxyFailures, cfg.Breaker.Cooldown),
 	}
+	statsManager.SetBreakers(engine.trippedBreakers)
 
 	// Initialize object pools for zero-allocation
 	engine.credentialPool.New = func() interface{} {
@@ -148,21 +184,90 @@ func New(cfg *config.Config, statsManager *stats.Stats, builder *TaskBuilder) (*
 	if cfg.ProxyEnabled {
 		engine.setupProxyClients()
 	}
+	engine.setupTaskProxies()
+
+	// Every run gets an id unless the caller resumes an earlier one.
+	if statsManager.RunID() == "" {
+		statsManager.SetRunID(stats.NewRunID(time.Now()))
+	}
 
 	return engine, nil
 }
 
+// RunID identifies this run in stats files, logs, findings and reports.
+func (e *Engine) RunID() string {
+	return e.stats.RunID()
+}
+
 // SetLogger registers a logger callback for error reporting.
 func (e *Engine) SetLogger(fn func(level, message, source string)) {
 	e.logger = fn
 }
 
-func (e *Engine) setupProxyClients() {
-	baseTransport, ok := e.client.Transport.(*http.Transport)
-	if !ok {
+// SetFindingHandler registers a callback invoked for every valid
+// credential, in addition to writing it to the output file.
+func (e *Engine) SetFindingHandler(fn func(cred Credential)) {
+	e.onFinding = fn
+}
+
+// SetSource makes the engine process the credentials of src instead of
+// the input file.
+func (e *Engine) SetSource(src Source) {
+	e.source = src
+}
+
+// SetResultHandler registers a callback invoked once for every credential
+// checked or skipped, with result stats.ResultGood or stats.ResultBad, or
+// with the error that prevented a check. Credentials left unprocessed when
+// the engine stops are not reported.
+func (e *Engine) SetResultHandler(fn func(cred Credential, result string, err error)) {
+	e.onResult = fn
+}
+
+// report passes the outcome for cred to the result handler.
+func (e *Engine) report(cred Credential, result string, err error) {
+	if e.onResult != nil {
+		e.onResult(cred, result, err)
+	}
+}
+
+// priorityWindow is how many input lines are reordered at a time when a
+// priority is set, bounding the memory used for it.
+const priorityWindow = 10000
+
+// SetPriority makes the engine try the credentials of the input file with
+// a higher score first. Lines are reordered within windows of
+// priorityWindow lines; equal scores keep the file order.
+func (e *Engine) SetPriority(score func(username, password string) float64) {
+	e.priority = score
+}
+
+// SetExclusions makes the engine skip targets matched by l. Skipped
+// targets are counted per rule in the stats.
+func (e *Engine) SetExclusions(l *exclude.List) {
+	e.exclusions = l
+}
+
+// SetScope makes the engine refuse every target outside s or attempted
+// outside the engagement dates.
+func (e *Engine) SetScope(s *scope.Scope) {
+	e.scope = s
+}
+
+// refuse counts and logs an out-of-scope target. Each host is logged once.
+func (e *Engine) refuse(ip string, err error) {
+	e.stats.RecordExcluded("out_of_scope")
+	if _, seen := e.refused.LoadOrStore(ip, struct{}{}); seen {
 		return
 	}
+	msg := fmt.Sprintf("refused out-of-scope target %s: %v", ip, err)
+	log.Printf("⛔ %s", msg)
+	if e.logger != nil {
+		e.logger("warning", msg, "scope")
+	}
+}
 
+func (e *Engine) setupProxyClients() {
 	proxyList := e.config.ProxyList
 	if e.taskBuilder != nil && len(e.taskBuilder.ProxyList) > 0 {
 		proxyList = e.taskBuilder.ProxyList
@@ -175,84 +280,141 @@ func (e *Engine) setupProxyClients() {
 		if addr == "" {
 			continue
 		}
-
-		tr := baseTransport.Clone()
-
-		switch strings.ToLower(e.config.ProxyType) {
-		case "socks5", "socks", "socks5h":
-			// Allow user:pass@host:port as well as plain host:port
-			proxyURL, err := url.Parse(addr)
-			if err != nil || proxyURL.Host == "" {
-				proxyURL, _ = url.Parse("socks5://" + addr)
-			}
-
-			var auth *proxy.Auth
-			if proxyURL.User != nil {
-				pass, _ := proxyURL.User.Password()
-				auth = &proxy.Auth{User: proxyURL.User.Username(), Password: pass}
-			}
-
-			d, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, &net.Dialer{
-				Timeout:   e.config.Timeout / 2,
-				KeepAlive: 0,
-				DualStack: true,
-			})
-			if err != nil {
-				fmt.Printf("failed to init SOCKS5 proxy %s: %v\n", addr, err)
-				continue
-			}
-
-			if cd, ok := d.(proxy.ContextDialer); ok {
-				tr.DialContext = cd.DialContext
-			} else {
-				tr.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
-					return d.Dial(network, address)
-				}
-			}
-			tr.Proxy = nil
-
-		default:
-			proxyURL, err := url.Parse(addr)
-			if err != nil || proxyURL.Scheme == "" {
-				proxyURL, _ = url.Parse(fmt.Sprintf("%s://%s", e.config.ProxyType, addr))
-			}
-
-			tr.Proxy = http.ProxyURL(proxyURL)
-			tr.DialContext = (&net.Dialer{
-				Timeout:   e.config.Timeout / 2,
-				KeepAlive: 0,
-				DualStack: true,
-			}).DialContext
+		client, err := e.newProxyClient(addr)
+		if err != nil {
+			fmt.Printf("failed to init proxy %s: %v\n", addr, err)
+			continue
 		}
+		e.proxyClients = append(e.proxyClients, client)
+	}
+}
 
-		client := &http.Client{
-			Transport: tr,
-			Timeout:   e.config.Timeout,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
+// setupTaskProxies creates the clients of the proxies pinned by tasks to
+// their target hosts and of the default proxy of the engine's VPN type.
+// Only tasks of that type (or without a vendor) apply.
+func (e *Engine) setupTaskProxies() {
+	if e.taskBuilder == nil {
+		return
+	}
+	vendor := strings.ToLower(e.config.VPNType)
+	if addr := e.taskBuilder.VendorProxies[vendor]; addr != "" {
+		if client, err := e.newProxyClient(addr); err == nil {
+			e.vendorClient = client
+		} else {
+			fmt.Printf("failed to init %s proxy %s: %v\n", vendor, addr, err)
 		}
-
-		e.proxyClients = append(e.proxyClients, client)
 	}
+	for _, t := range e.taskBuilder.Tasks {
+		addr := strings.TrimSpace(t.Proxy)
+		if addr == "" || (t.Vendor != "" && strings.ToLower(t.Vendor) != vendor) {
+			continue
+		}
+		host := strings.ToLower(geoip.Host(t.URL))
+		if host == "" {
+			continue
+		}
+		client, err := e.newProxyClient(addr)
+		if err != nil {
+			fmt.Printf("failed to init proxy %s for task %d: %v\n", addr, t.ID, err)
+			continue
+		}
+		if e.pinned == nil {
+			e.pinned = make(map[string]*http.Client)
+		}
+		e.pinned[host] = client
+	}
+}
+
+// newProxyClient returns the pooled client sending requests of the
+// engine's VPN type through the proxy at addr.
+func (e *Engine) newProxyClient(addr string) (*http.Client, error) {
+	return e.clients.get(addr, e.config.VPNType)
 }
 
+// getHTTPClient returns the direct client or the next proxy of the pool
+// whose breaker lets a request through, nil when all of them are open.
 func (e *Engine) getHTTPClient() *http.Client {
 	if !e.config.ProxyEnabled || len(e.proxyClients) == 0 {
 		return e.client
 	}
 
 	if !e.config.ProxyRotation || len(e.proxyClients) == 1 {
+		if !e.proxyBreakers.Allow(e.clients.proxy(e.proxyClients[0])) {
+			return nil
+		}
 		return e.proxyClients[0]
 	}
 
-	idx := atomic.AddInt64(&e.currentProxy, 1)
-	return e.proxyClients[int(idx)%len(e.proxyClients)]
+	idx := int(atomic.AddInt64(&e.currentProxy, 1))
+	for i := 0; i < len(e.proxyClients); i++ {
+		client := e.proxyClients[(idx+i)%len(e.proxyClients)]
+		if e.proxyBreakers.Allow(e.clients.proxy(client)) {
+			return client
+		}
+	}
+	return nil
 }
 
+// doRequest sends req through the client pickClient chooses.
 func (e *Engine) doRequest(req *http.Request) (*http.Response, error) {
-	client := e.getHTTPClient()
-	return client.Do(req)
+	client, err := e.pickClient(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	e.proxyResult(client, err)
+	return resp, err
+}
+
+// pickClient returns the client of the proxy pinned to the host of req by
+// a task, the vendor's default proxy or the rotating proxy pool, in that
+// order. The request is shed when the breaker of the chosen proxy is open.
+func (e *Engine) pickClient(req *http.Request) (*http.Client, error) {
+	client, ok := e.pinned[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		client = e.vendorClient
+	}
+	if client != nil {
+		if addr := e.clients.proxy(client); !e.proxyBreakers.Allow(addr) {
+			return nil, fmt.Errorf("proxy %s: %w", addr, breaker.ErrOpen)
+		}
+	} else if client = e.getHTTPClient(); client == nil {
+		return nil, fmt.Errorf("all proxies: %w", breaker.ErrOpen)
+	}
+	return client, nil
+}
+
+// proxyResult counts the outcome of a request sent with client against
+// the breaker of its proxy.
+func (e *Engine) proxyResult(client *http.Client, err error) {
+	addr := e.clients.proxy(client)
+	if addr == "" {
+		return
+	}
+	switch {
+	case err == nil:
+		e.proxyBreakers.Success(addr)
+	case e.ctx == nil || e.ctx.Err() == nil:
+		if e.proxyBreakers.Failure(addr) && e.logger != nil {
+			e.logger("warning", fmt.Sprintf("circuit open for proxy %s", addr), "breaker")
+		}
+	}
+}
+
+// hostFailed counts a connection error against the breaker of ip.
+func (e *Engine) hostFailed(ip string) {
+	if e.hostBreakers.Failure(ip) && e.logger != nil {
+		e.logger("warning", fmt.Sprintf("circuit open for %s", ip), "breaker")
+	}
+}
+
+// maxTrippedHosts bounds the host breakers listed in the stats file.
+const maxTrippedHosts = 100
+
+// trippedBreakers returns the open breakers of all proxies and of the most
+// recently tripped hosts.
+func (e *Engine) trippedBreakers() []breaker.Status {
+	return append(e.proxyBreakers.Tripped(0), e.hostBreakers.Tripped(maxTrippedHosts)...)
 }
 
 func (e *Engine) Start() error {
@@ -266,7 +428,13 @@ func (e *Engine) Start() error {
 	credChan := make(chan Credential, 10000)
 
 	// Start credential loader
-	go e.loadCredentialsStream(credChan)
+	if e.source != nil {
+		go e.source(e.ctx, credChan)
+	} else {
+		e.stats.SetCurrentFile(e.config.InputFile)
+		go e.countCredentials()
+		go e.loadCredentialsStream(credChan)
+	}
 
 	// Start dynamic thread scaler
 	if e.config.AutoScale {
@@ -283,10 +451,16 @@ func (e *Engine) Start() error {
 	fmt.Printf("🔧 VPN Type: %s | Auto-scale: %v | Streaming: %v\n\n",
 		e.config.VPNType, e.config.AutoScale, e.config.StreamingMode)
 
+	// Hold attempts back for their host's slot of the timing profile
+	var work <-chan Credential = credChan
+	if e.pacer != nil && e.pacer.active() {
+		work = e.pace(credChan, e.pacer)
+	}
+
 	// Start worker pool
 	for i := 0; i < int(e.currentThreads); i++ {
 		e.wg.Add(1)
-		go e.ultraFastWorker(credChan)
+		go e.ultraFastWorker(work)
 	}
 
 	// Wait for completion
@@ -314,6 +488,27 @@ func (e *Engine) ultraFastWorker(credChan <-chan Credential) {
 }
 
 func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
+	if rule, ok := e.exclusions.Match(cred.IP); ok {
+		e.stats.RecordExcluded(rule.String())
+		e.report(cred, "", fmt.Errorf("excluded by %s", rule))
+		return
+	}
+	if e.scope != nil {
+		if err := e.scope.Check(cred.IP, time.Now()); err != nil {
+			e.refuse(cred.IP, err)
+			e.report(cred, "", fmt.Errorf("out of scope: %w", err))
+			return
+		}
+	}
+
+	// Shed attempts against hosts that keep failing
+	if !e.hostBreakers.Allow(cred.IP) {
+		e.stats.IncrementOffline()
+		e.recordError(stats.ResultOffline, "circuit_open")
+		e.report(cred, "", fmt.Errorf("%s: %w", cred.IP, breaker.ErrOpen))
+		return
+	}
+
 	// Rate limiting
 	if e.rateLimiter != nil {
 		if err := e.rateLimiter.Wait(e.ctx); err != nil {
@@ -331,6 +526,7 @@ func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
 	resp := e.responsePool.Get().(*Response)
 	defer func() {
 		resp.Body = resp.Body[:0] // Reset slice
+		resp.Variant = ""
 		for k := range resp.Headers {
 			delete(resp.Headers, k)
 		}
@@ -338,12 +534,13 @@ func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
 	}()
 
 	// Create request context with timeout
-	ctx, cancel := context.WithTimeout(e.ctx, e.config.Timeout)
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
 	defer cancel()
 
 	start := time.Now()
 	success, err := e.checkVPNUltraFast(ctx, cred, resp, buf)
 	duration := time.Since(start)
+	e.stats.RecordLatency(e.config.VPNType, duration)
 
 	// Update RPS counter
 	atomic.AddInt64(&e.actualRPS, 1)
@@ -351,12 +548,21 @@ func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
 	// ✅ УЛУЧШЕННАЯ ОБРАБОТКА ОШИБОК И РЕЗУЛЬТАТОВ
 	if err != nil {
 		e.handleAdvancedError(cred.IP, err, duration)
+		e.report(cred, "", err)
 		return
 	}
+	e.hostBreakers.Success(cred.IP)
 
 	if success {
+		cred.Variant = resp.Variant
+		e.report(cred, stats.ResultGood, nil)
 		e.stats.IncrementGoods()
+		e.stats.RecordResult(e.config.VPNType, stats.ResultGood)
+		e.stats.RecordHit(e.config.VPNType, cred.IP, cred.Username)
 		e.saveValidUltraFast(cred)
+		if e.onFinding != nil {
+			e.onFinding(cred)
+		}
 		atomic.StoreInt64(&e.lastSuccessTime, time.Now().Unix())
 
 		if e.config.Verbose {
@@ -364,7 +570,9 @@ func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
 				cred.IP, cred.Username, cred.Password, float64(duration.Nanoseconds())/1e6)
 		}
 	} else {
+		e.report(cred, stats.ResultBad, nil)
 		e.stats.IncrementBads()
+		e.stats.RecordResult(e.config.VPNType, stats.ResultBad)
 		if e.config.Verbose {
 			fmt.Printf("\n❌ INVALID: %s;%s;%s (%.2fms)",
 				cred.IP, cred.Username, cred.Password, float64(duration.Nanoseconds())/1e6)
@@ -373,6 +581,13 @@ func (e *Engine) processCredentialUltraFast(cred Credential, buf []byte) {
 }
 
 func (e *Engine) checkVPNUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
+	switch e.authFlow(ctx, cred) {
+	case AuthBasic:
+		return e.checkBasicAuth(ctx, cred, "", resp)
+	case AuthNTLM:
+		return e.checkNTLMAuth(ctx, cred, resp)
+	}
+
 	switch e.config.VPNType {
 	case "fortinet":
 		return e.checkFortinetUltraFast(ctx, cred, resp, buf)
@@ -388,6 +603,11 @@ func (e *Engine) checkVPNUltraFast(ctx context.Context, cred Credential, resp *R
 		return e.checkCiscoUltraFast(ctx, cred, resp, buf)
 	case "citrix":
 		return e.checkCitrixUltraFast(ctx, cred, resp, buf)
+	case "mikrotik":
+		// Webfig users log in to the REST API of RouterOS 7 as well.
+		return e.checkBasicAuth(ctx, cred, "/rest/system/identity", resp)
+	case "rdweb", "owa", "zyxel", "barracuda", "array", "juniper", "mikrotik_hotspot", "edgerouter", "unifi":
+		return e.checkForm(ctx, formCheckers[e.config.VPNType], cred, resp, buf)
 	default:
 		e.stats.IncrementErrors()
 		return false, fmt.Errorf("unknown VPN type: %s", e.config.VPNType)
@@ -402,51 +622,76 @@ func (e *Engine) handleAdvancedError(ip string, err error, duration time.Duratio
 
 	// ✅ УЛУЧШЕННАЯ КЛАССИФИКАЦИЯ ОШИБОК
 	switch {
+	case errors.Is(err, errWrongVendor):
+		e.stats.IncrementErrors()
+		e.trackError(ip, "wrong_vendor")
+		e.recordError(stats.ResultError, "wrong_vendor")
+	case errors.Is(err, errNoPasswordLogin):
+		e.stats.IncrementErrors()
+		e.trackError(ip, "no_password_login")
+		e.recordError(stats.ResultError, "no_password_login")
 	case strings.Contains(errStr, "timeout") || strings.Contains(errStr, "deadline exceeded"):
 		e.stats.IncrementOffline()
 		e.trackError(ip, "timeout")
+		e.hostFailed(ip)
+		e.recordError(stats.ResultOffline, "timeout")
 		if e.config.Verbose {
 			fmt.Printf("\n⏰ TIMEOUT: %s (%.2fms)", ip, float64(duration.Nanoseconds())/1e6)
 		}
 	case strings.Contains(errStr, "connection refused") || strings.Contains(errStr, "connect: connection refused"):
 		e.stats.IncrementOffline()
 		e.trackError(ip, "refused")
+		e.hostFailed(ip)
+		e.recordError(stats.ResultOffline, "refused")
 		if e.config.Verbose {
 			fmt.Printf("\n🚫 REFUSED: %s", ip)
 		}
 	case strings.Contains(errStr, "no route to host") || strings.Contains(errStr, "network unreachable"):
 		e.stats.IncrementOffline()
 		e.trackError(ip, "unreachable")
+		e.hostFailed(ip)
+		e.recordError(stats.ResultOffline, "unreachable")
 		if e.config.Verbose {
 			fmt.Printf("\n🌐 UNREACHABLE: %s", ip)
 		}
 	case strings.Contains(errStr, "too many requests") || strings.Contains(errStr, "rate limit") || strings.Contains(errStr, "429"):
 		e.stats.IncrementIPBlock()
 		e.trackIPBlock(ip)
+		e.recordError(stats.ResultIPBlock, "rate_limited")
 		if e.config.Verbose {
 			fmt.Printf("\n🚧 RATE_LIMITED: %s", ip)
 		}
 	case strings.Contains(errStr, "certificate") || strings.Contains(errStr, "tls") || strings.Contains(errStr, "ssl"):
 		e.stats.IncrementErrors()
 		e.trackError(ip, "ssl_error")
+		e.recordError(stats.ResultError, "ssl_error")
 		if e.config.Verbose {
 			fmt.Printf("\n🔒 SSL_ERROR: %s", ip)
 		}
-	case duration > e.config.Timeout*2:
+	case duration > e.timeout*2:
 		e.stats.IncrementOffline()
 		e.trackError(ip, "slow")
+		e.hostFailed(ip)
+		e.recordError(stats.ResultOffline, "slow")
 		if e.config.Verbose {
 			fmt.Printf("\n🐌 SLOW: %s (%.2fms)", ip, float64(duration.Nanoseconds())/1e6)
 		}
 	default:
 		e.stats.IncrementErrors()
 		e.trackError(ip, "unknown")
+		e.recordError(stats.ResultError, "unknown")
 		if e.config.Verbose {
 			fmt.Printf("\n❓ ERROR: %s - %s", ip, errStr)
 		}
 	}
 }
 
+// recordError counts an error for the current vendor and its class.
+func (e *Engine) recordError(result, class string) {
+	e.stats.RecordResult(e.config.VPNType, result)
+	e.stats.RecordErrorClass(class)
+}
+
 func (e *Engine) trackError(ip, errorType string) {
 	if errors, ok := e.errorTracker.Load(ip); ok {
 		errorMap := errors.(map[string]int)
@@ -473,6 +718,31 @@ func (e *Engine) trackIPBlock(ip string) {
 	}
 }
 
+// countCredentials reads the input file once more to give the stats a
+// total for progress and ETA reporting.
+func (e *Engine) countCredentials() {
+	file, err := os.Open(e.config.InputFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var total int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.Count(line, ";") < 2 {
+			continue
+		}
+		total++
+		if total%100000 == 0 && e.ctx.Err() != nil {
+			return
+		}
+	}
+	e.stats.SetTotal(total)
+}
+
 func (e *Engine) loadCredentialsStream(credChan chan<- Credential) {
 	defer close(credChan)
 
@@ -490,6 +760,33 @@ func (e *Engine) loadCredentialsStream(credChan chan<- Credential) {
 	scanner := bufio.NewScanner(file)
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // 1MB buffer for large lines
 
+	var window []Credential
+	send := func(cred Credential) bool {
+		select {
+		case credChan <- cred:
+			return true
+		case <-e.ctx.Done():
+			return false
+		}
+	}
+	flush := func() bool {
+		sort.SliceStable(window, func(i, j int) bool {
+			return e.priority(window[i].Username, window[i].Password) > e.priority(window[j].Username, window[j].Password)
+		})
+		for _, cred := range window {
+			if !send(cred) {
+				return false
+			}
+		}
+		window = window[:0]
+		return true
+	}
+	defer func() {
+		if len(window) > 0 && e.ctx.Err() == nil {
+			flush()
+		}
+	}()
+
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -507,9 +804,13 @@ func (e *Engine) loadCredentialsStream(credChan chan<- Credential) {
 			Password: strings.TrimSpace(parts[2]),
 		}
 
-		select {
-		case credChan <- cred:
-		case <-e.ctx.Done():
+		if e.priority != nil {
+			if window = append(window, cred); len(window) == priorityWindow && !flush() {
+				return
+			}
+			continue
+		}
+		if !send(cred) {
 			return
 		}
 	}
@@ -589,4 +890,4 @@ func (e *Engine) rpsMonitor() {
 
 func (e *Engine) Stop() {
 	e.cancel()
-}
\ No newline at end of file
+}
@@ -0,0 +1,120 @@
+package bruteforce
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/breaker"
+	"vpn-bruteforce-client/internal/config"
+)
+
+// forwardProxy records the hosts of the requests it receives.
+func forwardProxy(t *testing.T, hosts *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hosts = append(*hosts, r.URL.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTaskProxies(t *testing.T) {
+	var pinnedHosts, vendorHosts []string
+	pinned := forwardProxy(t, &pinnedHosts)
+	vendor := forwardProxy(t, &vendorHosts)
+
+	e := &Engine{
+		config: &config.Config{VPNType: "fortinet"},
+		client: &http.Client{Transport: &http.Transport{}},
+		taskBuilder: &TaskBuilder{
+			Tasks: []Task{
+				{ID: 1, Vendor: "fortinet", URL: "https://pinned.example:10443", Proxy: pinned.URL},
+				{ID: 2, Vendor: "cisco", URL: "https://other.example", Proxy: pinned.URL},
+			},
+			VendorProxies: map[string]string{"fortinet": vendor.URL},
+		},
+	}
+	e.clients = newClientPool(e.config)
+	e.setupTaskProxies()
+
+	for _, target := range []string{"http://pinned.example/", "http://other.example/"} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		resp, err := e.doRequest(req)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		resp.Body.Close()
+	}
+	if len(pinnedHosts) != 1 || pinnedHosts[0] != "pinned.example" {
+		t.Errorf("pinned proxy saw %v", pinnedHosts)
+	}
+	if len(vendorHosts) != 1 || vendorHosts[0] != "other.example" {
+		t.Errorf("vendor proxy saw %v", vendorHosts)
+	}
+}
+
+func TestProxyBreaker(t *testing.T) {
+	var hosts []string
+	live := forwardProxy(t, &hosts)
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	e := &Engine{
+		config:        &config.Config{ProxyEnabled: true, ProxyRotation: true, ProxyList: []string{dead.URL, live.URL}},
+		client:        &http.Client{Transport: &http.Transport{}},
+		proxyBreakers: breaker.NewSet("proxy", 1, time.Hour),
+	}
+	e.clients = newClientPool(e.config)
+	e.setupProxyClients()
+
+	var failed int
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://target.example/", nil)
+		resp, err := e.doRequest(req)
+		if err != nil {
+			failed++
+			continue
+		}
+		resp.Body.Close()
+	}
+	if failed > 1 || len(hosts) != 4-failed {
+		t.Fatalf("%d failed, live proxy saw %v", failed, hosts)
+	}
+	if st := e.proxyBreakers.Tripped(0); len(st) != 1 || st[0].Key != "http://"+dead.Listener.Addr().String() {
+		t.Fatalf("tripped = %+v", st)
+	}
+
+	live.Close()
+	req, _ := http.NewRequest(http.MethodGet, "http://target.example/", nil)
+	e.doRequest(req)
+	if _, err := e.doRequest(req); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("all proxies open: err = %v", err)
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "creds.txt")
+	os.WriteFile(input, []byte("1.1.1.1;guest;guest\n1.1.1.1;admin;admin\n1.1.1.2;guest;guest\n1.1.1.2;vpn;vpn\n"), 0o644)
+	e := &Engine{config: &config.Config{InputFile: input}}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	defer e.cancel()
+	e.SetPriority(func(username, password string) float64 {
+		return map[string]float64{"admin": 2, "vpn": 1}[username]
+	})
+
+	ch := make(chan Credential, 10)
+	e.loadCredentialsStream(ch)
+	var got []string
+	for c := range ch {
+		got = append(got, c.IP+";"+c.Username)
+	}
+	if want := "1.1.1.1;admin 1.1.1.2;vpn 1.1.1.1;guest 1.1.1.2;guest"; strings.Join(got, " ") != want {
+		t.Fatalf("order = %v, want %s", got, want)
+	}
+}
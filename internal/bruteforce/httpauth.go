@@ -0,0 +1,198 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Authentication flows of the checkers.
+const (
+	AuthForm  = "form"
+	AuthBasic = "basic"
+	AuthNTLM  = "ntlm"
+	AuthAuto  = "auto"
+)
+
+var authModes = []string{AuthForm, AuthBasic, AuthNTLM, AuthAuto}
+
+// authURL is the URL credentials are sent to with HTTP authentication:
+// the target as given, https by default.
+func authURL(target string) string {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return target
+	}
+	return "https://" + target
+}
+
+// authFlow returns the flow the credentials of cred are checked with. With
+// auth "auto" the challenge of the target's portal decides, once per
+// host and port.
+func (e *Engine) authFlow(ctx context.Context, cred Credential) string {
+	if e.auth != AuthAuto {
+		return e.auth
+	}
+	target := authURL(cred.IP)
+	host := target
+	if u, err := url.Parse(target); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if scheme, ok := e.authSchemes.Load(host); ok {
+		return scheme.(string)
+	}
+	scheme, err := e.probeAuth(ctx, target)
+	if err != nil {
+		// Try the form now and probe again next time.
+		return AuthForm
+	}
+	e.authSchemes.Store(host, scheme)
+	return scheme
+}
+
+// probeAuth requests target without credentials and returns the HTTP
+// authentication scheme its challenge offers, NTLM before Basic, or the
+// form flow when there is none.
+func (e *Engine) probeAuth(ctx context.Context, target string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	hr.Body.Close()
+	if hr.StatusCode != http.StatusUnauthorized {
+		return AuthForm, nil
+	}
+	scheme := AuthForm
+	for _, v := range hr.Header.Values("WWW-Authenticate") {
+		name, _, _ := strings.Cut(strings.TrimSpace(v), " ")
+		switch strings.ToLower(name) {
+		case "ntlm":
+			return AuthNTLM, nil
+		case "basic":
+			scheme = AuthBasic
+		}
+	}
+	return scheme, nil
+}
+
+// authAccepted classifies the answer to credentials sent with HTTP
+// authentication: rejected with 401 or 403, accepted with 2xx or 3xx.
+func authAccepted(status int) (bool, error) {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return false, nil
+	case status >= 200 && status < 400:
+		return true, nil
+	}
+	return false, fmt.Errorf("unexpected status %d", status)
+}
+
+// checkBasicAuth sends the credentials of cred with HTTP Basic
+// authentication to path of the target, the target as given when empty.
+func (e *Engine) checkBasicAuth(ctx context.Context, cred Credential, path string, resp *Response) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(authURL(cred.IP), "/")+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.SetBasicAuth(cred.Username, cred.Password)
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+	resp.StatusCode = hr.StatusCode
+	return authAccepted(hr.StatusCode)
+}
+
+// checkNTLMAuth runs the NTLM handshake with the credentials of cred,
+// given as DOMAIN\user or user. Both messages go over one connection, as
+// NTLM authenticates the connection rather than the request.
+func (e *Engine) checkNTLMAuth(ctx context.Context, cred Credential, resp *Response) (bool, error) {
+	target := authURL(cred.IP)
+	newRequest := func(msg []byte) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(msg))
+		req.Header.Set("User-Agent", e.userAgent())
+		return req, nil
+	}
+
+	req, err := newRequest(ntlmNegotiate())
+	if err != nil {
+		return false, err
+	}
+	client, err := e.pickClient(req)
+	if err != nil {
+		return false, err
+	}
+	session := sessionClient(client)
+	defer session.CloseIdleConnections()
+
+	hr, err := session.Do(req)
+	e.proxyResult(client, err)
+	if err != nil {
+		return false, err
+	}
+	msg := ntlmChallengeMessage(hr.Header)
+	io.Copy(io.Discard, io.LimitReader(hr.Body, maxBodyScan))
+	hr.Body.Close()
+	if hr.StatusCode != http.StatusUnauthorized || msg == nil {
+		return false, fmt.Errorf("ntlm: no challenge (status %d)", hr.StatusCode)
+	}
+	challenge, err := parseNTLMChallenge(msg)
+	if err != nil {
+		return false, err
+	}
+
+	if req, err = newRequest(ntlmAuthenticate(challenge, cred.Username, cred.Password)); err != nil {
+		return false, err
+	}
+	if hr, err = session.Do(req); err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+	resp.StatusCode = hr.StatusCode
+	return authAccepted(hr.StatusCode)
+}
+
+// ntlmChallengeMessage returns the decoded NTLM challenge of h, nil when
+// there is none.
+func ntlmChallengeMessage(h http.Header) []byte {
+	for _, v := range h.Values("WWW-Authenticate") {
+		name, token, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if !strings.EqualFold(name, "NTLM") || token == "" {
+			continue
+		}
+		if msg, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token)); err == nil {
+			return msg
+		}
+	}
+	return nil
+}
+
+// sessionClient returns a client like c whose requests reuse one kept-alive
+// connection.
+func sessionClient(c *http.Client) *http.Client {
+	tr, ok := c.Transport.(*http.Transport)
+	if !ok {
+		return c
+	}
+	tr = tr.Clone()
+	tr.DisableKeepAlives = false
+	tr.MaxConnsPerHost = 1
+	return &http.Client{Transport: tr, Timeout: c.Timeout, CheckRedirect: c.CheckRedirect}
+}
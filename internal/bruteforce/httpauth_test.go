@@ -0,0 +1,143 @@
+package bruteforce
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// authEngine returns an engine checking srv with auth mode.
+func authEngine(t *testing.T, srv *httptest.Server, auth string) *Engine {
+	t.Helper()
+	cfg := &config.Config{VPNType: "fortinet", Transports: map[string]config.TransportConfig{"fortinet": {Auth: auth}}}
+	e := &Engine{config: cfg, clients: newClientPool(cfg)}
+	client, err := e.clients.get("", "fortinet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	e.client = client
+	e.auth = e.clients.auth("fortinet")
+	return e
+}
+
+func TestBasicAuth(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); ok && u == "admin" && p == "secret" {
+			w.Write([]byte("welcome"))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="vpn"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	for _, auth := range []string{AuthBasic, AuthAuto} {
+		e := authEngine(t, srv, auth)
+		for _, c := range []struct {
+			password string
+			want     bool
+		}{{"secret", true}, {"wrong", false}} {
+			ok, err := e.checkVPNUltraFast(context.Background(), Credential{IP: srv.URL, Username: "admin", Password: c.password}, &Response{}, make([]byte, 8192))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != c.want {
+				t.Errorf("auth %s, password %s: got %v", auth, c.password, ok)
+			}
+		}
+	}
+}
+
+func TestNTOWFv2(t *testing.T) {
+	// MS-NLMP 4.2.4.
+	key := ntowfv2("User", "Password", "Domain")
+	if got := hex.EncodeToString(key); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Fatalf("ntowfv2 = %s", got)
+	}
+	info := append(append([]byte{2, 0, 12, 0}, utf16le("Domain")...), 1, 0, 12, 0)
+	info = append(append(info, utf16le("Server")...), 0, 0, 0, 0)
+	serverChallenge, _ := hex.DecodeString("0123456789abcdef")
+	clientChallenge := bytes.Repeat([]byte{0xaa}, 8)
+	nt := ntlmv2Response(key, serverChallenge, clientChallenge, make([]byte, 8), info)
+	if got := hex.EncodeToString(nt[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Fatalf("NTProofStr = %s", got)
+	}
+}
+
+func TestNTLMAuth(t *testing.T) {
+	serverChallenge := []byte("srvchall")
+	challenge := func() string {
+		info := append(append([]byte{2, 0, 8, 0}, utf16le("CORP")...), 0, 0, 0, 0)
+		msg := make([]byte, 48)
+		copy(msg, ntlmSignature)
+		binary.LittleEndian.PutUint32(msg[8:], 2)
+		binary.LittleEndian.PutUint32(msg[20:], ntlmFlags)
+		copy(msg[24:], serverChallenge)
+		binary.LittleEndian.PutUint16(msg[40:], uint16(len(info)))
+		binary.LittleEndian.PutUint16(msg[42:], uint16(len(info)))
+		binary.LittleEndian.PutUint32(msg[44:], 48)
+		return base64.StdEncoding.EncodeToString(append(msg, info...))
+	}()
+	field := func(msg []byte, i int) []byte {
+		n := int(binary.LittleEndian.Uint16(msg[12+8*i:]))
+		off := int(binary.LittleEndian.Uint32(msg[16+8*i:]))
+		return msg[off : off+n]
+	}
+	decode := func(b []byte) string {
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(u))
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+		msg, _ := base64.StdEncoding.DecodeString(token)
+		if !ok || len(msg) < 32 {
+			w.Header().Set("WWW-Authenticate", "NTLM")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch binary.LittleEndian.Uint32(msg[8:]) {
+		case 1:
+			w.Header().Set("WWW-Authenticate", "NTLM "+challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			nt := field(msg, 1)
+			key := ntowfv2(decode(field(msg, 3)), "Passw0rd", decode(field(msg, 2)))
+			if len(nt) > 16 && bytes.Equal(nt[:16], hmacMD5(key, serverChallenge, nt[16:])) {
+				w.Write([]byte("welcome"))
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	for _, auth := range []string{AuthNTLM, AuthAuto} {
+		e := authEngine(t, srv, auth)
+		for _, c := range []struct {
+			password string
+			want     bool
+		}{{"Passw0rd", true}, {"wrong", false}} {
+			ok, err := e.checkVPNUltraFast(context.Background(), Credential{IP: srv.URL, Username: `CORP\jdoe`, Password: c.password}, &Response{}, make([]byte, 8192))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != c.want {
+				t.Errorf("auth %s, password %s: got %v", auth, c.password, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package bruteforce
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// TimingProfile spaces the attempts against each host: at most
+// PerHostPerHour attempts per host and hour (0 for no limit), each delayed
+// by a random jitter of up to Jitter.
+type TimingProfile struct {
+	PerHostPerHour int
+	Jitter         time.Duration
+}
+
+// TimingProfiles are the built-in profiles selectable with timing.profile
+// or scan --timing.
+var TimingProfiles = map[string]TimingProfile{
+	"fast":         {},
+	"normal":       {Jitter: 500 * time.Millisecond},
+	"polite":       {PerHostPerHour: 60, Jitter: 5 * time.Second},
+	"low-and-slow": {PerHostPerHour: 6, Jitter: 2 * time.Minute},
+	"paranoid":     {PerHostPerHour: 1, Jitter: 10 * time.Minute},
+}
+
+// timingProfile resolves cfg to a profile; explicit jitter and per-host
+// limits override those of the named profile.
+func timingProfile(cfg config.TimingConfig) (TimingProfile, error) {
+	var p TimingProfile
+	if cfg.Profile != "" {
+		var ok bool
+		if p, ok = TimingProfiles[cfg.Profile]; !ok {
+			names := make([]string, 0, len(TimingProfiles))
+			for name := range TimingProfiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return p, fmt.Errorf("unknown timing profile %q (want %s)", cfg.Profile, strings.Join(names, ", "))
+		}
+	}
+	if cfg.Jitter > 0 {
+		p.Jitter = cfg.Jitter
+	}
+	if cfg.PerHostPerHour > 0 {
+		p.PerHostPerHour = cfg.PerHostPerHour
+	}
+	return p, nil
+}
+
+// pacerMaxPending bounds the credentials held back by the pacer; the input
+// is not read further while it is full.
+const pacerMaxPending = 10000
+
+// pacerPruneHosts is the number of tracked hosts above which hosts
+// without a future slot are forgotten.
+const pacerPruneHosts = 100000
+
+// pacer assigns every attempt the time it may start so that attempts
+// against one host keep the profile's spacing.
+type pacer struct {
+	interval time.Duration
+	jitter   time.Duration
+	rnd      *rand.Rand
+	next     map[string]time.Time // host -> earliest start of its next attempt
+}
+
+func newPacer(p TimingProfile) *pacer {
+	pc := &pacer{jitter: p.Jitter, rnd: rand.New(rand.NewSource(time.Now().UnixNano())), next: make(map[string]time.Time)}
+	if p.PerHostPerHour > 0 {
+		pc.interval = time.Hour / time.Duration(p.PerHostPerHour)
+	}
+	return pc
+}
+
+// active reports whether the pacer delays anything.
+func (p *pacer) active() bool {
+	return p.interval > 0 || p.jitter > 0
+}
+
+// slot returns the start time of the next attempt against host, now or
+// later, and books it.
+func (p *pacer) slot(host string, now time.Time) time.Time {
+	at := now
+	if next, ok := p.next[host]; ok && next.After(at) {
+		at = next
+	}
+	if p.jitter > 0 {
+		at = at.Add(time.Duration(p.rnd.Int63n(int64(p.jitter))))
+	}
+	if len(p.next) >= pacerPruneHosts {
+		for h, t := range p.next {
+			if !t.After(now) {
+				delete(p.next, h)
+			}
+		}
+	}
+	p.next[host] = at.Add(p.interval)
+	return at
+}
+
+type pacedCred struct {
+	at   time.Time
+	seq  uint64
+	cred Credential
+}
+
+// pacedQueue is a min-heap of credentials by start time, in arrival order
+// for equal times.
+type pacedQueue []pacedCred
+
+func (q pacedQueue) Len() int { return len(q) }
+func (q pacedQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q pacedQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pacedQueue) Push(x interface{}) { *q = append(*q, x.(pacedCred)) }
+func (q *pacedQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// pace returns a channel delivering the credentials of in at the start
+// times the pacer assigns them. Credentials of other hosts overtake those
+// waiting for their slot, so workers never sleep for a single host.
+func (e *Engine) pace(in <-chan Credential, p *pacer) <-chan Credential {
+	out := make(chan Credential)
+	go func() {
+		defer close(out)
+		var q pacedQueue
+		var seq uint64
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		for in != nil || q.Len() > 0 {
+			var (
+				recv <-chan Credential
+				send chan<- Credential
+				head Credential
+				wait <-chan time.Time
+			)
+			if q.Len() < pacerMaxPending {
+				recv = in
+			}
+			if q.Len() > 0 {
+				if d := time.Until(q[0].at); d <= 0 {
+					send, head = out, q[0].cred
+				} else {
+					timer.Reset(d)
+					wait = timer.C
+				}
+			}
+			select {
+			case cred, ok := <-recv:
+				if !ok {
+					in = nil
+					break
+				}
+				seq++
+				heap.Push(&q, pacedCred{at: p.slot(cred.IP, time.Now()), seq: seq, cred: cred})
+			case send <- head:
+				heap.Pop(&q)
+			case <-wait:
+			case <-e.ctx.Done():
+				return
+			}
+			timer.Stop()
+		}
+	}()
+	return out
+}
@@ -0,0 +1,67 @@
+package bruteforce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+func TestTimingProfile(t *testing.T) {
+	p, err := timingProfile(config.TimingConfig{Profile: "low-and-slow", Jitter: time.Second})
+	if err != nil || p.PerHostPerHour != 6 || p.Jitter != time.Second {
+		t.Fatalf("profile = %+v, %v", p, err)
+	}
+	if _, err := timingProfile(config.TimingConfig{Profile: "turbo"}); err == nil {
+		t.Fatal("unknown profile accepted")
+	}
+	if newPacer(TimingProfiles["fast"]).active() {
+		t.Fatal("fast profile paces")
+	}
+}
+
+func TestPacerSpacesHosts(t *testing.T) {
+	p := newPacer(TimingProfile{PerHostPerHour: 60})
+	now := time.Now()
+	if at := p.slot("a", now); !at.Equal(now) {
+		t.Fatalf("first slot = %v", at.Sub(now))
+	}
+	if at := p.slot("a", now); at.Sub(now) != time.Minute {
+		t.Fatalf("second slot = %v", at.Sub(now))
+	}
+	if at := p.slot("b", now); !at.Equal(now) {
+		t.Fatalf("other host waits %v", at.Sub(now))
+	}
+
+	p = newPacer(TimingProfile{Jitter: time.Second})
+	for i := 0; i < 20; i++ {
+		if d := p.slot("c", now).Sub(now); d < 0 || d >= time.Duration(i+1)*time.Second {
+			t.Fatalf("slot %d after %v", i, d)
+		}
+	}
+}
+
+func TestPaceLetsOtherHostsOvertake(t *testing.T) {
+	e := &Engine{}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	defer e.cancel()
+	p := newPacer(TimingProfile{PerHostPerHour: 3600 * 20}) // 50ms per host
+
+	in := make(chan Credential, 4)
+	for _, ip := range []string{"a", "a", "b", "c"} {
+		in <- Credential{IP: ip}
+	}
+	close(in)
+	var order string
+	start := time.Now()
+	for c := range e.pace(in, p) {
+		order += c.IP
+	}
+	if order != "abca" {
+		t.Fatalf("order = %s", order)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("second attempt on a after %v", d)
+	}
+}
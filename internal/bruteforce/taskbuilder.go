@@ -1,8 +1,5 @@
 package bruteforce
 
-// TaskBuilder can be used to configure tasks programmatically.
-// At the moment it only provides a proxy list but can be extended
-// with more settings in the future.
 // Task represents a single scanning task loaded from the database.
 // It mirrors the "tasks" table structure used by the API.
 type Task struct {
@@ -14,7 +11,12 @@ type Task struct {
 	Proxy    string `json:"proxy"`
 }
 
+// TaskBuilder can be used to configure tasks programmatically. Requests
+// to the host of a task with a proxy go through that proxy; other requests
+// use the default proxy of the VPN type from VendorProxies (keyed by lower
+// case vendor), then the proxy pool.
 type TaskBuilder struct {
-	ProxyList []string
-	Tasks     []Task
+	ProxyList     []string
+	Tasks         []Task
+	VendorProxies map[string]string
 }
@@ -0,0 +1,57 @@
+package bruteforce
+
+import (
+	"math/rand"
+	"slices"
+	"sync/atomic"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// defaultUserAgents are current desktop browser User-Agent strings.
+var defaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.7; rv:131.0) Gecko/20100101 Firefox/131.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+}
+
+// userAgentPool hands out the User-Agent of each attempt: the pinned one
+// when the vendor is pinned, otherwise the pool entries in turn.
+type userAgentPool struct {
+	agents []string
+	pinned string
+	next   atomic.Uint64
+}
+
+func newUserAgentPool(cfg config.UserAgentConfig, vendor string) *userAgentPool {
+	p := &userAgentPool{agents: defaultUserAgents}
+	if len(cfg.List) > 0 {
+		p.agents = cfg.List
+	}
+	if ua := cfg.Vendors[vendor]; ua != "" {
+		p.pinned = ua
+	} else if slices.Contains(cfg.Pinned, vendor) {
+		p.pinned = p.agents[rand.Intn(len(p.agents))]
+	}
+	return p
+}
+
+func (p *userAgentPool) pick() string {
+	if p.pinned != "" {
+		return p.pinned
+	}
+	return p.agents[(p.next.Add(1)-1)%uint64(len(p.agents))]
+}
+
+// userAgent returns the User-Agent header of the next request.
+func (e *Engine) userAgent() string {
+	if e.userAgents == nil {
+		return defaultUserAgents[0]
+	}
+	return e.userAgents.pick()
+}
@@ -0,0 +1,38 @@
+package bruteforce
+
+import (
+	"testing"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+func TestUserAgentPool(t *testing.T) {
+	cfg := config.UserAgentConfig{
+		List:    []string{"a", "b", "c"},
+		Pinned:  []string{"cisco"},
+		Vendors: map[string]string{"citrix": "fixed"},
+	}
+
+	p := newUserAgentPool(cfg, "fortinet")
+	var got string
+	for i := 0; i < 4; i++ {
+		got += p.pick()
+	}
+	if got != "abca" {
+		t.Fatalf("rotation = %s", got)
+	}
+
+	p = newUserAgentPool(cfg, "cisco")
+	first := p.pick()
+	for i := 0; i < 5; i++ {
+		if ua := p.pick(); ua != first {
+			t.Fatalf("pinned vendor got %s then %s", first, ua)
+		}
+	}
+	if p := newUserAgentPool(cfg, "citrix"); p.pick() != "fixed" || p.pick() != "fixed" {
+		t.Fatal("vendor override not used")
+	}
+	if p := newUserAgentPool(config.UserAgentConfig{}, "fortinet"); p.pick() != defaultUserAgents[0] || p.pick() != defaultUserAgents[1] {
+		t.Fatal("built-in list not used")
+	}
+}
@@ -22,7 +22,7 @@ func (e *Engine) checkFortinet(ctx context.Context, cred Credential) (bool, erro
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Close = true // Force connection close
 
 	resp, err := e.doRequest(req)
@@ -61,7 +61,7 @@ func (e *Engine) checkGlobalProtect(ctx context.Context, cred Credential) (bool,
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Close = true
 
 	resp, err := e.doRequest(req)
@@ -95,7 +95,7 @@ func (e *Engine) checkCitrix(ctx context.Context, cred Credential) (bool, error)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Close = true
 
 	resp, err := e.doRequest(req)
@@ -128,7 +128,7 @@ func (e *Engine) checkCisco(ctx context.Context, cred Credential) (bool, error)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Close = true
 
 	resp, err := e.doRequest(req)
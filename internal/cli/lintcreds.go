@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/credlint"
+)
+
+func newLintCredsCmd(opts *Options) *cobra.Command {
+	var (
+		dir    string
+		fix    bool
+		report string
+	)
+	cmd := &cobra.Command{
+		Use:   "lint-creds",
+		Short: "Validate creds/<vendor>.txt files and optionally normalize them",
+		Long: "Checks field counts per vendor format, duplicate lines, invalid hosts and ports\n" +
+			"and encoding problems. With --fix each file is rewritten with its valid,\n" +
+			"normalized lines; the original is kept as <file>.bak and rejected lines go to\n" +
+			"<file>.rejected. The JSON report is written to --report for the dashboard.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rep, err := credlint.LintDir(dir)
+			if err != nil {
+				return err
+			}
+			if fix {
+				for i := range rep.Files {
+					if err := credlint.Fix(&rep.Files[i]); err != nil {
+						return fmt.Errorf("fix %s: %w", rep.Files[i].File, err)
+					}
+				}
+			}
+			if report == "" {
+				report = filepath.Join(dir, credlint.ReportFile)
+			}
+			if report != "-" {
+				data, err := json.MarshalIndent(rep, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(report, data, 0o644); err != nil {
+					return err
+				}
+			}
+			if err := opts.print(cmd.OutOrStdout(), rep, func(w io.Writer) {
+				PrintLintReport(w, rep)
+			}); err != nil {
+				return err
+			}
+			if !rep.OK() && !fix {
+				return fmt.Errorf("%d issue(s) found, run with --fix to normalize", rep.Issues)
+			}
+			return nil
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&dir, "dir", "creds", "Directory with <vendor>.txt credential files")
+	f.BoolVar(&fix, "fix", false, "Rewrite files normalized and deduplicated")
+	f.StringVar(&report, "report", "", "Report file (default <dir>/"+credlint.ReportFile+", - to skip)")
+	return cmd
+}
+
+// PrintLintReport renders a credential lint report for the console.
+func PrintLintReport(w io.Writer, rep *credlint.Report) {
+	for _, f := range rep.Files {
+		icon := "✅"
+		if len(f.Issues) > 0 {
+			icon = "⚠️ "
+		}
+		fmt.Fprintf(w, "%s %s (%s): %d lines, %d valid, %d duplicates", icon, f.File, f.Vendor, f.Lines, f.Valid, f.Duplicates)
+		if f.Fixed {
+			fmt.Fprint(w, ", fixed")
+		}
+		fmt.Fprintln(w)
+		for _, is := range f.Issues {
+			if is.Line > 0 {
+				fmt.Fprintf(w, "   line %d: %s: %s\n", is.Line, is.Kind, is.Message)
+			} else {
+				fmt.Fprintf(w, "   %s: %s\n", is.Kind, is.Message)
+			}
+		}
+	}
+	for _, s := range rep.Skipped {
+		fmt.Fprintf(w, "   skipped %s (no vendor format)\n", s)
+	}
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/manager"
+)
+
+// managerFlags are shared by the manager subcommands.
+type managerFlags struct {
+	scannersFile string
+	binDir       string
+	runDir       string
+	logDir       string
+	cgroupRoot   string
+}
+
+func (f *managerFlags) newManager() (*manager.Manager, error) {
+	scanners, err := LoadScanners(f.scannersFile)
+	if err != nil {
+		return nil, err
+	}
+	m := manager.New(scanners, f.binDir, f.runDir)
+	m.LogDir = f.logDir
+	m.CgroupRoot = f.cgroupRoot
+	return m, nil
+}
+
+func newManagerCmd(opts *Options) *cobra.Command {
+	mf := &managerFlags{}
+	cmd := &cobra.Command{
+		Use:   "manager",
+		Short: "Start, stop and inspect supervised scanner processes",
+	}
+	pf := cmd.PersistentFlags()
+	pf.StringVar(&mf.scannersFile, "scanners", manager.DefaultConfigFile, "Scanner definitions (YAML)")
+	pf.StringVar(&mf.binDir, "bin-dir", "bin", "Directory for compiled scanner binaries")
+	pf.StringVar(&mf.runDir, "run-dir", "run", "Directory for PID files")
+	pf.StringVar(&mf.logDir, "log-dir", "logs", "Directory for scanner logs")
+	pf.StringVar(&mf.cgroupRoot, "cgroup-root", manager.DefaultCgroupRoot, "cgroup v2 directory for scanner resource limits")
+
+	cmd.AddCommand(
+		newManagerStartCmd(opts, mf),
+		newManagerStopCmd(mf),
+		newManagerStatusCmd(opts, mf),
+		newManagerLogsCmd(mf),
+	)
+	return cmd
+}
+
+func newManagerStartCmd(opts *Options, mf *managerFlags) *cobra.Command {
+	var (
+		maxRestarts int
+		useDB       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "start <vpn|all>",
+		Short: "Run scanners in the foreground and restart them when they crash",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mf.newManager()
+			if err != nil {
+				return err
+			}
+			m.MaxRestarts = maxRestarts
+			names, err := m.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			if useDB {
+				database, err := db.ConnectFromApp(*opts.LoadConfig())
+				if err != nil {
+					return fmt.Errorf("failed to connect to database: %w", err)
+				}
+				defer database.Close()
+				m.Events = database
+			}
+			ctx, cancel := SignalContext()
+			defer cancel()
+			return m.Supervise(ctx, names)
+		},
+	}
+	cmd.Flags().IntVar(&maxRestarts, "max-restarts", 10, "Give up on a crashing scanner after this many restarts (0 = unlimited)")
+	cmd.Flags().BoolVar(&useDB, "db", false, "Record restart events in the database logs table")
+	return cmd
+}
+
+func newManagerStopCmd(mf *managerFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop [vpn|all]",
+		Short: "Stop running scanners",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mf.newManager()
+			if err != nil {
+				return err
+			}
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			names, err := m.Resolve(name)
+			if err != nil {
+				return err
+			}
+			m.Stop(names)
+			return nil
+		},
+	}
+}
+
+func newManagerStatusCmd(opts *Options, mf *managerFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show scanner processes and aggregated stats",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mf.newManager()
+			if err != nil {
+				return err
+			}
+			result := struct {
+				Scanners []manager.Status `json:"scanners"`
+				Totals   map[string]int   `json:"totals"`
+			}{m.Status(), StatsTotals(".")}
+			return opts.print(cmd.OutOrStdout(), result, func(w io.Writer) {
+				PrintStatus(w, result.Scanners, result.Totals)
+			})
+		},
+	}
+}
+
+func newManagerLogsCmd(mf *managerFlags) *cobra.Command {
+	var (
+		lines  int
+		follow bool
+	)
+	cmd := &cobra.Command{
+		Use:   "logs <vpn>",
+		Short: "Show the newest log of a scanner",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mf.newManager()
+			if err != nil {
+				return err
+			}
+			if _, err := m.Resolve(args[0]); err != nil {
+				return err
+			}
+			ctx, cancel := SignalContext()
+			defer cancel()
+			return m.TailLog(ctx, cmd.OutOrStdout(), args[0], lines, follow)
+		},
+	}
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "Number of lines to show")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new output")
+	return cmd
+}
+
+// LoadScanners reads the scanner definitions, falling back to the built-in
+// ones when the default config file does not exist.
+func LoadScanners(path string) (map[string]manager.Scanner, error) {
+	scanners, err := manager.LoadScanners(path)
+	if os.IsNotExist(err) && path == manager.DefaultConfigFile {
+		return manager.DefaultScanners, nil
+	}
+	return scanners, err
+}
+
+// StatsTotals sums the counters of all stats_*.json files in dir.
+func StatsTotals(dir string) map[string]int {
+	total := map[string]int{}
+	files, _ := filepath.Glob(filepath.Join(dir, "stats_*.json"))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var m map[string]int
+		if json.Unmarshal(data, &m) == nil {
+			for k, v := range m {
+				total[k] += v
+			}
+		}
+	}
+	return total
+}
+
+// PrintStatus renders scanner states and stats totals for the console.
+func PrintStatus(w io.Writer, statuses []manager.Status, total map[string]int) {
+	fmt.Fprintln(w, "\nScanner status:")
+	for _, st := range statuses {
+		switch {
+		case st.Running && st.Tracked:
+			fmt.Fprintf(w, "%-12s running (PID %d) restarts:%d\n", st.Name, st.PIDs[0], st.Restarts)
+		case st.Running:
+			fmt.Fprintf(w, "%-12s running untracked %v\n", st.Name, st.PIDs)
+		default:
+			fmt.Fprintf(w, "%-12s stopped restarts:%d\n", st.Name, st.Restarts)
+		}
+	}
+	if len(total) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "TOTAL goods:%d bads:%d errors:%d offline:%d ipblock:%d processed:%d\n",
+		total["goods"], total["bads"], total["errors"], total["offline"], total["ipblock"], total["processed"])
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+)
+
+func newMigrateCmd(opts *Options) *cobra.Command {
+	var (
+		driver      string
+		dsn         string
+		importCreds string
+	)
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Create or update the database schema",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := opts.LoadConfig()
+			OverrideDB(cfg, driver, dsn)
+			database, err := Migrate(cfg)
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			result := struct {
+				Status   string           `json:"status"`
+				Driver   string           `json:"driver"`
+				Database string           `json:"database"`
+				Import   *db.ImportResult `json:"import,omitempty"`
+			}{Status: "ok", Driver: cfg.DBDriver, Database: cfg.DBName}
+			if cfg.DBDriver == db.DriverSQLite {
+				result.Database = cfg.DatabaseDSN
+			}
+			if importCreds != "" {
+				res, err := ImportCredFiles(database, importCreds)
+				if err != nil {
+					return err
+				}
+				result.Import = &res
+			}
+			return opts.print(cmd.OutOrStdout(), result, func(w io.Writer) {
+				fmt.Fprintf(w, "✅ Database schema is up to date (%s %s)\n", result.Driver, result.Database)
+				if r := result.Import; r != nil {
+					fmt.Fprintf(w, "✅ Credentials imported: %d, skipped: %d\n", r.Imported, r.Skipped)
+					for _, e := range r.Errors {
+						fmt.Fprintf(w, "   %s\n", e)
+					}
+				}
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&driver, "driver", "", "Database driver: postgres or sqlite (default from config)")
+	f.StringVar(&dsn, "dsn", "", "Database DSN or SQLite file (default from config)")
+	f.StringVar(&importCreds, "import-creds", "", "Import credentials from files matching this glob, e.g. 'creds/*.txt'")
+	return cmd
+}
+
+// OverrideDB applies --driver/--dsn flags to cfg. Switching to SQLite
+// without a DSN uses config.DefaultSQLiteFile instead of the Postgres DSN.
+func OverrideDB(cfg *config.Config, driver, dsn string) {
+	if driver != "" && driver != cfg.DBDriver {
+		cfg.DBDriver = driver
+		if driver == db.DriverSQLite {
+			cfg.DatabaseDSN = config.DefaultSQLiteFile
+		}
+	}
+	if dsn != "" {
+		cfg.DatabaseDSN = dsn
+	}
+}
+
+// Migrate connects to the configured database, which applies the shared
+// schema, and records the migration in the logs table.
+func Migrate(cfg *config.Config) (*db.DB, error) {
+	database, err := db.ConnectFromApp(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("db setup failed: %w", err)
+	}
+	if err := database.InsertLog("info", "schema migrated", "migrate"); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return database, nil
+}
+
+// ImportCredFiles imports ip;username;password lines from all files matching
+// pattern through the validated credential import. Blank lines and #
+// comments are ignored; malformed lines are reported in the result.
+func ImportCredFiles(database *db.DB, pattern string) (db.ImportResult, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return db.ImportResult{}, err
+	}
+	var (
+		creds  []db.Credential
+		errors []string
+	)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return db.ImportResult{}, err
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			c, err := db.ParseCredentialLine(line)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s:%d: %v", f, i+1, err))
+				continue
+			}
+			creds = append(creds, c)
+		}
+	}
+	res, err := database.ImportCredentials(creds)
+	if err != nil {
+		return res, err
+	}
+	res.Skipped += len(errors)
+	res.Errors = append(errors, res.Errors...)
+	return res, nil
+}
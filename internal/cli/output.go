@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// print writes v as indented JSON when --output=json and otherwise calls
+// text to render it for humans.
+func (o *Options) print(w io.Writer, v interface{}, text func(io.Writer)) error {
+	if o.Output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	text(w)
+	return nil
+}
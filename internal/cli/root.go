@@ -0,0 +1,95 @@
+// Package cli implements vpnctl, the single command line entry point for
+// scanning, scanner supervision, the dashboard and maintenance tasks.
+// The older cmd/* binaries call into the same functions.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// Options holds the flags shared by every subcommand.
+type Options struct {
+	ConfigFile string
+	Output     string // text or json
+	Verbose    bool
+}
+
+// NewRootCmd builds the vpnctl command tree.
+func NewRootCmd() *cobra.Command {
+	opts := &Options{}
+	root := &cobra.Command{
+		Use:           "vpnctl",
+		Short:         "Control VPN scanners, workers and the dashboard",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.setup()
+		},
+	}
+	pf := root.PersistentFlags()
+	pf.StringVar(&opts.ConfigFile, "config", "config.yaml", "Configuration file path")
+	pf.StringVarP(&opts.Output, "output", "o", "text", "Output format: text or json")
+	pf.BoolVarP(&opts.Verbose, "verbose", "v", false, "Include file and line in log output")
+
+	root.AddCommand(
+		newScanCmd(opts),
+		newManagerCmd(opts),
+		newDashboardCmd(opts),
+		newCollectCmd(opts),
+		newSplitCmd(opts),
+		newSetupCmd(opts),
+		newMigrateCmd(opts),
+		newAggregateCmd(opts),
+		newRunAllCmd(opts),
+		newLintCredsCmd(opts),
+		newScopeCmd(opts),
+		newImportScanCmd(opts),
+		newExportFindingsCmd(opts),
+	)
+	return root
+}
+
+// Execute runs vpnctl with os.Args and exits non-zero on failure.
+func Execute() {
+	if err := NewRootCmd().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (o *Options) setup() error {
+	switch o.Output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q (want text or json)", o.Output)
+	}
+	if o.Verbose {
+		log.SetFlags(log.LstdFlags | log.Lshortfile)
+	}
+	return nil
+}
+
+// LoadConfig reads the application config and falls back to the defaults
+// when it cannot be loaded.
+func (o *Options) LoadConfig() *config.Config {
+	cfg, err := config.Load(o.ConfigFile)
+	if err != nil {
+		log.Printf("config load error: %v", err)
+		cfg = config.Default()
+	}
+	return cfg
+}
+
+// SignalContext is cancelled on SIGINT or SIGTERM.
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
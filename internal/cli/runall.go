@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/manager"
+)
+
+func newRunAllCmd(opts *Options) *cobra.Command {
+	mf := &managerFlags{}
+	var (
+		port        int
+		vpnType     string
+		setup       bool
+		maxRestarts int
+	)
+	cmd := &cobra.Command{
+		Use:   "runall",
+		Short: "Run the dashboard and supervised scanners in one process",
+		Long: "Serves the dashboard and, with --vpn-type, supervises the scanners in the same\n" +
+			"process. If either part fails everything is stopped and the error is returned;\n" +
+			"SIGINT/SIGTERM shuts both down cleanly.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := opts.LoadConfig()
+			if setup {
+				if err := PrepareRun(cfg); err != nil {
+					return err
+				}
+			}
+			var (
+				m     *manager.Manager
+				names []string
+			)
+			if vpnType != "" {
+				var err error
+				if m, err = mf.newManager(); err != nil {
+					return err
+				}
+				m.MaxRestarts = maxRestarts
+				if names, err = m.Resolve(vpnType); err != nil {
+					return err
+				}
+			}
+			ctx, cancel := SignalContext()
+			defer cancel()
+			return RunAll(ctx, cfg, port, m, names)
+		},
+	}
+	f := cmd.Flags()
+	f.IntVarP(&port, "port", "p", 8080, "Dashboard server port")
+	f.StringVarP(&vpnType, "vpn-type", "t", "", "Scanners to supervise (vpn or all); none when empty")
+	f.BoolVar(&setup, "setup", false, "Install dependencies and create working directories first")
+	f.IntVar(&maxRestarts, "max-restarts", 10, "Give up on a crashing scanner after this many restarts (0 = unlimited)")
+	f.StringVar(&mf.scannersFile, "scanners", manager.DefaultConfigFile, "Scanner definitions (YAML)")
+	f.StringVar(&mf.binDir, "bin-dir", "bin", "Directory for compiled scanner binaries")
+	f.StringVar(&mf.runDir, "run-dir", "run", "Directory for PID files")
+	f.StringVar(&mf.logDir, "log-dir", "logs", "Directory for scanner logs")
+	f.StringVar(&mf.cgroupRoot, "cgroup-root", manager.DefaultCgroupRoot, "cgroup v2 directory for scanner resource limits")
+	return cmd
+}
+
+// PrepareRun installs dependencies and creates the working directories used
+// by the scanners.
+func PrepareRun(cfg *config.Config) error {
+	log.Println("🔧 Setting up environment...")
+	if err := InstallDeps(cfg); err != nil {
+		return fmt.Errorf("setup failed: %w", err)
+	}
+	for _, dir := range []string{"Generated", "Valid"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("setup failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunAll serves the dashboard and supervises the named scanners of m until
+// ctx is cancelled. The first failure of either part stops the other one and
+// is returned. m may be nil when no scanners should be started.
+func RunAll(ctx context.Context, cfg *config.Config, port int, m *manager.Manager, names []string) error {
+	log.Println("🚀 Starting all components")
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		if err := RunDashboard(ctx, cfg, port); err != nil {
+			return fmt.Errorf("dashboard: %w", err)
+		}
+		return nil
+	})
+	if m != nil && len(names) > 0 {
+		g.Go(func() error {
+			if err := m.Supervise(ctx, names); err != nil {
+				return fmt.Errorf("manager: %w", err)
+			}
+			return nil
+		})
+	} else {
+		log.Println("scanners not started (no --vpn-type)")
+	}
+	return g.Wait()
+}
@@ -0,0 +1,530 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/bruteforce"
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/db"
+	"vpn-bruteforce-client/internal/exclude"
+	"vpn-bruteforce-client/internal/geoip"
+	"vpn-bruteforce-client/internal/report"
+	"vpn-bruteforce-client/internal/scope"
+	"vpn-bruteforce-client/internal/stats"
+	"vpn-bruteforce-client/internal/tui"
+)
+
+func newScanCmd(opts *Options) *cobra.Command {
+	var (
+		vpnType   string
+		input     string
+		validFile string
+		threads   int
+		rateLimit int
+		timeout   time.Duration
+		useTUI    bool
+		statsDir  string
+		progFmt   string
+		progFile  string
+		reportDir string
+		runID     string
+		staleAge  time.Duration
+		useDB     bool
+		scopeFile string
+		taskID    int
+		source    string
+		poll      time.Duration
+		drain     bool
+		timing    string
+	)
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Check credentials against VPN endpoints with the built-in engine",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := opts.LoadConfig()
+			f := cmd.Flags()
+			if f.Changed("vpn-type") {
+				cfg.VPNType = vpnType
+			}
+			if f.Changed("input") {
+				cfg.InputFile = input
+			}
+			if f.Changed("valid-file") {
+				cfg.OutputFile = validFile
+			}
+			if f.Changed("threads") {
+				cfg.Threads = threads
+			}
+			if f.Changed("rate") {
+				cfg.RateLimit = rateLimit
+			}
+			if f.Changed("timeout") {
+				cfg.Timeout = timeout
+			}
+			if f.Changed("timing") {
+				cfg.Timing.Profile = timing
+			}
+
+			switch progFmt {
+			case stats.ProgressText, stats.ProgressJSON:
+			default:
+				return fmt.Errorf("unknown progress format %q (want text or json)", progFmt)
+			}
+			jsonStdout := progFmt == stats.ProgressJSON && progFile == ""
+			if useTUI && jsonStdout {
+				return fmt.Errorf("--tui owns stdout; use --progress-file with --progress-format json")
+			}
+
+			if taskID != 0 && !useDB {
+				return fmt.Errorf("--task needs --db")
+			}
+			switch source {
+			case sourceFile:
+			case sourceDB:
+				if !useDB {
+					return fmt.Errorf("--source db needs --db")
+				}
+				if taskID != 0 {
+					return fmt.Errorf("--task and --source db cannot be combined")
+				}
+			default:
+				return fmt.Errorf("unknown source %q (want file or db)", source)
+			}
+			if f.Changed("scope") {
+				cfg.Scope.File = scopeFile
+			}
+			sc, err := loadScope(cfg.Scope)
+			if err != nil {
+				return err
+			}
+			log.Printf("engagement %q in scope until %s", sc.Engagement, sc.NotAfter.Format(time.RFC3339))
+
+			if useTUI {
+				// Per-credential output would draw over the monitor.
+				cfg.Verbose = false
+			}
+			st := stats.New()
+			st.SetQuiet(useTUI || progFmt == stats.ProgressJSON)
+			if runID != "" {
+				if !report.ValidRunID(runID) {
+					return fmt.Errorf("invalid run id %q", runID)
+				}
+				st.SetRunID(runID)
+				switch err := st.LoadSnapshot(st.FileName()); {
+				case err == nil:
+					log.Printf("resuming stats of run %s", runID)
+				case !os.IsNotExist(err):
+					log.Printf("stats snapshot: %v", err)
+				}
+			}
+			if removed, err := stats.CleanupStale(".", staleAge, st.FileName()); err != nil {
+				log.Printf("stats cleanup: %v", err)
+			} else if len(removed) > 0 {
+				log.Printf("removed %d stale stats file(s)", len(removed))
+			}
+			st.SetMemoryCap(int64(cfg.MemoryLimitMB), nil)
+			var (
+				database *db.DB
+				builder  *bruteforce.TaskBuilder
+			)
+			if useDB {
+				database, err = db.ConnectFromApp(*cfg)
+				if err != nil {
+					return fmt.Errorf("failed to connect to database: %w", err)
+				}
+				defer database.Close()
+				if builder, err = loadTaskProxies(database); err != nil {
+					return err
+				}
+			}
+			engine, err := bruteforce.New(cfg, st, builder)
+			if err != nil {
+				return err
+			}
+			engine.SetScope(sc)
+			geo := openGeoIP(cfg)
+			defer geo.Close()
+			if database != nil {
+				recordToDB(engine, st, database, cfg, geo)
+			}
+			var tracker *credTracker
+			if database != nil {
+				tracker = newCredTracker(database)
+				engine.SetResultHandler(tracker.Result)
+				if source == sourceFile {
+					score, err := credentialPriority(database)
+					if err != nil {
+						return err
+					}
+					engine.SetPriority(score)
+				}
+			}
+			if source == sourceDB {
+				ts := newTaskSource(database, cfg.VPNType, poll, drain)
+				engine.SetSource(ts.Run)
+				engine.SetResultHandler(resultHandlers(ts.Result, tracker.Result))
+				log.Printf("consuming %s tasks from the database as worker %s", cfg.VPNType, ts.worker)
+			}
+			exclusions, err := loadExclusions(cfg, database, geo)
+			if err != nil {
+				return err
+			}
+			engine.SetExclusions(exclusions)
+
+			out := cmd.OutOrStdout()
+			var progress io.Writer
+			if progFmt == stats.ProgressJSON {
+				if jsonStdout {
+					// Keep stdout for the event stream; the engine's
+					// banner goes to stderr instead.
+					progress, out = out, cmd.ErrOrStderr()
+					stdout := os.Stdout
+					os.Stdout = os.Stderr
+					defer func() { os.Stdout = stdout }()
+				} else {
+					pf, err := openProgressFile(progFile)
+					if err != nil {
+						return err
+					}
+					defer pf.Close()
+					progress = pf
+				}
+				st.SetProgressWriter(progress)
+			}
+			go st.Start()
+			defer st.Stop()
+
+			ctx, cancel := SignalContext()
+			defer cancel()
+			go func() {
+				<-ctx.Done()
+				engine.Stop()
+			}()
+
+			var finishTask func(error, bool)
+			if taskID != 0 {
+				if finishTask, err = trackTask(database, taskID, st); err != nil {
+					return err
+				}
+			}
+			if tracker != nil {
+				stopTracker, trackerDone := make(chan struct{}), make(chan struct{})
+				go func() {
+					tracker.Run(stopTracker)
+					close(trackerDone)
+				}()
+				defer func() {
+					close(stopTracker)
+					<-trackerDone
+				}()
+			}
+			started := time.Now()
+			if useTUI {
+				err = runWithTUI(ctx, cancel, engine, st, cfg.VPNType, statsDir)
+			} else {
+				err = engine.Start()
+			}
+			if finishTask != nil {
+				finishTask(err, ctx.Err() != nil)
+			}
+			if err != nil {
+				return err
+			}
+			var reportPath string
+			if reportDir != "" {
+				rep := report.Build(engine.RunID(), cfg, st, started, ctx.Err() != nil)
+				if reportPath, err = rep.Write(reportDir); err != nil {
+					log.Printf("run report: %v", err)
+				}
+			}
+			if progress != nil {
+				st.SetProgressWriter(nil)
+				if err := st.WriteProgress(progress, stats.EventDone); err != nil {
+					log.Printf("progress output: %v", err)
+				}
+			}
+			result := map[string]interface{}{
+				"run_id":    engine.RunID(),
+				"vpn_type":  cfg.VPNType,
+				"goods":     atomic.LoadInt64(&st.Goods),
+				"bads":      atomic.LoadInt64(&st.Bads),
+				"errors":    atomic.LoadInt64(&st.Errors),
+				"offline":   atomic.LoadInt64(&st.Offline),
+				"ipblock":   atomic.LoadInt64(&st.IPBlock),
+				"processed": atomic.LoadInt64(&st.Processed),
+				"duration":  time.Since(started).Round(time.Second).String(),
+			}
+			if reportPath != "" {
+				result["report"] = reportPath
+			}
+			return opts.print(out, result, func(w io.Writer) {
+				fmt.Fprintf(w, "\n✅ Scan finished: %d valid of %d processed, results in %s\n",
+					result["goods"], result["processed"], cfg.OutputFile)
+				if reportPath != "" {
+					fmt.Fprintf(w, "📄 Run report: %s\n", reportPath)
+				}
+			})
+		},
+	}
+	f := cmd.Flags()
+	// Unset flags keep the values from the config file.
+	f.StringVarP(&vpnType, "vpn-type", "t", "", "VPN type (default from config)")
+	f.StringVarP(&input, "input", "i", "", "Credentials file in ip;user;pass format (default from config)")
+	f.StringVar(&validFile, "valid-file", "", "File receiving valid credentials (default from config)")
+	f.IntVar(&threads, "threads", 0, "Number of worker goroutines (default from config)")
+	f.IntVar(&rateLimit, "rate", 0, "Requests per second (default from config)")
+	f.DurationVar(&timeout, "timeout", 0, "Per-request timeout (default from config)")
+	f.StringVar(&timing, "timing", "", "Timing profile: fast, normal, polite, low-and-slow or paranoid (default from config)")
+	f.BoolVar(&useTUI, "tui", false, "Show an interactive live monitor instead of the status line")
+	f.StringVar(&statsDir, "stats-dir", ".", "Directory with worker stats_*.json files for the --tui worker table")
+	f.StringVar(&progFmt, "progress-format", stats.ProgressText, "Progress output: text (status line) or json (one event per line)")
+	f.StringVar(&reportDir, "report-dir", report.DefaultDir, "Directory for the run report written when the scan ends (empty disables it)")
+	f.StringVar(&runID, "run-id", "", "Keep stats in stats_<run-id>.json and resume its counters after a restart")
+	f.BoolVar(&useDB, "db", false, "Record findings and engine errors with the run id in the configured database")
+	f.StringVar(&scopeFile, "scope", "", "Signed scope file (default scope.file from the config)")
+	f.IntVar(&taskID, "task", 0, "Report state and progress of this task of the tasks table (needs --db)")
+	f.StringVar(&source, "source", sourceFile, "Credential source: file (--input) or db (claim tasks of the VPN type from the tasks table; needs --db)")
+	f.DurationVar(&poll, "poll", 5*time.Second, "With --source db, how long to wait before polling an empty task queue again")
+	f.BoolVar(&drain, "drain", false, "With --source db, stop once no pending task is left instead of polling")
+	f.DurationVar(&staleAge, "stats-max-age", stats.DefaultStaleAge, "Remove stats files of other runs not updated for this long (0 keeps them; files of dead processes are always removed)")
+	f.StringVar(&progFile, "progress-file", "", "Write json progress events to this file or named pipe instead of stdout")
+	return cmd
+}
+
+// recordToDB stores the engine's findings, error log entries and memory
+// alerts in database, attributed to the engine's run. Findings are tagged
+// with country and ASN when GeoIP databases are configured.
+func recordToDB(engine *bruteforce.Engine, st *stats.Stats, database *db.DB, cfg *config.Config, geo *geoip.Reader) {
+	runID, vpnType := engine.RunID(), cfg.VPNType
+	engine.SetLogger(func(level, message, source string) {
+		if err := database.InsertRunLog(runID, level, message, source); err != nil {
+			log.Printf("log insert error: %v", err)
+		}
+	})
+	engine.SetFindingHandler(func(cred bruteforce.Credential) {
+		f := db.Finding{RunID: runID, VPNType: vpnType, IP: cred.IP, Username: cred.Username, Password: cred.Password, Variant: cred.Variant}
+		info := geo.Lookup(cred.IP)
+		f.Country, f.ASN, f.ASOrg = info.Country, info.ASN, info.ASOrg
+		if _, err := database.InsertFinding(f); err != nil {
+			log.Printf("finding insert error: %v", err)
+		}
+	})
+	st.SetMemoryCap(int64(cfg.MemoryLimitMB), func(memMB, capMB int64) {
+		msg := fmt.Sprintf("memory usage %d MB exceeds cap of %d MB", memMB, capMB)
+		log.Printf("⚠️ %s", msg)
+		if err := database.InsertRunLog(runID, "warning", msg, "stats"); err != nil {
+			log.Printf("log insert error: %v", err)
+		}
+	})
+	if err := database.InsertRunLog(runID, "info", "scan started", "scan"); err != nil {
+		log.Printf("log insert error: %v", err)
+	}
+}
+
+// loadScope loads and verifies the scope file and checks that the
+// engagement is running.
+func loadScope(cfg config.ScopeConfig) (*scope.Scope, error) {
+	if cfg.PublicKey == "" {
+		return nil, fmt.Errorf("scope.public_key is not set; scans require a signed scope file (see `vpnctl scope --help`)")
+	}
+	key, err := scope.ParsePublicKey(cfg.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := scope.Load(cfg.File, key)
+	if err != nil {
+		return nil, fmt.Errorf("scope: %w", err)
+	}
+	if err := sc.Active(time.Now()); err != nil {
+		return nil, fmt.Errorf("scope: %w", err)
+	}
+	return sc, nil
+}
+
+// loadExclusions builds the exclusion list from the config and, when
+// database is not nil, the exclusions table.
+func loadExclusions(cfg *config.Config, database *db.DB, geo *geoip.Reader) (*exclude.List, error) {
+	var rules []exclude.Rule
+	add := func(kind string, values []string) {
+		for _, v := range values {
+			rules = append(rules, exclude.Rule{Kind: kind, Value: v})
+		}
+	}
+	add(exclude.KindCIDR, cfg.Exclusions.CIDRs)
+	add(exclude.KindASN, cfg.Exclusions.ASNs)
+	add(exclude.KindCountry, cfg.Exclusions.Countries)
+	if database != nil {
+		list, err := database.ListExclusions()
+		if err != nil {
+			return nil, fmt.Errorf("load exclusions: %w", err)
+		}
+		for _, e := range list {
+			rules = append(rules, exclude.Rule{Kind: e.Kind, Value: e.Value})
+		}
+	}
+	l, err := exclude.New(rules, geo)
+	if err != nil {
+		return nil, fmt.Errorf("exclusions: %w", err)
+	}
+	if len(rules) > 0 {
+		log.Printf("excluding targets matching %d rule(s)", len(rules))
+	}
+	for _, r := range rules {
+		if geo == nil && r.Kind != exclude.KindCIDR {
+			log.Printf("⚠️ asn and country exclusions need geoip databases and are ignored")
+			break
+		}
+	}
+	return l, nil
+}
+
+// taskProgressInterval is how often the progress of a --task scan is
+// written to the tasks table.
+const taskProgressInterval = 5 * time.Second
+
+// trackTask marks task id running and records the scan's progress until
+// the returned function is called with the outcome of the scan.
+func trackTask(database *db.DB, id int, st *stats.Stats) (func(err error, cancelled bool), error) {
+	if err := database.SetTaskStatus(id, db.TaskRunning, ""); err != nil {
+		return nil, fmt.Errorf("task %d: %w", id, err)
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(taskProgressInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				pct := int(st.Progress(stats.EventProgress).Percent)
+				if pct > 100 {
+					pct = 100
+				}
+				if err := database.SetTaskProgress(id, pct); err != nil {
+					log.Printf("task %d progress: %v", id, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func(err error, cancelled bool) {
+		close(stop)
+		<-done
+		state, msg := db.TaskDone, ""
+		switch {
+		case err != nil:
+			state, msg = db.TaskFailed, err.Error()
+		case cancelled:
+			state = db.TaskCancelled
+		}
+		if err := database.SetTaskStatus(id, state, msg); err != nil {
+			log.Printf("task %d: %v", id, err)
+		}
+	}, nil
+}
+
+// loadTaskProxies returns the proxies pinned by the tasks table: tasks
+// with a URL pin its host, tasks without one set the default proxy of their
+// vendor.
+func loadTaskProxies(database *db.DB) (*bruteforce.TaskBuilder, error) {
+	tasks, err := database.TaskProxies()
+	if err != nil {
+		return nil, fmt.Errorf("load task proxies: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	b := &bruteforce.TaskBuilder{VendorProxies: make(map[string]string)}
+	for _, t := range tasks {
+		if t.URL == "" {
+			b.VendorProxies[strings.ToLower(t.Vendor)] = t.Proxy
+			continue
+		}
+		b.Tasks = append(b.Tasks, bruteforce.Task{ID: t.ID, Vendor: t.Vendor, URL: t.URL, Proxy: t.Proxy})
+	}
+	log.Printf("%d task proxy pin(s), %d vendor default(s)", len(b.Tasks), len(b.VendorProxies))
+	return b, nil
+}
+
+// openGeoIP opens the configured GeoIP databases. It returns nil when none
+// are configured or they cannot be opened; a nil reader finds nothing.
+func openGeoIP(cfg *config.Config) *geoip.Reader {
+	if cfg.GeoIP.CountryDB == "" && cfg.GeoIP.ASNDB == "" {
+		return nil
+	}
+	geo, err := geoip.Open(cfg.GeoIP.CountryDB, cfg.GeoIP.ASNDB)
+	if err != nil {
+		log.Printf("geoip disabled: %v", err)
+		return nil
+	}
+	return geo
+}
+
+// openProgressFile opens path for appending progress events. Opening a
+// named pipe blocks until a reader has opened it.
+func openProgressFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open progress file: %w", err)
+	}
+	return f, nil
+}
+
+// runWithTUI runs the engine while the live monitor owns the terminal.
+// Quitting the monitor stops the scan; the monitor closes when the scan
+// finishes. Engine and log output is discarded meanwhile.
+func runWithTUI(ctx context.Context, cancel context.CancelFunc, engine *bruteforce.Engine, st *stats.Stats, vpnType, statsDir string) error {
+	term := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	log.SetOutput(io.Discard)
+	defer func() {
+		os.Stdout = term
+		log.SetOutput(os.Stderr)
+	}()
+
+	engineDone := make(chan error, 1)
+	go func() { engineDone <- engine.Start() }()
+
+	uiCtx, uiCancel := context.WithCancel(ctx)
+	defer uiCancel()
+	uiDone := make(chan error, 1)
+	go func() {
+		stop, err := tui.Run(uiCtx, st, tui.Options{
+			Title:  vpnType,
+			Output: term,
+			Servers: func() []aggregator.ServerInfo {
+				servers, _ := aggregator.New(statsDir).GetServerInfo()
+				return servers
+			},
+		})
+		if stop {
+			cancel()
+		}
+		uiDone <- err
+	}()
+
+	err = <-engineDone
+	uiCancel()
+	if uiErr := <-uiDone; err == nil {
+		err = uiErr
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/scope"
+)
+
+func newScopeCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scope",
+		Short: "Create, sign and check engagement scope files",
+		Long: "Every scan runs under a scope file listing the networks and domains that may be\n" +
+			"tested and the engagement dates. The file is signed with an Ed25519 key whose\n" +
+			"public half is set as scope.public_key in the config; targets outside the\n" +
+			"scope are refused and logged.",
+	}
+	cmd.AddCommand(newScopeKeygenCmd(opts), newScopeSignCmd(), newScopeCheckCmd(opts))
+	return cmd
+}
+
+func newScopeKeygenCmd(opts *Options) *cobra.Command {
+	var keyFile string
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a signing key pair",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := os.Stat(keyFile); err == nil {
+				return fmt.Errorf("%s already exists", keyFile)
+			}
+			pub, priv, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(priv)+"\n"), 0o600); err != nil {
+				return err
+			}
+			res := map[string]string{"private_key_file": keyFile, "public_key": base64.StdEncoding.EncodeToString(pub)}
+			return opts.print(cmd.OutOrStdout(), res, func(w io.Writer) {
+				fmt.Fprintf(w, "🔑 Private key written to %s; keep it off the scanners.\n", keyFile)
+				fmt.Fprintf(w, "Add to the config:\n\nscope:\n  public_key: %s\n", res["public_key"])
+			})
+		},
+	}
+	cmd.Flags().StringVar(&keyFile, "key", "scope.key", "File receiving the private key")
+	return cmd
+}
+
+func newScopeSignCmd() *cobra.Command {
+	var keyFile string
+	cmd := &cobra.Command{
+		Use:   "sign FILE",
+		Short: "Sign a scope file in place",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(keyFile)
+			if err != nil {
+				return err
+			}
+			key, err := scope.ParsePrivateKey(string(data))
+			if err != nil {
+				return err
+			}
+			f, err := scope.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			if _, err := scope.New(*f); err != nil {
+				return err
+			}
+			f.Sign(key)
+			if err := f.WriteFile(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Signed %s\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&keyFile, "key", "scope.key", "Private key file from `vpnctl scope keygen`")
+	return cmd
+}
+
+// scopeCheck is the result of `vpnctl scope check`.
+type scopeCheck struct {
+	Engagement string            `json:"engagement"`
+	NotBefore  time.Time         `json:"not_before"`
+	NotAfter   time.Time         `json:"not_after"`
+	Active     string            `json:"active"`
+	Targets    map[string]string `json:"targets,omitempty"`
+}
+
+func newScopeCheckCmd(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [TARGET...]",
+		Short: "Verify the configured scope file and test targets against it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := opts.LoadConfig()
+			key, err := scope.ParsePublicKey(cfg.Scope.PublicKey)
+			if err != nil {
+				return err
+			}
+			sc, err := scope.Load(cfg.Scope.File, key)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			res := scopeCheck{Engagement: sc.Engagement, NotBefore: sc.NotBefore, NotAfter: sc.NotAfter, Active: "yes"}
+			if err := sc.Active(now); err != nil {
+				res.Active = err.Error()
+			}
+			if len(args) > 0 {
+				res.Targets = make(map[string]string, len(args))
+				for _, t := range args {
+					res.Targets[t] = "in scope"
+					if err := sc.Check(t, now); err != nil {
+						res.Targets[t] = err.Error()
+					}
+				}
+			}
+			return opts.print(cmd.OutOrStdout(), res, func(w io.Writer) {
+				fmt.Fprintf(w, "✅ %s: signature valid, %s – %s\n", cfg.Scope.File,
+					sc.NotBefore.Format(time.RFC3339), sc.NotAfter.Format(time.RFC3339))
+				fmt.Fprintf(w, "   engagement %q active: %s\n", sc.Engagement, res.Active)
+				for _, t := range args {
+					fmt.Fprintf(w, "   %s: %s\n", t, res.Targets[t])
+				}
+			})
+		},
+	}
+	return cmd
+}
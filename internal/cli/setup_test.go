@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vpn-bruteforce-client/internal/db"
+)
+
+// chdir switches to dir for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSetupEnvModes(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	t.Setenv("SETUP_PASS", `p"w`)
+	os.WriteFile("setup-data.json", []byte(`{
+		"sshCredentials": ["10.0.0.1;root;${SETUP_PASS}"],
+		"vpnCredentials": {"fortinet": ["https://1.1.1.1:443;guest;guest"]},
+		"proxies": ["socks5://u:p@127.0.0.1:1080"]
+	}`), 0o644)
+	os.MkdirAll("creds", 0o755)
+	os.WriteFile(filepath.Join("creds", "fortinet.txt"), []byte("https://9.9.9.9:443;a;b\nhttps://1.1.1.1:443;guest;guest"), 0o644)
+
+	if err := SetupEnv("setup-data.json", SetupOptions{Mode: ModeMerge}); err != nil {
+		t.Fatalf("SetupEnv: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join("creds", "fortinet.txt"))
+	if string(got) != "https://9.9.9.9:443;a;b\nhttps://1.1.1.1:443;guest;guest" {
+		t.Fatalf("merge changed file: %q", got)
+	}
+	if creds, _ := os.ReadFile("credentials.txt"); string(creds) != `10.0.0.1;root;p"w` {
+		t.Fatalf("template not expanded: %q", creds)
+	}
+
+	if err := SetupEnv("setup-data.json", SetupOptions{Mode: ModeAppend}); err != nil {
+		t.Fatalf("SetupEnv: %v", err)
+	}
+	got, _ = os.ReadFile(filepath.Join("creds", "fortinet.txt"))
+	if strings.Count(string(got), "1.1.1.1") != 2 {
+		t.Fatalf("append did not add line: %q", got)
+	}
+	backups, _ := filepath.Glob(filepath.Join("creds", "fortinet.txt.*.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+
+	if err := SetupEnv("setup-data.json", SetupOptions{Mode: "replace"}); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
+
+func TestSetupEnvUndefinedVariable(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	os.WriteFile("setup-data.json", []byte(`{"sshCredentials": ["10.0.0.1;root;${SETUP_UNDEFINED_VAR}"]}`), 0o644)
+	err := SetupEnv("setup-data.json", SetupOptions{})
+	if err == nil || !strings.Contains(err.Error(), "SETUP_UNDEFINED_VAR") {
+		t.Fatalf("expected undefined variable error, got %v", err)
+	}
+}
+
+func TestSetupEnvImportDB(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	os.WriteFile("setup-data.json", []byte(`{
+		"vpnCredentials": {"fortinet": ["https://1.1.1.1:443;guest;guest", "broken"]},
+		"proxies": ["socks5://u:p@127.0.0.1:1080"],
+		"workers": [{"ip": "10.0.0.2", "username": "root", "password": "x"}],
+		"vendorUrls": ["https://vpn.example.com"]
+	}`), 0o644)
+	database, err := db.Connect(db.Config{Driver: db.DriverSQLite, DSN: filepath.Join(dir, "vpn.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	if err := SetupEnv("setup-data.json", SetupOptions{DB: database}); err != nil {
+		t.Fatalf("SetupEnv: %v", err)
+	}
+	for table, want := range map[string]int{"credentials": 1, "proxies": 1, "workers": 1, "vendor_urls": 1} {
+		var n int
+		if err := database.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil || n != want {
+			t.Errorf("%s: %d rows (err %v), want %d", table, n, err, want)
+		}
+	}
+	var port int
+	database.QueryRow(`SELECT port FROM workers`).Scan(&port)
+	if port != 22 {
+		t.Errorf("worker port %d, want default 22", port)
+	}
+}
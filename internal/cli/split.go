@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"vpn-bruteforce-client/internal/collect"
+)
+
+func newSplitCmd(opts *Options) *cobra.Command {
+	var (
+		input     string
+		outDir    string
+		credsFile string
+		parts     int
+	)
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split a generated credential list into one part per worker",
+		Long: "Splits the input file into Generated/part_N.txt. The number of parts defaults\n" +
+			"to the number of workers in the credentials file.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if parts <= 0 {
+				creds, err := collect.ParseCredentials(credsFile)
+				if err != nil {
+					return fmt.Errorf("read credentials: %w", err)
+				}
+				if len(creds) == 0 {
+					return fmt.Errorf("%s has no workers, nothing to split for", credsFile)
+				}
+				parts = len(creds)
+			}
+			paths, err := collect.SplitFile(input, outDir, parts)
+			if err != nil {
+				return err
+			}
+			return opts.print(cmd.OutOrStdout(), map[string]interface{}{"parts": paths}, func(w io.Writer) {
+				fmt.Fprintf(w, "✅ Split %s into %d parts in %s\n", input, len(paths), outDir)
+			})
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&input, "input", "gener.txt", "Generated credential list")
+	f.StringVar(&outDir, "output-dir", "Generated", "Directory for part_N.txt files")
+	f.StringVar(&credsFile, "credentials", "credentials.txt", "Worker credentials used to count parts")
+	f.IntVarP(&parts, "parts", "n", 0, "Number of parts (default: number of workers)")
+	return cmd
+}
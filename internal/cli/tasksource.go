@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"vpn-bruteforce-client/internal/bruteforce"
+	"vpn-bruteforce-client/internal/db"
+)
+
+// Credential sources of the scan command.
+const (
+	sourceFile = "file"
+	sourceDB   = "db"
+)
+
+// taskLease is how long a claimed task stays with this worker without a
+// lease renewal; leases are renewed every third of it.
+const taskLease = 2 * time.Minute
+
+// taskSource feeds the engine with tasks claimed from the tasks table and
+// writes each task's result back. Several scanners may share the queue.
+type taskSource struct {
+	db     *db.DB
+	worker string
+	vendor string
+	poll   time.Duration
+	drain  bool
+
+	mu       sync.Mutex
+	inFlight map[int]bool
+}
+
+func newTaskSource(database *db.DB, vendor string, poll time.Duration, drain bool) *taskSource {
+	host, _ := os.Hostname()
+	return &taskSource{
+		db:       database,
+		worker:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		vendor:   vendor,
+		poll:     poll,
+		drain:    drain,
+		inFlight: make(map[int]bool),
+	}
+}
+
+// Run claims tasks and sends them to out until ctx is done or, when
+// draining, no pending task is left. Tasks claimed but not finished when
+// it returns are put back in the queue.
+func (s *taskSource) Run(ctx context.Context, out chan<- bruteforce.Credential) {
+	defer close(out)
+	stopRenew := make(chan struct{})
+	go s.renewLeases(stopRenew)
+	defer func() {
+		close(stopRenew)
+		s.requeue()
+	}()
+
+	for ctx.Err() == nil {
+		task, err := s.db.ClaimTask(s.worker, s.vendor, taskLease)
+		if err != nil {
+			log.Printf("claim task: %v", err)
+		}
+		if task == nil {
+			if err == nil && s.drain {
+				s.wait(ctx)
+				return
+			}
+			select {
+			case <-time.After(s.poll):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		if err := s.db.SetClaimedTaskStatus(task.ID, s.worker, db.TaskRunning, ""); err != nil {
+			log.Printf("task %d: %v", task.ID, err)
+			continue
+		}
+		s.mu.Lock()
+		s.inFlight[task.ID] = true
+		s.mu.Unlock()
+		cred := bruteforce.Credential{IP: taskTarget(task.URL), Username: task.Login, Password: task.Password, TaskID: task.ID}
+		select {
+		case out <- cred:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// wait returns when every task handed to the engine has a result or ctx
+// is done.
+func (s *taskSource) wait(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.mu.Lock()
+		n := len(s.inFlight)
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Result records the engine's result for a task credential.
+func (s *taskSource) Result(cred bruteforce.Credential, result string, err error) {
+	if cred.TaskID == 0 || errors.Is(err, context.Canceled) {
+		// Checks interrupted by a stop are requeued by Run.
+		return
+	}
+	if cerr := s.db.CompleteClaimedTask(cred.TaskID, s.worker, result, err); cerr != nil {
+		log.Printf("task %d result: %v", cred.TaskID, cerr)
+	}
+	s.mu.Lock()
+	delete(s.inFlight, cred.TaskID)
+	s.mu.Unlock()
+}
+
+func (s *taskSource) renewLeases(stop <-chan struct{}) {
+	t := time.NewTicker(taskLease / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.mu.Lock()
+			ids := make([]int, 0, len(s.inFlight))
+			for id := range s.inFlight {
+				ids = append(ids, id)
+			}
+			s.mu.Unlock()
+			for _, id := range ids {
+				if err := s.db.RenewTaskLease(id, s.worker, taskLease); err != nil {
+					log.Printf("task %d lease: %v", id, err)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// requeue returns the unfinished tasks of this worker to the queue.
+func (s *taskSource) requeue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.inFlight {
+		if err := s.db.SetClaimedTaskStatus(id, s.worker, db.TaskPending, ""); err != nil {
+			log.Printf("task %d requeue: %v", id, err)
+		}
+		delete(s.inFlight, id)
+	}
+}
+
+// taskTarget returns the host[:port] the checkers expect for a task URL.
+func taskTarget(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "://") {
+		if u, err := url.Parse(raw); err == nil {
+			return u.Host
+		}
+	}
+	return strings.TrimSuffix(raw, "/")
+}
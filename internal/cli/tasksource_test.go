@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"vpn-bruteforce-client/internal/db"
+)
+
+func TestScanTasksFromDB(t *testing.T) {
+	vpn := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/monitor/system/status" {
+			w.Write([]byte(`{"status":"success"}`))
+			return
+		}
+		r.ParseForm()
+		if r.FormValue("username") == "good" {
+			w.Write([]byte("redirect vpn/tunnel"))
+			return
+		}
+		w.Write([]byte("denied"))
+	}))
+	defer vpn.Close()
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	out, err := runCLI(t, "-o", "json", "scope", "keygen", "--key", "scope.key")
+	if err != nil {
+		t.Fatalf("keygen: %v", err)
+	}
+	var kg struct {
+		PublicKey string `json:"public_key"`
+	}
+	json.Unmarshal([]byte(out), &kg)
+	os.WriteFile("scope.yaml", []byte("engagement: test\nnot_before: 2020-01-01T00:00:00Z\nnot_after: 2099-01-01T00:00:00Z\ncidrs: [127.0.0.0/8]\n"), 0o644)
+	if _, err := runCLI(t, "scope", "sign", "--key", "scope.key", "scope.yaml"); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	os.WriteFile("config.yaml", []byte("vpn_type: fortinet\nthreads: 4\nmin_threads: 1\nmax_threads: 4\nauto_scale: false\n"+
+		"timeout: 5s\ntls_handshake_timeout: 5s\nbuffer_size: 8192\n"+
+		"db_driver: sqlite\ndatabase_dsn: vpn.db\noutput_file: valid.txt\n"+
+		"scope:\n  file: scope.yaml\n  public_key: "+kg.PublicKey+"\n"), 0o644)
+
+	database, err := db.Connect(db.Config{Driver: db.DriverSQLite, DSN: "vpn.db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range [][3]string{{"fortinet", "good"}, {"fortinet", "bad"}, {"cisco", "good"}} {
+		if _, err := database.Exec(`INSERT INTO tasks(vendor, url, login, password) VALUES($1, $2, $3, 'pw')`,
+			task[0], vpn.URL, task[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	database.Close()
+
+	if out, err := runCLI(t, "--config", "config.yaml", "scan", "--db", "--source", "db", "--drain",
+		"--report-dir", "", "--stats-max-age", "0"); err != nil {
+		t.Fatalf("scan: %v\n%s", err, out)
+	}
+
+	database, err = db.Connect(db.Config{Driver: db.DriverSQLite, DSN: "vpn.db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	rows, err := database.Query(`SELECT login, vendor, status, COALESCE(result, COALESCE(error, '')) FROM tasks ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	want := [][4]string{{"good", "fortinet", "done", "good"}, {"bad", "fortinet", "done", "bad"}, {"good", "cisco", "pending", ""}}
+	for i := 0; rows.Next(); i++ {
+		var got [4]string
+		rows.Scan(&got[0], &got[1], &got[2], &got[3])
+		if got != want[i] {
+			t.Errorf("task %d = %v, want %v", i+1, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package collect
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SplitFile distributes the lines of input over parts files named
+// part_1.txt .. part_N.txt in outDir, one per worker. Sizes differ by at
+// most one line. It returns the paths of the written files.
+func SplitFile(input, outDir string, parts int) ([]string, error) {
+	if parts <= 0 {
+		return nil, fmt.Errorf("number of parts must be positive, got %d", parts)
+	}
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+	size, extra := len(lines)/parts, len(lines)%parts
+	paths := make([]string, 0, parts)
+	start := 0
+	for i := 0; i < parts; i++ {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		path := filepath.Join(outDir, fmt.Sprintf("part_%d.txt", i+1))
+		if err := writeLines(path, lines[start:end]); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		start = end
+	}
+	return paths, nil
+}
+
+func writeLines(path string, lines []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range lines {
+		w.WriteString(l)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,36 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "gener.txt")
+	os.WriteFile(input, []byte("a\nb\nc\nd\ne\n"), 0o644)
+
+	paths, err := SplitFile(input, filepath.Join(dir, "Generated"), 3)
+	if err != nil {
+		t.Fatalf("SplitFile: %v", err)
+	}
+	want := []string{"a\nb\n", "c\nd\n", "e\n"}
+	if len(paths) != len(want) {
+		t.Fatalf("expected %d parts, got %d", len(want), len(paths))
+	}
+	for i, p := range paths {
+		if !strings.HasSuffix(p, filepath.Join("Generated", "part_"+string(rune('1'+i))+".txt")) {
+			t.Fatalf("unexpected part name %s", p)
+		}
+		data, _ := os.ReadFile(p)
+		if string(data) != want[i] {
+			t.Fatalf("part %d = %q, want %q", i+1, data, want[i])
+		}
+	}
+
+	if _, err := SplitFile(input, dir, 0); err == nil {
+		t.Fatal("expected error for zero parts")
+	}
+}
@@ -9,6 +9,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultSQLiteFile is the database file used with db_driver: sqlite when
+// database_dsn is not set.
+const DefaultSQLiteFile = "vpn_scanner.db"
+
 type Config struct {
 	InputFile  string        `yaml:"input_file"`
 	OutputFile string        `yaml:"output_file"`
@@ -31,6 +35,22 @@ type Config struct {
 	ProxyList     []string `yaml:"proxy_list"`
 	ProxyRotation bool     `yaml:"proxy_rotation"`
 
+	// ProxySources are remote proxy lists the dashboard imports into the
+	// proxies table.
+	ProxySources ProxySourcesConfig `yaml:"proxy_sources"`
+
+	// UserAgents is the pool of User-Agent headers checkers send.
+	UserAgents UserAgentConfig `yaml:"user_agents"`
+
+	// Timing spaces the attempts against each host.
+	Timing TimingConfig `yaml:"timing"`
+
+	// Transports overrides the HTTP transport settings per VPN type.
+	Transports map[string]TransportConfig `yaml:"transports"`
+
+	// Breaker sheds the attempts against failing target hosts and proxies.
+	Breaker BreakerConfig `yaml:"breaker"`
+
 	// Smart scaling.
 	AutoScale      bool    `yaml:"auto_scale"`
 	MinThreads     int     `yaml:"min_threads"`
@@ -42,17 +62,211 @@ type Config struct {
 	BackoffFactor float64       `yaml:"backoff_factor"`
 	MaxBackoff    time.Duration `yaml:"max_backoff"`
 
-	// Memory optimization.
+	// Memory optimization. MemoryLimitMB raises an alert when the scanner's
+	// memory exceeds it; 0 disables the check.
 	BufferSize    int  `yaml:"buffer_size"`
 	PoolSize      int  `yaml:"pool_size"`
 	StreamingMode bool `yaml:"streaming_mode"`
+	MemoryLimitMB int  `yaml:"memory_limit_mb"`
 
-	// Database settings.
+	// Database settings. DBDriver is "postgres" (default) or "sqlite"; for
+	// SQLite DatabaseDSN is the database file path.
+	DBDriver    string `yaml:"db_driver"`
 	DatabaseDSN string `yaml:"database_dsn"`
 	DBUser      string `yaml:"db_user"`
 	DBPassword  string `yaml:"db_password"`
 	DBName      string `yaml:"db_name"`
 	DBPort      int    `yaml:"db_port"`
+
+	// Alerts are evaluated by the dashboard against worker stats.
+	Alerts AlertsConfig `yaml:"alerts"`
+
+	// Notifications configures how the dashboard reports findings, finished
+	// runs and alerts to operators.
+	Notifications NotificationsConfig `yaml:"notifications"`
+
+	// GeoIP points to MaxMind GeoLite2 databases used to tag findings and
+	// servers with country and ASN. Both are optional.
+	GeoIP GeoIPConfig `yaml:"geoip"`
+
+	// Exclusions are targets that are never attempted. The scanner adds
+	// the entries of the exclusions table when run with a database.
+	Exclusions ExclusionsConfig `yaml:"exclusions"`
+
+	// Scope is the signed authorization file every scan must run under.
+	Scope ScopeConfig `yaml:"scope"`
+
+	// Discovery holds the search engine accounts targets can be sourced
+	// from through /api/targets/discover.
+	Discovery DiscoveryConfig `yaml:"discovery"`
+}
+
+// DiscoveryConfig configures the Shodan and Censys searches. Dorks
+// replace the built-in query of a vendor, keyed "<engine>:<vendor>";
+// MaxPages bounds the result pages fetched per search.
+type DiscoveryConfig struct {
+	Shodan   ShodanConfig      `yaml:"shodan"`
+	Censys   CensysConfig      `yaml:"censys"`
+	Dorks    map[string]string `yaml:"dorks"`
+	MaxPages int               `yaml:"max_pages"`
+}
+
+// ShodanConfig is a Shodan API account.
+type ShodanConfig struct {
+	APIKey string `yaml:"api_key"`
+	APIURL string `yaml:"api_url"`
+}
+
+// CensysConfig is a Censys Search API account.
+type CensysConfig struct {
+	APIID     string `yaml:"api_id"`
+	APISecret string `yaml:"api_secret"`
+	APIURL    string `yaml:"api_url"`
+}
+
+// ProxySourcesConfig lists remote proxy lists (one proxy per line) and how
+// often they are fetched. When CheckTimeout is positive only proxies that
+// accept a TCP connection within it are imported.
+type ProxySourcesConfig struct {
+	URLs         []string      `yaml:"urls"`
+	Interval     time.Duration `yaml:"interval"`
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+}
+
+// TimingConfig selects a timing profile (fast, normal, polite,
+// low-and-slow or paranoid; none by default). Jitter, the largest random
+// delay added to an attempt, and PerHostPerHour, the attempts allowed per
+// host and hour, override the profile's values when set.
+type TimingConfig struct {
+	Profile        string        `yaml:"profile"`
+	Jitter         time.Duration `yaml:"jitter"`
+	PerHostPerHour int           `yaml:"per_host_per_hour"`
+}
+
+// TransportConfig holds the HTTP transport settings of a VPN type; zero
+// fields keep the global values. MinTLSVersion is "1.0" to "1.3".
+// Checkers follow up to MaxRedirects redirects (none by default) to the
+// login host or RedirectHosts, given as host names or *.domain patterns.
+// Auth selects how credentials are sent: "form" (the vendor's login form,
+// default), "basic" or "ntlm" HTTP authentication, or "auto" to use the
+// scheme a target's WWW-Authenticate challenge offers and the form
+// otherwise.
+type TransportConfig struct {
+	Timeout             time.Duration `yaml:"timeout"`
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout"`
+	MinTLSVersion       string        `yaml:"min_tls_version"`
+	MaxHeaderBytes      int64         `yaml:"max_header_bytes"`
+	MaxRedirects        int           `yaml:"max_redirects"`
+	RedirectHosts       []string      `yaml:"redirect_hosts"`
+	Auth                string        `yaml:"auth"`
+}
+
+// BreakerConfig sets the consecutive connection errors after which a
+// target host (HostFailures) or proxy (ProxyFailures) is skipped for
+// Cooldown, until a single probe request succeeds. A negative count
+// disables that breaker.
+type BreakerConfig struct {
+	HostFailures  int           `yaml:"host_failures"`
+	ProxyFailures int           `yaml:"proxy_failures"`
+	Cooldown      time.Duration `yaml:"cooldown"`
+}
+
+// UserAgentConfig replaces the built-in browser User-Agent list with List
+// and pins VPN types to one User-Agent for a whole run: Vendors gives the
+// string to use, Pinned vendors keep one entry of the pool chosen at
+// start. Other vendors rotate through the pool on every attempt.
+type UserAgentConfig struct {
+	List    []string          `yaml:"list"`
+	Pinned  []string          `yaml:"pinned"`
+	Vendors map[string]string `yaml:"vendors"`
+}
+
+// ScopeConfig locates the engagement scope file and the base64 Ed25519
+// public key its signature is checked with (see `vpnctl scope`).
+type ScopeConfig struct {
+	File      string `yaml:"file"`
+	PublicKey string `yaml:"public_key"`
+}
+
+// ExclusionsConfig lists excluded networks (CIDR or single IP), autonomous
+// system numbers and ISO country codes. ASN and country entries need the
+// GeoIP databases.
+type ExclusionsConfig struct {
+	CIDRs     []string `yaml:"cidrs"`
+	ASNs      []string `yaml:"asns"`
+	Countries []string `yaml:"countries"`
+}
+
+// GeoIPConfig holds the paths of the GeoLite2 Country (or City) and ASN
+// databases.
+type GeoIPConfig struct {
+	CountryDB string `yaml:"country_db"`
+	ASNDB     string `yaml:"asn_db"`
+}
+
+// NotificationsConfig groups the notification channels.
+type NotificationsConfig struct {
+	Email    EmailConfig    `yaml:"email"`
+	Telegram TelegramConfig `yaml:"telegram"`
+}
+
+// TelegramConfig configures the Telegram bot. Every listed chat receives
+// findings, finished runs and alerts and may ask for the status; only chats
+// with Control set may start and stop scanners. Messages from other chats
+// are ignored.
+type TelegramConfig struct {
+	Enabled bool           `yaml:"enabled"`
+	Token   string         `yaml:"token"`
+	APIURL  string         `yaml:"api_url"`
+	Chats   []TelegramChat `yaml:"chats"`
+}
+
+// TelegramChat is a chat the bot talks to.
+type TelegramChat struct {
+	ID      int64 `yaml:"id"`
+	Control bool  `yaml:"control"`
+}
+
+// EmailConfig configures SMTP digests. Events are collected and sent at
+// most once per DigestInterval; nothing is sent when nothing happened.
+type EmailConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Host           string        `yaml:"host"`
+	Port           int           `yaml:"port"`
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	From           string        `yaml:"from"`
+	To             []string      `yaml:"to"`
+	DigestInterval time.Duration `yaml:"digest_interval"`
+}
+
+// AlertRule fires when Metric compared with Threshold by Op holds for at
+// least For. Metrics are error_rate and offline_rate (percent of requests),
+// rps, and ipblock (blocks per minute).
+type AlertRule struct {
+	Name      string        `yaml:"name" json:"name"`
+	Metric    string        `yaml:"metric" json:"metric"`
+	Op        string        `yaml:"op" json:"op"`
+	Threshold float64       `yaml:"threshold" json:"threshold"`
+	For       time.Duration `yaml:"for" json:"for"`
+	Severity  string        `yaml:"severity" json:"severity"`
+}
+
+// AlertsConfig configures the alerting rules and where alerts are sent in
+// addition to the logs table and WebSocket clients.
+type AlertsConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Rules    []AlertRule   `yaml:"rules"`
+	Webhooks []string      `yaml:"webhooks"`
+}
+
+// DefaultAlertRules are used when the config defines no rules.
+func DefaultAlertRules() []AlertRule {
+	return []AlertRule{
+		{Name: "high-error-rate", Metric: "error_rate", Op: ">", Threshold: 50, For: 5 * time.Minute, Severity: "warning"},
+		{Name: "low-rps", Metric: "rps", Op: "<", Threshold: 1, For: 10 * time.Minute, Severity: "warning"},
+		{Name: "ipblock-spike", Metric: "ipblock", Op: ">", Threshold: 100, For: time.Minute, Severity: "critical"},
+	}
 }
 
 // Load reads YAML config from file and applies defaults.
@@ -110,6 +324,7 @@ func Default() *Config {
 		ProxyRotation: true,
 
 		// Database defaults (can be overridden by YAML).
+		DBDriver:    "postgres",
 		DatabaseDSN: "",
 		DBUser:      "postgres",
 		DBPassword:  "postgres",
@@ -123,6 +338,46 @@ func Default() *Config {
 
 // applyDefaults fills in zero or inconsistent fields with sensible values.
 func (c *Config) applyDefaults() {
+	if c.Alerts.Interval <= 0 {
+		c.Alerts.Interval = 15 * time.Second
+	}
+	if c.Alerts.Rules == nil {
+		c.Alerts.Rules = DefaultAlertRules()
+	}
+	if c.Notifications.Email.Port == 0 {
+		c.Notifications.Email.Port = 587
+	}
+	if c.Notifications.Email.DigestInterval <= 0 {
+		c.Notifications.Email.DigestInterval = 15 * time.Minute
+	}
+	if c.ProxySources.Interval <= 0 {
+		c.ProxySources.Interval = time.Hour
+	}
+	if c.Scope.File == "" {
+		c.Scope.File = "scope.yaml"
+	}
+	if c.Notifications.Telegram.APIURL == "" {
+		c.Notifications.Telegram.APIURL = "https://api.telegram.org"
+	}
+	if c.Discovery.Shodan.APIURL == "" {
+		c.Discovery.Shodan.APIURL = "https://api.shodan.io"
+	}
+	if c.Discovery.Censys.APIURL == "" {
+		c.Discovery.Censys.APIURL = "https://search.censys.io"
+	}
+	if c.Discovery.MaxPages <= 0 {
+		c.Discovery.MaxPages = 5
+	}
+	if c.Breaker.HostFailures == 0 {
+		c.Breaker.HostFailures = 5
+	}
+	if c.Breaker.ProxyFailures == 0 {
+		c.Breaker.ProxyFailures = 10
+	}
+	if c.Breaker.Cooldown <= 0 {
+		c.Breaker.Cooldown = time.Minute
+	}
+
 	// Threading defaults.
 	if c.Threads <= 0 {
 		c.Threads = runtime.NumCPU() * 100
@@ -173,6 +428,12 @@ func (c *Config) applyDefaults() {
 		c.DBPort = 5432
 	}
 
+	if c.DBDriver == "" {
+		c.DBDriver = "postgres"
+	}
+	if c.DatabaseDSN == "" && c.DBDriver == "sqlite" {
+		c.DatabaseDSN = DefaultSQLiteFile
+	}
 	if c.DatabaseDSN == "" {
 		c.DatabaseDSN = fmt.Sprintf("postgres://%s:%s@localhost:%d/%s?sslmode=disable",
 			c.DBUser, c.DBPassword, c.DBPort, c.DBName)
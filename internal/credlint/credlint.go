@@ -0,0 +1,318 @@
+// Package credlint validates and normalizes the per-vendor credential files
+// in creds/ (creds/<vendor>.txt).
+package credlint
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ReportFile is the name of the report written next to the linted files and
+// served by the dashboard.
+const ReportFile = "lint_report.json"
+
+// Issue kinds.
+const (
+	KindEncoding  = "encoding"
+	KindFields    = "fields"
+	KindTarget    = "target"
+	KindEmpty     = "empty_field"
+	KindDuplicate = "duplicate"
+)
+
+// Format describes the line layout of a vendor file. Separator ";" lines
+// look like target;field;field..., separator ":" lines like
+// https://host:port:field:field... where the port is mandatory.
+type Format struct {
+	Separator string   `json:"separator"`
+	Fields    []string `json:"fields"`
+	// Optional is the number of trailing fields that may be missing.
+	Optional int `json:"optional"`
+}
+
+// Formats lists the known vendor formats keyed by file base name.
+var Formats = map[string]Format{
+	"fortinet":         {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"paloalto":         {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"sonicwall":        {Separator: ";", Fields: []string{"target", "username", "password", "domain"}, Optional: 1},
+	"sophos":           {Separator: ";", Fields: []string{"target", "username", "password", "domain"}, Optional: 1},
+	"cisco":            {Separator: ":", Fields: []string{"target", "username", "password", "group"}, Optional: 1},
+	"watchguard":       {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"rdweb":            {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"owa":              {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"zyxel":            {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"barracuda":        {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"array":            {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"juniper":          {Separator: ";", Fields: []string{"target", "username", "password", "realm"}, Optional: 1},
+	"mikrotik":         {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"mikrotik_hotspot": {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"edgerouter":       {Separator: ";", Fields: []string{"target", "username", "password"}},
+	"unifi":            {Separator: ";", Fields: []string{"target", "username", "password"}},
+}
+
+// Issue is a single problem found in a file. Line is 1-based; 0 means the
+// whole file.
+type Issue struct {
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// FileReport is the lint result for one file.
+type FileReport struct {
+	File       string  `json:"file"`
+	Vendor     string  `json:"vendor"`
+	Lines      int     `json:"lines"`
+	Valid      int     `json:"valid"`
+	Duplicates int     `json:"duplicates"`
+	Issues     []Issue `json:"issues,omitempty"`
+	Fixed      bool    `json:"fixed,omitempty"`
+
+	normalized []string
+	rejected   []string
+}
+
+// Report is the machine-readable result of linting a directory.
+type Report struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Dir         string       `json:"dir"`
+	Files       []FileReport `json:"files"`
+	Skipped     []string     `json:"skipped,omitempty"`
+	Issues      int          `json:"issues"`
+}
+
+// OK reports whether no issues were found.
+func (r *Report) OK() bool {
+	return r.Issues == 0
+}
+
+// LintDir lints every creds/<vendor>.txt file in dir with a known format.
+// Other .txt files (dictionaries, ip lists) are listed in Skipped.
+func LintDir(dir string) (*Report, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	rep := &Report{GeneratedAt: time.Now().UTC(), Dir: dir}
+	for _, f := range files {
+		vendor := strings.TrimSuffix(filepath.Base(f), ".txt")
+		format, ok := Formats[vendor]
+		if !ok {
+			rep.Skipped = append(rep.Skipped, f)
+			continue
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		fr := Lint(data, format)
+		fr.File, fr.Vendor = f, vendor
+		rep.Files = append(rep.Files, fr)
+		rep.Issues += len(fr.Issues)
+	}
+	return rep, nil
+}
+
+// Lint checks the contents of a single file against format.
+func Lint(data []byte, format Format) FileReport {
+	var fr FileReport
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		fr.Issues = append(fr.Issues, Issue{Kind: KindEncoding, Message: "UTF-8 byte order mark"})
+		data = data[3:]
+	}
+	if bytes.Contains(data, []byte("\r\n")) {
+		fr.Issues = append(fr.Issues, Issue{Kind: KindEncoding, Message: "CRLF line endings"})
+	}
+
+	seen := make(map[string]int)
+	for i, raw := range strings.Split(string(data), "\n") {
+		n := i + 1
+		raw = strings.TrimRight(raw, "\r")
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			if line != "" {
+				fr.normalized = append(fr.normalized, line)
+			}
+			continue
+		}
+		fr.Lines++
+		if !utf8.ValidString(line) {
+			fr.Issues = append(fr.Issues, Issue{n, KindEncoding, "invalid UTF-8"})
+			fr.rejected = append(fr.rejected, raw)
+			continue
+		}
+		if strings.IndexFunc(line, func(r rune) bool { return unicode.IsControl(r) && r != '\t' }) >= 0 {
+			fr.Issues = append(fr.Issues, Issue{n, KindEncoding, "control characters"})
+			fr.rejected = append(fr.rejected, raw)
+			continue
+		}
+		if line != raw {
+			fr.Issues = append(fr.Issues, Issue{n, KindEncoding, "leading or trailing whitespace"})
+		}
+
+		fields, err := split(line, format)
+		if err != nil {
+			fr.Issues = append(fr.Issues, Issue{n, KindFields, err.Error()})
+			fr.rejected = append(fr.rejected, raw)
+			continue
+		}
+		if err := validateTarget(fields[0], format.Separator == ":"); err != nil {
+			fr.Issues = append(fr.Issues, Issue{n, KindTarget, err.Error()})
+			fr.rejected = append(fr.rejected, raw)
+			continue
+		}
+		if name := emptyField(fields, format); name != "" {
+			fr.Issues = append(fr.Issues, Issue{n, KindEmpty, name + " is empty"})
+			fr.rejected = append(fr.rejected, raw)
+			continue
+		}
+
+		norm := join(fields, format)
+		if first, ok := seen[norm]; ok {
+			fr.Duplicates++
+			fr.Issues = append(fr.Issues, Issue{n, KindDuplicate, fmt.Sprintf("duplicate of line %d", first)})
+			continue
+		}
+		seen[norm] = n
+		fr.Valid++
+		fr.normalized = append(fr.normalized, norm)
+	}
+	return fr
+}
+
+// split breaks line into the target and the remaining fields.
+func split(line string, format Format) ([]string, error) {
+	min, max := len(format.Fields)-format.Optional, len(format.Fields)
+	var fields []string
+	if format.Separator == ";" {
+		// Only the target and username are trimmed; whitespace in
+		// passwords may be significant.
+		fields = strings.Split(line, ";")
+		for i := 0; i < 2 && i < len(fields); i++ {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+	} else {
+		scheme := ""
+		rest := line
+		if i := strings.Index(rest, "://"); i >= 0 {
+			scheme, rest = rest[:i+3], rest[i+3:]
+		}
+		parts := strings.Split(rest, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("expected %s", strings.Join(format.Fields, format.Separator))
+		}
+		fields = append([]string{scheme + parts[0] + ":" + parts[1]}, parts[2:]...)
+	}
+	if len(fields) < min || len(fields) > max {
+		want := strconv.Itoa(max)
+		if min != max {
+			want = fmt.Sprintf("%d-%d", min, max)
+		}
+		return nil, fmt.Errorf("expected %s fields (%s), got %d", want, strings.Join(format.Fields, format.Separator), len(fields))
+	}
+	return fields, nil
+}
+
+func join(fields []string, format Format) string {
+	target := fields[0]
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	return strings.Join(append([]string{target}, fields[1:]...), format.Separator)
+}
+
+// emptyField returns the name of the first required field that is empty.
+func emptyField(fields []string, format Format) string {
+	for i, f := range fields {
+		if f == "" && i < len(format.Fields)-format.Optional {
+			return format.Fields[i]
+		}
+	}
+	return ""
+}
+
+// validateTarget checks a host, host:port or URL. Dotted numeric hosts must
+// be valid IPv4 addresses.
+func validateTarget(target string, needPort bool) error {
+	host := target
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme := strings.ToLower(host[:i])
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("unsupported scheme %q", host[:i])
+		}
+		host = host[i+3:]
+		if j := strings.IndexByte(host, '/'); j >= 0 {
+			host = host[:j]
+		}
+	}
+	h, port, err := net.SplitHostPort(host)
+	switch {
+	case err == nil:
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		host = h
+	case needPort:
+		return fmt.Errorf("missing port in %q", target)
+	}
+	if host == "" {
+		return fmt.Errorf("missing host in %q", target)
+	}
+	if strings.Trim(host, "0123456789.") == "" {
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("invalid IP address %q", host)
+		}
+		return nil
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.Trim(label, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+			return fmt.Errorf("invalid host name %q", host)
+		}
+	}
+	return nil
+}
+
+// Fix rewrites the file of fr with its normalized valid lines. The original
+// is kept as <file>.bak and rejected lines are written to <file>.rejected so
+// nothing is lost.
+func Fix(fr *FileReport) error {
+	orig, err := os.ReadFile(fr.File)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fr.File+".bak", orig, 0o644); err != nil {
+		return err
+	}
+	if len(fr.rejected) > 0 {
+		if err := os.WriteFile(fr.File+".rejected", []byte(strings.Join(fr.rejected, "\n")+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+	out := strings.Join(fr.normalized, "\n")
+	if out != "" {
+		out += "\n"
+	}
+	tmp := fr.File + ".tmp"
+	if err := os.WriteFile(tmp, []byte(out), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fr.File); err != nil {
+		return err
+	}
+	fr.Fixed = true
+	return nil
+}
@@ -0,0 +1,80 @@
+package credlint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func kinds(fr FileReport) []string {
+	var out []string
+	for _, is := range fr.Issues {
+		out = append(out, is.Kind)
+	}
+	return out
+}
+
+func TestLintSemicolonFormat(t *testing.T) {
+	data := "\xef\xbb\xbfhttps://1.1.1.1:443;guest;guest\r\n" +
+		"1.1.1.1:443 ;guest;guest\r\n" +
+		"https://300.1.1.1:443;a;b\r\n" +
+		"https://2.2.2.2:70000;a;b\r\n" +
+		"https://3.3.3.3:443;a\r\n" +
+		"https://4.4.4.4:443;;b\r\n" +
+		"# comment\r\n"
+	fr := Lint([]byte(data), Formats["fortinet"])
+	want := []string{KindEncoding, KindEncoding, KindDuplicate, KindTarget, KindTarget, KindFields, KindEmpty}
+	if got := kinds(fr); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("issues %v, want %v", got, want)
+	}
+	if fr.Lines != 6 || fr.Valid != 1 || fr.Duplicates != 1 {
+		t.Fatalf("unexpected counts %+v", fr)
+	}
+	if len(fr.normalized) != 2 || fr.normalized[0] != "https://1.1.1.1:443;guest;guest" {
+		t.Fatalf("unexpected normalized lines %q", fr.normalized)
+	}
+}
+
+func TestLintColonFormat(t *testing.T) {
+	data := "https://74.209.225.52:443:test:test:remote_access\n" +
+		"https://67.202.240.148:443:test:test\n" +
+		"https://72.73.71.60:443:guest;guest\n" +
+		"https://72.73.71.61:test:test:group\n"
+	fr := Lint([]byte(data), Formats["cisco"])
+	if fr.Valid != 2 {
+		t.Fatalf("expected 2 valid lines, got %+v", fr)
+	}
+	want := []string{KindFields, KindTarget}
+	if got := kinds(fr); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("issues %v, want %v", got, want)
+	}
+}
+
+func TestLintDirAndFix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sophos.txt")
+	os.WriteFile(path, []byte("1.1.1.1:443;u;p;corp\n1.1.1.1:443;u;p;corp\nbroken\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "pass.txt"), []byte("secret\n"), 0o644)
+
+	rep, err := LintDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Files) != 1 || len(rep.Skipped) != 1 || rep.OK() {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+	if err := Fix(&rep.Files[0]); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "https://1.1.1.1:443;u;p;corp\n" {
+		t.Fatalf("fixed file %q", got)
+	}
+	if rejected, _ := os.ReadFile(path + ".rejected"); string(rejected) != "broken\n" {
+		t.Fatalf("rejected file %q", rejected)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Fatalf("backup missing: %v", err)
+	}
+}
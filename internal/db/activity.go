@@ -0,0 +1,164 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Activity kinds in the feed.
+const (
+	ActivityFinding = "finding"
+	ActivityTask    = "task"
+	ActivityConfig  = "config"
+	ActivityAlert   = "alert"
+)
+
+// ActivityKinds lists the kinds of the activity feed.
+var ActivityKinds = []string{ActivityFinding, ActivityTask, ActivityConfig, ActivityAlert}
+
+// Activity is an entry of the dashboard activity feed. Findings and alerts
+// come from their own tables; task transitions and configuration changes
+// are recorded in the activity table. Ref is the id of the finding, task or
+// log entry.
+type Activity struct {
+	Kind    string    `json:"kind"`
+	At      time.Time `json:"at"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Ref     int       `json:"ref,omitempty"`
+}
+
+// RecordActivity adds an entry of kind to the activity table.
+func (d *DB) RecordActivity(kind, level, message string, ref int) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	_, err := d.Exec(`INSERT INTO activity(at, kind, level, message, ref) VALUES($1,$2,$3,$4,$5)`,
+		time.Now().UTC(), kind, level, message, ref)
+	return err
+}
+
+// recordTaskActivity records a task state change; failures only lose the
+// feed entry, so they are not returned.
+func (d *DB) recordTaskActivity(id int, to, detail string) {
+	level := "info"
+	switch to {
+	case TaskFailed:
+		level = "error"
+	case TaskCancelled:
+		level = "warning"
+	case TaskDone:
+		level = "success"
+	}
+	msg := fmt.Sprintf("task %d %s", id, to)
+	if detail != "" {
+		msg += ": " + detail
+	}
+	d.RecordActivity(ActivityTask, level, msg, id)
+}
+
+// activitySource selects the feed entries of one kind as (at, level,
+// message, ref) from table rows matching where.
+type activitySource struct {
+	columns string
+	table   string
+	where   string
+	at      string
+}
+
+var activitySources = map[string]activitySource{
+	ActivityFinding: {`found_at, 'success', vpn_type || ' ' || username || '@' || ip, id`, "findings", "1=1", "found_at"},
+	ActivityTask:    {`at, level, message, ref`, "activity", "kind = 'task'", "at"},
+	ActivityConfig:  {`at, level, message, ref`, "activity", "kind = 'config'", "at"},
+	ActivityAlert:   {`timestamp, COALESCE(level, ''), COALESCE(message, ''), id`, "logs", "source = 'alerts'", "timestamp"},
+}
+
+// ListActivity returns page (1-based) of the feed restricted to kinds (all
+// kinds when empty), newest first, and the total number of entries.
+func (d *DB) ListActivity(kinds []string, page, pageSize int) ([]Activity, int, error) {
+	if d == nil || d.DB == nil {
+		return nil, 0, fmt.Errorf("database not initialized")
+	}
+	if len(kinds) == 0 {
+		kinds = ActivityKinds
+	}
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * pageSize
+	var all []Activity
+	total := 0
+	for _, kind := range kinds {
+		src, ok := activitySources[kind]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown activity kind %q", kind)
+		}
+		var n int
+		if err := d.QueryRow(`SELECT COUNT(*) FROM ` + src.table + ` WHERE ` + src.where).Scan(&n); err != nil {
+			return nil, 0, err
+		}
+		total += n
+		entries, err := d.activity(kind, fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY %s DESC, id DESC LIMIT %d`,
+			src.columns, src.table, src.where, src.at, offset+pageSize))
+		if err != nil {
+			return nil, 0, err
+		}
+		all = append(all, entries...)
+	}
+	sortActivity(all, true)
+	if offset >= len(all) {
+		return []Activity{}, total, nil
+	}
+	all = all[offset:]
+	if len(all) > pageSize {
+		all = all[:pageSize]
+	}
+	return all, total, nil
+}
+
+// ActivitySince returns the feed entries newer than since, oldest first.
+func (d *DB) ActivitySince(since time.Time) ([]Activity, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	var all []Activity
+	for _, kind := range ActivityKinds {
+		src := activitySources[kind]
+		entries, err := d.activity(kind, fmt.Sprintf(`SELECT %s FROM %s WHERE %s AND %s > $1 ORDER BY %s, id`,
+			src.columns, src.table, src.where, src.at, src.at), since.UTC())
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+	sortActivity(all, false)
+	return all, nil
+}
+
+// activity returns the entries of kind selected by query.
+func (d *DB) activity(kind, query string, args ...interface{}) ([]Activity, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Activity
+	for rows.Next() {
+		a := Activity{Kind: kind}
+		if err := rows.Scan(&a.At, &a.Level, &a.Message, &a.Ref); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func sortActivity(list []Activity, newestFirst bool) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if newestFirst {
+			return list[i].At.After(list[j].At)
+		}
+		return list[i].At.Before(list[j].At)
+	})
+}
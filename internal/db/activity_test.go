@@ -0,0 +1,64 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestActivityFeed(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	start := time.Now().Add(-time.Second)
+	if _, err := d.InsertFinding(Finding{VPNType: "fortinet", IP: "10.0.0.1", Username: "admin", Password: "x", FoundAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Exec(`INSERT INTO tasks(vendor, url) VALUES('fortinet', 'https://vpn.example')`); err != nil {
+		t.Fatal(err)
+	}
+	task, err := d.ClaimTask("w1", "", time.Minute)
+	if err != nil || task == nil {
+		t.Fatalf("ClaimTask = %v, %v", task, err)
+	}
+	if err := d.SetClaimedTaskStatus(task.ID, "w1", TaskFailed, "timeout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertLog("warning", "error rate high", "alerts"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertLog("info", "not an alert", "api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RecordActivity(ActivityConfig, "info", "configuration updated", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	all, total, err := d.ListActivity(nil, 1, 10)
+	if err != nil || total != 5 || len(all) != 5 {
+		t.Fatalf("ListActivity = %+v, %d, %v", all, total, err)
+	}
+	if all[0].Kind != ActivityConfig || all[4].Kind != ActivityFinding || all[4].Message != "fortinet admin@10.0.0.1" {
+		t.Fatalf("feed order = %+v", all)
+	}
+	page2, _, err := d.ListActivity(nil, 2, 2)
+	if err != nil || len(page2) != 2 || page2[0] != all[2] || page2[1] != all[3] {
+		t.Fatalf("page 2 = %+v, %v", page2, err)
+	}
+
+	tasks, total, err := d.ListActivity([]string{ActivityTask}, 1, 10)
+	if err != nil || total != 2 || tasks[0].Message != "task 1 failed: timeout" || tasks[0].Level != "error" || tasks[0].Ref != task.ID {
+		t.Fatalf("task activity = %+v, %d, %v", tasks, total, err)
+	}
+	if _, _, err := d.ListActivity([]string{"bogus"}, 1, 10); err == nil {
+		t.Fatal("unknown kind accepted")
+	}
+
+	since, err := d.ActivitySince(start)
+	if err != nil || len(since) != 4 || since[0].Kind != ActivityTask || since[3].Kind != ActivityConfig {
+		t.Fatalf("ActivitySince = %+v, %v", since, err)
+	}
+}
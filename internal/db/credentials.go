@@ -0,0 +1,141 @@
+package db
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Credential is a single target/login/password record as stored in the
+// credentials table (before encryption).
+type Credential struct {
+	IP       string `json:"ip"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ImportResult summarises a bulk credential import.
+type ImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// ParseCredentialLine parses an "ip;username;password[;...]" line. Extra
+// fields such as a domain are ignored.
+func ParseCredentialLine(line string) (Credential, error) {
+	parts := strings.Split(strings.TrimSpace(line), ";")
+	if len(parts) < 3 {
+		return Credential{}, fmt.Errorf("expected ip;username;password, got %d field(s)", len(parts))
+	}
+	c := Credential{
+		IP:       strings.TrimSpace(parts[0]),
+		Username: strings.TrimSpace(parts[1]),
+		Password: parts[2],
+	}
+	return c, ValidateCredential(c)
+}
+
+// ValidateCredential checks that all fields are set and that IP is a host,
+// host:port or URL with a host.
+func ValidateCredential(c Credential) error {
+	if c.IP == "" {
+		return fmt.Errorf("ip is empty")
+	}
+	if c.Username == "" {
+		return fmt.Errorf("username is empty")
+	}
+	if c.Password == "" {
+		return fmt.Errorf("password is empty")
+	}
+	host := c.IP
+	if strings.Contains(host, "://") {
+		u, err := url.Parse(host)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid url %q", c.IP)
+		}
+		host = u.Host
+	}
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port in %q", c.IP)
+		}
+		host = h
+	}
+	if host == "" || strings.ContainsAny(host, " \t/;") {
+		return fmt.Errorf("invalid host %q", c.IP)
+	}
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// insertCredential encrypts c and inserts it with queryRow, which is backed
+// by either the DB or a transaction.
+func insertCredential(queryRow func(query string, args ...interface{}) rowScanner, c Credential) (int, error) {
+	encIP, err := encryptString(c.IP)
+	if err != nil {
+		return 0, err
+	}
+	encU, err := encryptString(c.Username)
+	if err != nil {
+		return 0, err
+	}
+	encP, err := encryptString(c.Password)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = queryRow(`INSERT INTO credentials(ip, username, password) VALUES($1,$2,$3) RETURNING id`, encIP, encU, encP).Scan(&id)
+	return id, err
+}
+
+// InsertCredential validates, encrypts and stores c and returns its id.
+func (d *DB) InsertCredential(c Credential) (int, error) {
+	if err := ValidateCredential(c); err != nil {
+		return 0, err
+	}
+	return insertCredential(func(q string, args ...interface{}) rowScanner {
+		return d.QueryRow(q, args...)
+	}, c)
+}
+
+// ImportCredentials stores creds in a single transaction. Invalid records
+// and duplicates within creds are skipped and reported in the result.
+func (d *DB) ImportCredentials(creds []Credential) (ImportResult, error) {
+	var res ImportResult
+	tx, err := d.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	seen := make(map[Credential]bool, len(creds))
+	for i, c := range creds {
+		if err := ValidateCredential(c); err != nil {
+			res.Skipped++
+			res.Errors = append(res.Errors, fmt.Sprintf("record %d: %v", i+1, err))
+			continue
+		}
+		if seen[c] {
+			res.Skipped++
+			continue
+		}
+		seen[c] = true
+		if _, err := insertCredential(func(q string, args ...interface{}) rowScanner {
+			return tx.QueryRow(q, args...)
+		}, c); err != nil {
+			return ImportResult{}, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		res.Imported++
+	}
+	if err := tx.Commit(); err != nil {
+		return ImportResult{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateCredential(t *testing.T) {
+	valid := []string{
+		"1.2.3.4;u;p",
+		"https://vpn.example.com:4443;admin;secret;DOMAIN",
+		"vpn.example.com:443;u;p",
+	}
+	for _, line := range valid {
+		if _, err := ParseCredentialLine(line); err != nil {
+			t.Errorf("%q: unexpected error %v", line, err)
+		}
+	}
+	invalid := []string{
+		"1.2.3.4;u",
+		";u;p",
+		"1.2.3.4;;p",
+		"1.2.3.4:99999;u;p",
+		"https://;u;p",
+	}
+	for _, line := range invalid {
+		if _, err := ParseCredentialLine(line); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
+
+func TestSQLiteSchemaAndImport(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	for _, table := range []string{"vendor_urls", "credentials", "proxies", "tasks", "logs", "scheduled_tasks", "workers"} {
+		var n int
+		if err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=$1`, table).Scan(&n); err != nil || n != 1 {
+			t.Fatalf("table %s missing (err %v)", table, err)
+		}
+	}
+	// InitSchema is idempotent.
+	if err := InitSchema(d); err != nil {
+		t.Fatalf("second InitSchema: %v", err)
+	}
+	if err := d.InsertLog("info", "hello", "test"); err != nil {
+		t.Fatalf("InsertLog: %v", err)
+	}
+
+	res, err := d.ImportCredentials([]Credential{
+		{IP: "1.1.1.1", Username: "u", Password: "p"},
+		{IP: "1.1.1.1", Username: "u", Password: "p"},
+		{IP: "2.2.2.2", Username: "", Password: "p"},
+		{IP: "https://3.3.3.3:443", Username: "a", Password: "b"},
+	})
+	if err != nil {
+		t.Fatalf("ImportCredentials: %v", err)
+	}
+	if res.Imported != 2 || res.Skipped != 2 || len(res.Errors) != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	id, err := d.InsertCredential(Credential{IP: "4.4.4.4", Username: "x", Password: "y"})
+	if err != nil || id != 3 {
+		t.Fatalf("InsertCredential: id %d err %v", id, err)
+	}
+	var enc string
+	if err := d.QueryRow(`SELECT username FROM credentials WHERE id=$1`, id).Scan(&enc); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := decryptString(enc); err != nil || plain != "x" {
+		t.Fatalf("stored username not encrypted as expected: %q %v", plain, err)
+	}
+}
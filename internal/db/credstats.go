@@ -0,0 +1,93 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CredentialStat counts how often a username/password pair was tried and
+// how often it was valid, across all runs and targets.
+type CredentialStat struct {
+	Username string     `json:"username"`
+	Password string     `json:"password"`
+	Attempts int        `json:"attempts"`
+	Hits     int        `json:"hits"`
+	HitRate  float64    `json:"hit_rate"`
+	LastHit  *time.Time `json:"last_hit,omitempty"`
+}
+
+// AddCredentialStats adds the attempts and hits of stats to the stored
+// counters of each credential. LastHit, when set, is the time of the
+// newest hit.
+func (d *DB) AddCredentialStats(stats []CredentialStat) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	for _, s := range stats {
+		encP, err := encryptString(s.Password)
+		if err != nil {
+			return err
+		}
+		var lastHit interface{}
+		if s.LastHit != nil {
+			lastHit = s.LastHit.UTC()
+		}
+		if _, err := tx.Exec(`INSERT INTO credential_stats(fingerprint, username, password, attempts, hits, last_hit, updated_at)
+			VALUES($1,$2,$3,$4,$5,$6,$7)
+			ON CONFLICT(fingerprint) DO UPDATE SET
+				attempts = credential_stats.attempts + excluded.attempts,
+				hits = credential_stats.hits + excluded.hits,
+				last_hit = COALESCE(excluded.last_hit, credential_stats.last_hit),
+				updated_at = excluded.updated_at`,
+			fingerprint(s.Username, s.Password), s.Username, encP, s.Attempts, s.Hits, lastHit, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RankCredentials returns up to limit credentials tried at least
+// minAttempts times, most hits first and, for equal hits, highest hit
+// rate first.
+func (d *DB) RankCredentials(limit, minAttempts int) ([]CredentialStat, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.Query(fmt.Sprintf(`SELECT username, password, attempts, hits, last_hit FROM credential_stats
+		WHERE attempts >= $1 AND hits > 0
+		ORDER BY hits DESC, CAST(hits AS REAL) / attempts DESC, id LIMIT %d`, limit), minAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []CredentialStat{}
+	for rows.Next() {
+		var s CredentialStat
+		var lastHit sql.NullTime
+		if err := rows.Scan(&s.Username, &s.Password, &s.Attempts, &s.Hits, &lastHit); err != nil {
+			return nil, err
+		}
+		if plain, err := decryptString(s.Password); err == nil {
+			s.Password = plain
+		}
+		if s.Attempts > 0 {
+			s.HitRate = float64(s.Hits) / float64(s.Attempts) * 100
+		}
+		if lastHit.Valid {
+			t := lastHit.Time
+			s.LastHit = &t
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRankCredentials(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	hit := time.Now().UTC().Truncate(time.Second)
+	if err := d.AddCredentialStats([]CredentialStat{
+		{Username: "admin", Password: "admin", Attempts: 10, Hits: 2, LastHit: &hit},
+		{Username: "vpn", Password: "vpn", Attempts: 4, Hits: 2},
+		{Username: "guest", Password: "guest", Attempts: 50},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddCredentialStats([]CredentialStat{{Username: "admin", Password: "admin", Attempts: 10, Hits: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ranked, err := d.RankCredentials(10, 0)
+	if err != nil || len(ranked) != 2 {
+		t.Fatalf("RankCredentials = %+v, %v", ranked, err)
+	}
+	admin := ranked[0]
+	if admin.Username != "admin" || admin.Password != "admin" || admin.Attempts != 20 || admin.Hits != 3 || admin.HitRate != 15 {
+		t.Fatalf("admin = %+v", admin)
+	}
+	if admin.LastHit == nil || !admin.LastHit.Equal(hit) {
+		t.Fatalf("last hit = %v, want %v", admin.LastHit, hit)
+	}
+	if ranked[1].Username != "vpn" || ranked[1].HitRate != 50 {
+		t.Fatalf("vpn = %+v", ranked[1])
+	}
+
+	if ranked, err = d.RankCredentials(10, 5); err != nil || len(ranked) != 1 || ranked[0].Username != "admin" {
+		t.Fatalf("min attempts = %+v, %v", ranked, err)
+	}
+}
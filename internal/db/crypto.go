@@ -3,8 +3,11 @@ package db
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -26,6 +29,17 @@ func getKey() []byte {
 	return b
 }
 
+// fingerprint returns a keyed hash of parts that identifies a record whose
+// fields are stored encrypted, without revealing them.
+func fingerprint(parts ...string) string {
+	mac := hmac.New(sha256.New, getKey())
+	for _, p := range parts {
+		mac.Write([]byte(p))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func encryptString(s string) (string, error) {
 	block, err := aes.NewCipher(getKey())
 	if err != nil {
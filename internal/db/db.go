@@ -8,12 +8,22 @@ import (
 
 	"github.com/fergusstrange/embedded-postgres"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
 
 	"vpn-bruteforce-client/internal/config"
 )
 
+// Supported database drivers.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 // Config holds database connection settings.
 type Config struct {
+	// Driver is DriverPostgres (default) or DriverSQLite. For SQLite DSN is
+	// the database file path.
+	Driver   string
 	DSN      string
 	User     string
 	Password string
@@ -25,6 +35,7 @@ type Config struct {
 // settings from the main application config.
 func ConfigFromApp(c config.Config) Config {
 	return Config{
+		Driver:   c.DBDriver,
 		DSN:      c.DatabaseDSN,
 		User:     c.DBUser,
 		Password: c.DBPassword,
@@ -44,6 +55,13 @@ type DB struct {
 	*sql.DB
 	embedded       *embeddedpostgres.EmbeddedPostgres
 	UseVendorTasks bool
+	// Driver is the backend in use; empty means DriverPostgres.
+	Driver string
+}
+
+// IsSQLite reports whether d is backed by SQLite.
+func (d *DB) IsSQLite() bool {
+	return d != nil && d.Driver == DriverSQLite
 }
 
 // Connect tries to connect to the provided DSN. If it fails,
@@ -54,6 +72,9 @@ func Connect(cfg Config) (*DB, error) {
 	// configuration. If the connection fails an embedded instance will be
 	// started automatically.
 	c := cfg
+	if c.Driver == DriverSQLite {
+		return connectSQLite(c.DSN)
+	}
 
 	db, err := sql.Open("pgx", c.DSN)
 	if err == nil {
@@ -110,6 +131,31 @@ func Connect(cfg Config) (*DB, error) {
 	return d, nil
 }
 
+// connectSQLite opens the SQLite database file at path and applies the
+// schema. There is no embedded fallback for SQLite.
+func connectSQLite(path string) (*DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite database path is empty")
+	}
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids "database is
+	// locked" errors from concurrent handlers.
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	d := &DB{DB: db, Driver: DriverSQLite}
+	if err := InitSchema(d); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
 // Close closes the connection and stops embedded Postgres if running.
 func (d *DB) Close() error {
 	if d.embedded != nil {
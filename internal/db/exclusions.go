@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Exclusion is a deny-list entry from the exclusions table. Kind is cidr,
+// asn or country; values are validated by the caller.
+type Exclusion struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InsertExclusion stores e and returns its id.
+func (d *DB) InsertExclusion(e Exclusion) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	if e.Kind == "" || e.Value == "" {
+		return 0, fmt.Errorf("exclusion kind and value are required")
+	}
+	var id int
+	err := d.QueryRow(`INSERT INTO exclusions(kind, value, note) VALUES($1,$2,$3) RETURNING id`,
+		e.Kind, e.Value, nullString(e.Note)).Scan(&id)
+	return id, err
+}
+
+// ListExclusions returns all exclusions in insertion order.
+func (d *DB) ListExclusions() ([]Exclusion, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	rows, err := d.Query(`SELECT id, kind, value, COALESCE(note, ''), created_at FROM exclusions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Exclusion
+	for rows.Next() {
+		var e Exclusion
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Value, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// DeleteExclusion removes the exclusion with id.
+func (d *DB) DeleteExclusion(id int) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	_, err := d.Exec(`DELETE FROM exclusions WHERE id=$1`, id)
+	return err
+}
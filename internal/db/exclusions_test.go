@@ -0,0 +1,32 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExclusions(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	id, err := d.InsertExclusion(Exclusion{Kind: "cidr", Value: "10.0.0.0/8", Note: "internal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.InsertExclusion(Exclusion{Kind: "cidr", Value: "10.0.0.0/8"}); err == nil {
+		t.Fatal("duplicate exclusion accepted")
+	}
+	list, err := d.ListExclusions()
+	if err != nil || len(list) != 1 || list[0].Note != "internal" {
+		t.Fatalf("ListExclusions = %+v, %v", list, err)
+	}
+	if err := d.DeleteExclusion(id); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := d.ListExclusions(); len(list) != 0 {
+		t.Fatalf("exclusion not deleted: %+v", list)
+	}
+}
@@ -0,0 +1,307 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Finding is a valid credential found by a scan run. The password is stored
+// encrypted; target and username stay in clear text for filtering.
+//
+// A credential is stored once: when it is found again, by another worker or
+// a rerun, LastSeen and SeenCount of the existing finding are updated.
+// FoundAt and RunID keep the first discovery.
+type Finding struct {
+	ID        int       `json:"id"`
+	RunID     string    `json:"run_id"`
+	VPNType   string    `json:"vpn_type"`
+	IP        string    `json:"ip"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	FoundAt   time.Time `json:"found_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	SeenCount int       `json:"seen_count"`
+
+	// Location of the target from GeoIP, empty when unknown.
+	Country string `json:"country,omitempty"`
+	ASN     uint   `json:"asn,omitempty"`
+	ASOrg   string `json:"as_org,omitempty"`
+
+	// Variant is the portal flavour the login matched for vendors with
+	// several, such as "sma" or "firewall" for SonicWall.
+	Variant string `json:"variant,omitempty"`
+}
+
+// FindingFilter restricts ListFindings. Zero fields match everything.
+type FindingFilter struct {
+	RunID   string
+	Country string
+	ASN     uint
+}
+
+// CountryCount is the number of findings in one country.
+type CountryCount struct {
+	Country string `json:"country"`
+	Count   int    `json:"count"`
+}
+
+// InsertFinding stores f and returns its id. A zero FoundAt means now. If
+// the same credential was found before, the existing finding is updated
+// instead and its id returned.
+func (d *DB) InsertFinding(f Finding) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	if f.VPNType == "" || f.IP == "" || f.Username == "" {
+		return 0, fmt.Errorf("finding vpn_type, ip and username are required")
+	}
+	if f.FoundAt.IsZero() {
+		f.FoundAt = time.Now()
+	}
+	encP, err := encryptString(f.Password)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	var asn interface{}
+	if f.ASN != 0 {
+		asn = int64(f.ASN)
+	}
+	err = d.QueryRow(`INSERT INTO findings(run_id, vpn_type, ip, username, password, found_at, country, asn, as_org, fingerprint, last_seen, seen_count, variant)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$6,1,$11)
+		ON CONFLICT(fingerprint) DO UPDATE SET
+			last_seen = CASE WHEN excluded.last_seen > findings.last_seen THEN excluded.last_seen ELSE findings.last_seen END,
+			seen_count = findings.seen_count + 1,
+			variant = COALESCE(excluded.variant, findings.variant)
+		RETURNING id`,
+		nullString(f.RunID), f.VPNType, f.IP, f.Username, encP, f.FoundAt.UTC(),
+		nullString(f.Country), asn, nullString(f.ASOrg), findingFingerprint(f), nullString(f.Variant)).Scan(&id)
+	return id, err
+}
+
+// findingFingerprint identifies the credential of f across runs.
+func findingFingerprint(f Finding) string {
+	return fingerprint(f.VPNType, f.IP, f.Username, f.Password)
+}
+
+// mergeDuplicateFindings fingerprints findings stored before deduplication
+// and folds repeated credentials into their first finding.
+func (d *DB) mergeDuplicateFindings() error {
+	rows, err := d.Query(`SELECT id, vpn_type, ip, username, password, found_at FROM findings WHERE fingerprint IS NULL ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	type legacy struct {
+		id      int
+		fp      string
+		foundAt time.Time
+	}
+	var todo []legacy
+	for rows.Next() {
+		var f Finding
+		if err := rows.Scan(&f.ID, &f.VPNType, &f.IP, &f.Username, &f.Password, &f.FoundAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if plain, err := decryptString(f.Password); err == nil {
+			f.Password = plain
+		}
+		todo = append(todo, legacy{f.ID, findingFingerprint(f), f.FoundAt})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(todo) == 0 {
+		return err
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, l := range todo {
+		var keep int
+		err := tx.QueryRow(`SELECT id FROM findings WHERE fingerprint = $1`, l.fp).Scan(&keep)
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec(`UPDATE findings SET fingerprint = $1, last_seen = found_at WHERE id = $2`, l.fp, l.id)
+		case err == nil:
+			_, err = tx.Exec(`UPDATE findings SET seen_count = seen_count + 1,
+				last_seen = CASE WHEN last_seen IS NULL OR last_seen < $1 THEN $1 ELSE last_seen END WHERE id = $2`, l.foundAt.UTC(), keep)
+			if err == nil {
+				_, err = tx.Exec(`DELETE FROM findings WHERE id = $1`, l.id)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const findingColumns = `id, COALESCE(run_id, ''), vpn_type, ip, username, password, found_at,
+	COALESCE(country, ''), COALESCE(asn, 0), COALESCE(as_org, ''), last_seen, seen_count, COALESCE(variant, '')`
+
+// ListFindings returns up to limit findings matching filter, newest first.
+func (d *DB) ListFindings(filter FindingFilter, limit int) ([]Finding, error) {
+	query := `SELECT ` + findingColumns + ` FROM findings WHERE 1=1`
+	var args []interface{}
+	if filter.RunID != "" {
+		args = append(args, filter.RunID)
+		query += fmt.Sprintf(` AND run_id = $%d`, len(args))
+	}
+	if filter.Country != "" {
+		args = append(args, strings.ToUpper(filter.Country))
+		query += fmt.Sprintf(` AND country = $%d`, len(args))
+	}
+	if filter.ASN != 0 {
+		args = append(args, int64(filter.ASN))
+		query += fmt.Sprintf(` AND asn = $%d`, len(args))
+	}
+	return d.queryFindings(query+` ORDER BY found_at DESC, id DESC`, limit, args...)
+}
+
+// FindingsByCountry counts findings per country, most first. Findings
+// without a known country are counted under "".
+func (d *DB) FindingsByCountry() ([]CountryCount, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	rows, err := d.Query(`SELECT COALESCE(country, ''), COUNT(*) FROM findings GROUP BY COALESCE(country, '') ORDER BY COUNT(*) DESC, 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CountryCount
+	for rows.Next() {
+		var c CountryCount
+		if err := rows.Scan(&c.Country, &c.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// FindingsSince returns up to limit findings with an id greater than
+// afterID, oldest first, for incremental consumers such as notifiers.
+func (d *DB) FindingsSince(afterID, limit int) ([]Finding, error) {
+	return d.queryFindings(`SELECT `+findingColumns+` FROM findings WHERE id > $1 ORDER BY id`, limit, afterID)
+}
+
+// LastFindingID returns the highest finding id, or 0 without findings.
+func (d *DB) LastFindingID() (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	var id int
+	err := d.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM findings`).Scan(&id)
+	return id, err
+}
+
+func (d *DB) queryFindings(query string, limit int, args ...interface{}) ([]Finding, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := d.Query(fmt.Sprintf("%s LIMIT %d", query, limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Finding
+	for rows.Next() {
+		var f Finding
+		var asn int64
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&f.ID, &f.RunID, &f.VPNType, &f.IP, &f.Username, &f.Password, &f.FoundAt,
+			&f.Country, &asn, &f.ASOrg, &lastSeen, &f.SeenCount, &f.Variant); err != nil {
+			return nil, err
+		}
+		f.ASN = uint(asn)
+		f.LastSeen = f.FoundAt
+		if lastSeen.Valid {
+			f.LastSeen = lastSeen.Time
+		}
+		if plain, err := decryptString(f.Password); err == nil {
+			f.Password = plain
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// CredentialCount is how many findings share a username and password.
+type CredentialCount struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Count    int    `json:"count"`
+}
+
+// VendorFindings summarizes the findings of one VPN type.
+type VendorFindings struct {
+	Findings       int               `json:"findings"`
+	TopCredentials []CredentialCount `json:"top_credentials"`
+}
+
+// FindingsByVendor counts the findings first found in [since, until) per
+// VPN type, together with the top credentials found most often.
+func (d *DB) FindingsByVendor(since, until time.Time, top int) (map[string]VendorFindings, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	rows, err := d.Query(`SELECT vpn_type, username, password FROM findings WHERE found_at >= $1 AND found_at < $2`,
+		since.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type cred struct{ vendor, username, password string }
+	counts := make(map[cred]int)
+	totals := make(map[string]int)
+	for rows.Next() {
+		var c cred
+		if err := rows.Scan(&c.vendor, &c.username, &c.password); err != nil {
+			return nil, err
+		}
+		// Passwords are encrypted with a random nonce, so they are
+		// grouped after decryption.
+		if plain, err := decryptString(c.password); err == nil {
+			c.password = plain
+		}
+		counts[c]++
+		totals[c.vendor]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]VendorFindings, len(totals))
+	for vendor, n := range totals {
+		var creds []CredentialCount
+		for c, count := range counts {
+			if c.vendor == vendor {
+				creds = append(creds, CredentialCount{Username: c.username, Password: c.password, Count: count})
+			}
+		}
+		sort.Slice(creds, func(i, j int) bool {
+			if creds[i].Count != creds[j].Count {
+				return creds[i].Count > creds[j].Count
+			}
+			if creds[i].Username != creds[j].Username {
+				return creds[i].Username < creds[j].Username
+			}
+			return creds[i].Password < creds[j].Password
+		})
+		if len(creds) > top {
+			creds = creds[:top]
+		}
+		out[vendor] = VendorFindings{Findings: n, TopCredentials: creds}
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindingsAndRunLogs(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	for _, f := range []Finding{
+		{RunID: "run1", VPNType: "fortinet", IP: "1.1.1.1", Username: "a", Password: "p1"},
+		{RunID: "run2", VPNType: "sonicwall", IP: "2.2.2.2", Username: "b", Password: "p2", Country: "DE", ASN: 3320, ASOrg: "DTAG", Variant: "sma"},
+	} {
+		if _, err := d.InsertFinding(f); err != nil {
+			t.Fatalf("InsertFinding: %v", err)
+		}
+	}
+	got, err := d.ListFindings(FindingFilter{RunID: "run1"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].IP != "1.1.1.1" || got[0].Password != "p1" || got[0].FoundAt.IsZero() {
+		t.Fatalf("ListFindings(run1) = %+v", got)
+	}
+	var stored string
+	if err := d.QueryRow(`SELECT password FROM findings WHERE id=$1`, got[0].ID).Scan(&stored); err != nil || stored == "p1" {
+		t.Fatalf("password not encrypted: %q %v", stored, err)
+	}
+	if all, _ := d.ListFindings(FindingFilter{}, 0); len(all) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(all))
+	}
+
+	de, err := d.ListFindings(FindingFilter{Country: "de", ASN: 3320}, 0)
+	if err != nil || len(de) != 1 || de[0].ASOrg != "DTAG" || de[0].Variant != "sma" {
+		t.Fatalf("ListFindings(DE) = %+v, %v", de, err)
+	}
+	counts, err := d.FindingsByCountry()
+	if err != nil || len(counts) != 2 {
+		t.Fatalf("FindingsByCountry = %+v, %v", counts, err)
+	}
+
+	if err := d.InsertRunLog("run1", "error", "boom", "engine"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertLog("info", "plain", "test"); err != nil {
+		t.Fatal(err)
+	}
+	logs, total, err := d.GetLogsWithFilters(map[string]interface{}{"run_id": "run1"}, 1, 10)
+	if err != nil || total != 1 || len(logs) != 1 || logs[0]["run_id"] != "run1" {
+		t.Fatalf("run logs = %v (total %d, err %v)", logs, total, err)
+	}
+}
+
+func TestFindingsDeduplicated(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	first := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	f := Finding{RunID: "run1", VPNType: "fortinet", IP: "1.1.1.1", Username: "a", Password: "p", FoundAt: first}
+	id1, err := d.InsertFinding(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.RunID, f.FoundAt = "run2", first.Add(time.Hour)
+	id2, err := d.InsertFinding(f)
+	if err != nil || id2 != id1 {
+		t.Fatalf("duplicate got id %d (first %d): %v", id2, id1, err)
+	}
+	f.Password = "other"
+	if id3, _ := d.InsertFinding(f); id3 == id1 {
+		t.Fatal("different password merged")
+	}
+
+	got, _ := d.ListFindings(FindingFilter{RunID: "run1"}, 0)
+	if len(got) != 1 || got[0].SeenCount != 2 || !got[0].FoundAt.Equal(first) || !got[0].LastSeen.Equal(first.Add(time.Hour)) {
+		t.Fatalf("merged finding = %+v", got)
+	}
+
+	// Rows stored before deduplication are merged on the next migration.
+	enc, _ := encryptString("p")
+	for i := 0; i < 2; i++ {
+		if _, err := d.Exec(`INSERT INTO findings(run_id, vpn_type, ip, username, password, found_at) VALUES('old','cisco','2.2.2.2','b',$1,$2)`, enc, first); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := InitSchema(d); err != nil {
+		t.Fatalf("InitSchema: %v", err)
+	}
+	old, _ := d.ListFindings(FindingFilter{RunID: "old"}, 0)
+	if len(old) != 1 || old[0].SeenCount != 2 {
+		t.Fatalf("legacy findings = %+v", old)
+	}
+}
@@ -1,12 +1,27 @@
 package db
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 // InsertLog stores a log entry in the database.
 func (d *DB) InsertLog(level, message, source string) error {
+	return d.InsertRunLog("", level, message, source)
+}
+
+// InsertRunLog stores a log entry attributed to a scan run. An empty runID
+// is stored as NULL.
+func (d *DB) InsertRunLog(runID, level, message, source string) error {
 	if d == nil || d.DB == nil {
 		return nil
 	}
-	_, err := d.Exec(`INSERT INTO logs (timestamp, level, message, source) VALUES ($1,$2,$3,$4)`, time.Now(), level, message, source)
+	_, err := d.Exec(`INSERT INTO logs (timestamp, level, message, source, run_id) VALUES ($1,$2,$3,$4,$5)`,
+		time.Now(), level, message, source, nullString(runID))
 	return err
 }
+
+// nullString maps "" to NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
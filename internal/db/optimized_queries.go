@@ -30,8 +30,10 @@ func (d *DB) QueryWithPagination(query string, page, pageSize int, args ...inter
 	offset := (page - 1) * pageSize
 	paginatedQuery := fmt.Sprintf("%s LIMIT %d OFFSET %d", query, pageSize, offset)
 
-	// Execute paginated query
-	rows, err := d.QueryContext(ctx, paginatedQuery, args...)
+	// Execute paginated query. The timeout context is cancelled when this
+	// function returns, which would close the rows before the caller reads
+	// them, so it only guards the count.
+	rows, err := d.Query(paginatedQuery, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("paginated query error: %w", err)
 	}
@@ -82,7 +84,7 @@ func (d *DB) GetProxiesWithPagination(page, pageSize int) ([]map[string]interfac
 		return nil, 0, fmt.Errorf("database not initialized")
 	}
 
-	query := `SELECT id, address, username, password FROM proxies`
+	query := `SELECT id, address, username, password, COALESCE(source, '') FROM proxies`
 	rows, total, err := d.QueryWithPagination(query, page, pageSize)
 	if err != nil {
 		return nil, 0, err
@@ -92,8 +94,8 @@ func (d *DB) GetProxiesWithPagination(page, pageSize int) ([]map[string]interfac
 	var proxies []map[string]interface{}
 	for rows.Next() {
 		var id int
-		var address, username, password string
-		if err := rows.Scan(&id, &address, &username, &password); err != nil {
+		var address, username, password, source string
+		if err := rows.Scan(&id, &address, &username, &password, &source); err != nil {
 			return nil, 0, err
 		}
 
@@ -107,6 +109,7 @@ func (d *DB) GetProxiesWithPagination(page, pageSize int) ([]map[string]interfac
 			"address":  decryptedAddress,
 			"username": decryptedUsername,
 			"password": decryptedPassword,
+			"source":   source,
 		})
 	}
 
@@ -127,7 +130,7 @@ func (d *DB) GetTasksWithPagination(page, pageSize int) ([]map[string]interface{
 			LEFT JOIN vendor_urls v ON v.id = t.vendor_url_id
 		`
 	} else {
-		query = `SELECT id, vendor, url, login, password, proxy FROM tasks`
+		query = `SELECT id, vendor, url, login, password, proxy, status, progress FROM tasks`
 	}
 
 	rows, total, err := d.QueryWithPagination(query, page, pageSize)
@@ -163,7 +166,9 @@ func (d *DB) GetTasksWithPagination(page, pageSize int) ([]map[string]interface{
 		for rows.Next() {
 			var id int
 			var vendor, url, login, password, proxy sql.NullString
-			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy); err != nil {
+			var status string
+			var progress int
+			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy, &status, &progress); err != nil {
 				continue
 			}
 			tasks = append(tasks, map[string]interface{}{
@@ -173,6 +178,8 @@ func (d *DB) GetTasksWithPagination(page, pageSize int) ([]map[string]interface{
 				"login":    login.String,
 				"password": password.String,
 				"proxy":    proxy.String,
+				"status":   status,
+				"progress": progress,
 			})
 		}
 	}
@@ -186,7 +193,7 @@ func (d *DB) GetLogsWithPagination(page, pageSize int) ([]map[string]interface{}
 		return nil, 0, fmt.Errorf("database not initialized")
 	}
 
-	query := `SELECT timestamp, level, message, source FROM logs ORDER BY timestamp DESC`
+	query := `SELECT timestamp, level, message, source, COALESCE(run_id, '') FROM logs ORDER BY timestamp DESC`
 	rows, total, err := d.QueryWithPagination(query, page, pageSize)
 	if err != nil {
 		return nil, 0, err
@@ -196,8 +203,8 @@ func (d *DB) GetLogsWithPagination(page, pageSize int) ([]map[string]interface{}
 	var logs []map[string]interface{}
 	for rows.Next() {
 		var ts time.Time
-		var level, msg, src string
-		if err := rows.Scan(&ts, &level, &msg, &src); err != nil {
+		var level, msg, src, runID string
+		if err := rows.Scan(&ts, &level, &msg, &src, &runID); err != nil {
 			continue
 		}
 		logs = append(logs, map[string]interface{}{
@@ -205,6 +212,7 @@ func (d *DB) GetLogsWithPagination(page, pageSize int) ([]map[string]interface{}
 			"level":     level,
 			"message":   msg,
 			"source":    src,
+			"run_id":    runID,
 		})
 	}
 
@@ -381,7 +389,7 @@ func (d *DB) GetTasksByStatus(status string, page, pageSize int) ([]map[string]i
 		`
 	} else {
 		query = `
-			SELECT id, vendor, url, login, password, proxy 
+			SELECT id, vendor, url, login, password, proxy, status, progress
 			FROM tasks 
 			WHERE status = $1
 		`
@@ -420,7 +428,9 @@ func (d *DB) GetTasksByStatus(status string, page, pageSize int) ([]map[string]i
 		for rows.Next() {
 			var id int
 			var vendor, url, login, password, proxy sql.NullString
-			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy); err != nil {
+			var status string
+			var progress int
+			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy, &status, &progress); err != nil {
 				continue
 			}
 			tasks = append(tasks, map[string]interface{}{
@@ -430,6 +440,8 @@ func (d *DB) GetTasksByStatus(status string, page, pageSize int) ([]map[string]i
 				"login":    login.String,
 				"password": password.String,
 				"proxy":    proxy.String,
+				"status":   status,
+				"progress": progress,
 			})
 		}
 	}
@@ -444,7 +456,7 @@ func (d *DB) GetLogsByLevel(level string, page, pageSize int) ([]map[string]inte
 	}
 
 	query := `
-		SELECT timestamp, level, message, source 
+		SELECT timestamp, level, message, source, COALESCE(run_id, '') 
 		FROM logs 
 		WHERE level = $1
 		ORDER BY timestamp DESC
@@ -458,8 +470,8 @@ func (d *DB) GetLogsByLevel(level string, page, pageSize int) ([]map[string]inte
 	var logs []map[string]interface{}
 	for rows.Next() {
 		var ts time.Time
-		var level, msg, src string
-		if err := rows.Scan(&ts, &level, &msg, &src); err != nil {
+		var level, msg, src, runID string
+		if err := rows.Scan(&ts, &level, &msg, &src, &runID); err != nil {
 			continue
 		}
 		logs = append(logs, map[string]interface{}{
@@ -467,6 +479,7 @@ func (d *DB) GetLogsByLevel(level string, page, pageSize int) ([]map[string]inte
 			"level":     level,
 			"message":   msg,
 			"source":    src,
+			"run_id":    runID,
 		})
 	}
 
@@ -480,7 +493,7 @@ func (d *DB) GetLogsByTimeRange(start, end time.Time, page, pageSize int) ([]map
 	}
 
 	query := `
-		SELECT timestamp, level, message, source 
+		SELECT timestamp, level, message, source, COALESCE(run_id, '') 
 		FROM logs 
 		WHERE timestamp BETWEEN $1 AND $2
 		ORDER BY timestamp DESC
@@ -494,8 +507,8 @@ func (d *DB) GetLogsByTimeRange(start, end time.Time, page, pageSize int) ([]map
 	var logs []map[string]interface{}
 	for rows.Next() {
 		var ts time.Time
-		var level, msg, src string
-		if err := rows.Scan(&ts, &level, &msg, &src); err != nil {
+		var level, msg, src, runID string
+		if err := rows.Scan(&ts, &level, &msg, &src, &runID); err != nil {
 			continue
 		}
 		logs = append(logs, map[string]interface{}{
@@ -503,6 +516,7 @@ func (d *DB) GetLogsByTimeRange(start, end time.Time, page, pageSize int) ([]map
 			"level":     level,
 			"message":   msg,
 			"source":    src,
+			"run_id":    runID,
 		})
 	}
 
@@ -676,7 +690,7 @@ func (d *DB) GetLogsWithSearch(search string, page, pageSize int) ([]map[string]
 	}
 
 	query := `
-		SELECT timestamp, level, message, source 
+		SELECT timestamp, level, message, source, COALESCE(run_id, '') 
 		FROM logs 
 		WHERE message ILIKE $1 OR source ILIKE $1
 		ORDER BY timestamp DESC
@@ -691,8 +705,8 @@ func (d *DB) GetLogsWithSearch(search string, page, pageSize int) ([]map[string]
 	var logs []map[string]interface{}
 	for rows.Next() {
 		var ts time.Time
-		var level, msg, src string
-		if err := rows.Scan(&ts, &level, &msg, &src); err != nil {
+		var level, msg, src, runID string
+		if err := rows.Scan(&ts, &level, &msg, &src, &runID); err != nil {
 			continue
 		}
 		logs = append(logs, map[string]interface{}{
@@ -700,6 +714,7 @@ func (d *DB) GetLogsWithSearch(search string, page, pageSize int) ([]map[string]
 			"level":     level,
 			"message":   msg,
 			"source":    src,
+			"run_id":    runID,
 		})
 	}
 
@@ -722,7 +737,7 @@ func (d *DB) GetTasksWithSearch(search string, page, pageSize int) ([]map[string
 		`
 	} else {
 		query = `
-			SELECT id, vendor, url, login, password, proxy 
+			SELECT id, vendor, url, login, password, proxy, status, progress
 			FROM tasks 
 			WHERE vendor ILIKE $1 OR url ILIKE $1 OR login ILIKE $1 OR status ILIKE $1
 		`
@@ -762,7 +777,9 @@ func (d *DB) GetTasksWithSearch(search string, page, pageSize int) ([]map[string
 		for rows.Next() {
 			var id int
 			var vendor, url, login, password, proxy sql.NullString
-			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy); err != nil {
+			var status string
+			var progress int
+			if err := rows.Scan(&id, &vendor, &url, &login, &password, &proxy, &status, &progress); err != nil {
 				continue
 			}
 			tasks = append(tasks, map[string]interface{}{
@@ -772,6 +789,8 @@ func (d *DB) GetTasksWithSearch(search string, page, pageSize int) ([]map[string
 				"login":    login.String,
 				"password": password.String,
 				"proxy":    proxy.String,
+				"status":   status,
+				"progress": progress,
 			})
 		}
 	}
@@ -825,7 +844,7 @@ func (d *DB) GetProxiesWithSearch(search string, page, pageSize int) ([]map[stri
 	}
 
 	query := `
-		SELECT id, address, username, password 
+		SELECT id, address, username, password, COALESCE(source, '')
 		FROM proxies 
 		WHERE address ILIKE $1 OR username ILIKE $1
 	`
@@ -839,8 +858,8 @@ func (d *DB) GetProxiesWithSearch(search string, page, pageSize int) ([]map[stri
 	var proxies []map[string]interface{}
 	for rows.Next() {
 		var id int
-		var address, username, password string
-		if err := rows.Scan(&id, &address, &username, &password); err != nil {
+		var address, username, password, source string
+		if err := rows.Scan(&id, &address, &username, &password, &source); err != nil {
 			continue
 		}
 
@@ -854,6 +873,7 @@ func (d *DB) GetProxiesWithSearch(search string, page, pageSize int) ([]map[stri
 			"address":  decryptedAddress,
 			"username": decryptedUsername,
 			"password": decryptedPassword,
+			"source":   source,
 		})
 	}
 
@@ -960,7 +980,7 @@ func (d *DB) GetLogsWithFilters(filters map[string]interface{}, page, pageSize i
 
 	// Build query with filters
 	query := `
-		SELECT timestamp, level, message, source 
+		SELECT timestamp, level, message, source, COALESCE(run_id, '') 
 		FROM logs 
 		WHERE 1=1
 	`
@@ -989,8 +1009,8 @@ func (d *DB) GetLogsWithFilters(filters map[string]interface{}, page, pageSize i
 	var logs []map[string]interface{}
 	for rows.Next() {
 		var ts time.Time
-		var level, msg, src string
-		if err := rows.Scan(&ts, &level, &msg, &src); err != nil {
+		var level, msg, src, runID string
+		if err := rows.Scan(&ts, &level, &msg, &src, &runID); err != nil {
 			continue
 		}
 		logs = append(logs, map[string]interface{}{
@@ -998,6 +1018,7 @@ func (d *DB) GetLogsWithFilters(filters map[string]interface{}, page, pageSize i
 			"level":     level,
 			"message":   msg,
 			"source":    src,
+			"run_id":    runID,
 		})
 	}
 
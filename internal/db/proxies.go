@@ -0,0 +1,172 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ProxySource is a remote proxy list from the proxy_sources table.
+type ProxySource struct {
+	ID          int        `json:"id"`
+	URL         string     `json:"url"`
+	LastFetched *time.Time `json:"last_fetched,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastCount   int        `json:"last_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// proxyFingerprint identifies a proxy by address and user so that imported
+// lists do not duplicate proxies already stored.
+func proxyFingerprint(p Proxy) string {
+	return fingerprint(p.Address, p.Username)
+}
+
+// fingerprintProxies sets the fingerprint of proxies stored before list
+// imports.
+func (d *DB) fingerprintProxies() error {
+	rows, err := d.Query(`SELECT id, address, COALESCE(username, '') FROM proxies WHERE fingerprint IS NULL`)
+	if err != nil {
+		return err
+	}
+	fps := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var p Proxy
+		if err := rows.Scan(&id, &p.Address, &p.Username); err != nil {
+			rows.Close()
+			return err
+		}
+		p.Address, _ = decryptString(p.Address)
+		p.Username, _ = decryptString(p.Username)
+		fps[id] = proxyFingerprint(p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, fp := range fps {
+		if _, err := d.Exec(`UPDATE proxies SET fingerprint = $1 WHERE id = $2`, fp, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateProxy replaces the proxy with id, keeping its source.
+func (d *DB) UpdateProxy(id int, p Proxy) error {
+	encAddr, err := encryptString(p.Address)
+	if err != nil {
+		return err
+	}
+	encU, err := encryptString(p.Username)
+	if err != nil {
+		return err
+	}
+	encP, err := encryptString(p.Password)
+	if err != nil {
+		return err
+	}
+	_, err = d.Exec(`UPDATE proxies SET address=$1, username=$2, password=$3, fingerprint=$4 WHERE id=$5`,
+		encAddr, encU, encP, proxyFingerprint(p), id)
+	return err
+}
+
+// MergeProxies stores the proxies not yet in the table, attributed to
+// source, and returns how many were added. Proxies already stored keep
+// their original source.
+func (d *DB) MergeProxies(source string, proxies []Proxy) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	added := 0
+	for _, p := range proxies {
+		var id int
+		err := d.QueryRow(`SELECT id FROM proxies WHERE fingerprint = $1`, proxyFingerprint(p)).Scan(&id)
+		if err == nil {
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return added, err
+		}
+		p.Source = source
+		if _, err := d.InsertProxy(p); err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// EnsureProxySource stores url unless it is already a source and returns
+// the source id.
+func (d *DB) EnsureProxySource(url string) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	if url == "" {
+		return 0, fmt.Errorf("proxy source url is required")
+	}
+	if _, err := d.Exec(`INSERT INTO proxy_sources(url) VALUES($1) ON CONFLICT(url) DO NOTHING`, url); err != nil {
+		return 0, err
+	}
+	var id int
+	err := d.QueryRow(`SELECT id FROM proxy_sources WHERE url = $1`, url).Scan(&id)
+	return id, err
+}
+
+// ListProxySources returns all proxy sources in insertion order.
+func (d *DB) ListProxySources() ([]ProxySource, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	rows, err := d.Query(`SELECT id, url, last_fetched, COALESCE(last_error, ''), last_count, created_at FROM proxy_sources ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ProxySource
+	for rows.Next() {
+		var s ProxySource
+		var fetched sql.NullTime
+		if err := rows.Scan(&s.ID, &s.URL, &fetched, &s.LastError, &s.LastCount, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if fetched.Valid {
+			t := fetched.Time
+			s.LastFetched = &t
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// RecordProxyFetch stores the outcome of fetching source id: the number of
+// valid proxies in the list, or the error.
+func (d *DB) RecordProxyFetch(id int, at time.Time, count int, fetchErr error) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	var msg string
+	if fetchErr != nil {
+		msg = fetchErr.Error()
+	}
+	_, err := d.Exec(`UPDATE proxy_sources SET last_fetched=$1, last_error=$2, last_count=$3 WHERE id=$4`,
+		at.UTC(), nullString(msg), count, id)
+	return err
+}
+
+// DeleteProxySource removes the source with id. Proxies imported from it
+// are removed too unless keepProxies is set.
+func (d *DB) DeleteProxySource(id int, keepProxies bool) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if !keepProxies {
+		if _, err := d.Exec(`DELETE FROM proxies WHERE source = (SELECT url FROM proxy_sources WHERE id=$1)`, id); err != nil {
+			return err
+		}
+	}
+	_, err := d.Exec(`DELETE FROM proxy_sources WHERE id=$1`, id)
+	return err
+}
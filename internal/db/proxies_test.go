@@ -0,0 +1,65 @@
+package db
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMergeProxies(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	if _, err := d.InsertProxy(Proxy{Address: "1.1.1.1:80"}); err != nil {
+		t.Fatal(err)
+	}
+	const src = "https://lists.example/proxies.txt"
+	id, err := d.EnsureProxySource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := d.EnsureProxySource(src); err != nil || again != id {
+		t.Fatalf("EnsureProxySource again = %d, %v; want %d", again, err, id)
+	}
+
+	list := []Proxy{{Address: "1.1.1.1:80"}, {Address: "2.2.2.2:80", Username: "u", Password: "p"}}
+	added, err := d.MergeProxies(src, list)
+	if err != nil || added != 1 {
+		t.Fatalf("MergeProxies = %d, %v; want 1", added, err)
+	}
+	if added, _ := d.MergeProxies(src, list); added != 0 {
+		t.Fatalf("second merge added %d", added)
+	}
+	proxies, total, err := d.GetProxiesWithPagination(1, 10)
+	if err != nil || total != 2 {
+		t.Fatalf("GetProxiesWithPagination = %v, %d, %v", proxies, total, err)
+	}
+	for _, p := range proxies {
+		want := ""
+		if p["address"] == "2.2.2.2:80" {
+			want = src
+		}
+		if p["source"] != want {
+			t.Errorf("proxy %v source = %q, want %q", p["address"], p["source"], want)
+		}
+	}
+
+	if err := d.RecordProxyFetch(id, time.Now(), 2, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := d.ListProxySources()
+	if err != nil || len(sources) != 1 || sources[0].LastFetched == nil || sources[0].LastError != "boom" || sources[0].LastCount != 2 {
+		t.Fatalf("ListProxySources = %+v, %v", sources, err)
+	}
+
+	if err := d.DeleteProxySource(id, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := d.GetProxiesWithPagination(1, 10); total != 1 {
+		t.Fatalf("%d proxies left, want the manual one", total)
+	}
+}
@@ -0,0 +1,68 @@
+package db
+
+import "fmt"
+
+// Worker is a remote SSH worker as stored in the workers table.
+type Worker struct {
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Proxy is an outbound proxy as stored (encrypted) in the proxies table.
+// Source is the list URL a proxy was imported from; empty for proxies
+// added by hand.
+type Proxy struct {
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Source   string `json:"source,omitempty"`
+}
+
+// InsertWorker stores w and returns its id. A zero port defaults to 22.
+func (d *DB) InsertWorker(w Worker) (int, error) {
+	if w.IP == "" || w.Username == "" {
+		return 0, fmt.Errorf("worker ip and username are required")
+	}
+	if w.Port == 0 {
+		w.Port = 22
+	}
+	var id int
+	err := d.QueryRow(`INSERT INTO workers(ip, port, username, password) VALUES($1,$2,$3,$4) RETURNING id`,
+		w.IP, w.Port, w.Username, w.Password).Scan(&id)
+	return id, err
+}
+
+// InsertProxy encrypts and stores p and returns its id.
+func (d *DB) InsertProxy(p Proxy) (int, error) {
+	if p.Address == "" {
+		return 0, fmt.Errorf("proxy address is required")
+	}
+	encAddr, err := encryptString(p.Address)
+	if err != nil {
+		return 0, err
+	}
+	encU, err := encryptString(p.Username)
+	if err != nil {
+		return 0, err
+	}
+	encP, err := encryptString(p.Password)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = d.QueryRow(`INSERT INTO proxies(address, username, password, source, fingerprint) VALUES($1,$2,$3,$4,$5) RETURNING id`,
+		encAddr, encU, encP, nullString(p.Source), proxyFingerprint(p)).Scan(&id)
+	return id, err
+}
+
+// InsertVendorURL stores url and returns its id.
+func (d *DB) InsertVendorURL(url string) (int, error) {
+	if url == "" {
+		return 0, fmt.Errorf("vendor url is required")
+	}
+	var id int
+	err := d.QueryRow(`INSERT INTO vendor_urls(url) VALUES($1) RETURNING id`, url).Scan(&id)
+	return id, err
+}
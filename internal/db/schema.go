@@ -1,5 +1,7 @@
 package db
 
+import "strings"
+
 // This file contains database schema initialization logic.
 
 // InitSchema exposes the schema initialization logic for external callers.
@@ -34,7 +36,9 @@ func initSchema(d *DB) error {
                         id SERIAL PRIMARY KEY,
                         address TEXT NOT NULL,
                         username TEXT,
-                        password TEXT
+                        password TEXT,
+                        source TEXT,
+                        fingerprint TEXT
                 )`,
 		`CREATE TABLE IF NOT EXISTS scheduled_tasks (
                         id SERIAL PRIMARY KEY,
@@ -56,28 +60,95 @@ func initSchema(d *DB) error {
                         login TEXT,
                         password TEXT,
                         proxy TEXT,
-                        vendor_url_id INT REFERENCES vendor_urls(id)
+                        vendor_url_id INT REFERENCES vendor_urls(id),
+                        status TEXT NOT NULL DEFAULT 'pending',
+                        progress INTEGER NOT NULL DEFAULT 0,
+                        error TEXT,
+                        updated_at TIMESTAMPTZ,
+                        worker TEXT,
+                        lease_until TIMESTAMPTZ,
+                        attempts INTEGER NOT NULL DEFAULT 0,
+                        result TEXT
                 )`,
 		`CREATE TABLE IF NOT EXISTS logs (
                         id SERIAL PRIMARY KEY,
                         timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
                         level TEXT,
                         message TEXT,
-                        source TEXT
+                        source TEXT,
+                        run_id TEXT
+                )`,
+		`CREATE TABLE IF NOT EXISTS findings (
+                        id SERIAL PRIMARY KEY,
+                        run_id TEXT,
+                        vpn_type TEXT NOT NULL,
+                        ip TEXT NOT NULL,
+                        username TEXT NOT NULL,
+                        password TEXT NOT NULL,
+                        found_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        country TEXT,
+                        asn BIGINT,
+                        as_org TEXT,
+                        fingerprint TEXT,
+                        last_seen TIMESTAMPTZ,
+                        seen_count INTEGER NOT NULL DEFAULT 1,
+                        variant TEXT
+                )`,
+		`CREATE INDEX IF NOT EXISTS idx_findings_run_id ON findings(run_id)`,
+		`CREATE TABLE IF NOT EXISTS exclusions (
+                        id SERIAL PRIMARY KEY,
+                        kind TEXT NOT NULL,
+                        value TEXT NOT NULL,
+                        note TEXT,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        UNIQUE(kind, value)
+                )`,
+		`CREATE TABLE IF NOT EXISTS proxy_sources (
+                        id SERIAL PRIMARY KEY,
+                        url TEXT NOT NULL UNIQUE,
+                        last_fetched TIMESTAMPTZ,
+                        last_error TEXT,
+                        last_count INTEGER NOT NULL DEFAULT 0,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+                )`,
+		`CREATE TABLE IF NOT EXISTS activity (
+                        id SERIAL PRIMARY KEY,
+                        at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                        kind TEXT NOT NULL,
+                        level TEXT NOT NULL,
+                        message TEXT NOT NULL,
+                        ref INTEGER NOT NULL DEFAULT 0
+                )`,
+		`CREATE INDEX IF NOT EXISTS idx_activity_kind_at ON activity(kind, at)`,
+		`CREATE TABLE IF NOT EXISTS credential_stats (
+                        id SERIAL PRIMARY KEY,
+                        fingerprint TEXT NOT NULL UNIQUE,
+                        username TEXT NOT NULL,
+                        password TEXT NOT NULL,
+                        attempts INTEGER NOT NULL DEFAULT 0,
+                        hits INTEGER NOT NULL DEFAULT 0,
+                        last_hit TIMESTAMPTZ,
+                        updated_at TIMESTAMPTZ
+                )`,
+		`CREATE TABLE IF NOT EXISTS targets (
+                        id SERIAL PRIMARY KEY,
+                        host TEXT NOT NULL UNIQUE,
+                        vendor TEXT,
+                        customer TEXT,
+                        region TEXT,
+                        source TEXT,
+                        source_ref TEXT,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
                 )`,
 	}
 	for _, q := range queries {
-		if _, err := d.Exec(q); err != nil {
+		if _, err := d.Exec(d.ddl(q)); err != nil {
 			return err
 		}
 	}
 
 	// ensure the vendor_url_id column exists in tasks table
-	var exists bool
-	err := d.QueryRow(`SELECT EXISTS (
-               SELECT 1 FROM information_schema.columns
-               WHERE table_name='tasks' AND column_name='vendor_url_id'
-       )`).Scan(&exists)
+	exists, err := d.columnExists("tasks", "vendor_url_id")
 	if err != nil {
 		return err
 	}
@@ -86,5 +157,135 @@ func initSchema(d *DB) error {
 			return err
 		}
 	}
-	return nil
+
+	// tasks created before the state machine and claiming lack their
+	// columns or hold a free-text status
+	for _, col := range []struct{ name, typ string }{
+		{"status", "TEXT NOT NULL DEFAULT 'pending'"},
+		{"progress", "INTEGER NOT NULL DEFAULT 0"},
+		{"error", "TEXT"},
+		{"updated_at", "TIMESTAMPTZ"},
+		{"worker", "TEXT"},
+		{"lease_until", "TIMESTAMPTZ"},
+		{"attempts", "INTEGER NOT NULL DEFAULT 0"},
+		{"result", "TEXT"},
+	} {
+		exists, err = d.columnExists("tasks", col.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := d.Exec(d.ddl(`ALTER TABLE tasks ADD COLUMN ` + col.name + ` ` + col.typ)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := d.normalizeTaskStates(); err != nil {
+		return err
+	}
+	if _, err := d.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_updated_at ON tasks(updated_at)`); err != nil {
+		return err
+	}
+	if _, err := d.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status)`); err != nil {
+		return err
+	}
+
+	// logs created before run ids were introduced lack run_id
+	exists, err = d.columnExists("logs", "run_id")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if _, err := d.Exec(`ALTER TABLE logs ADD COLUMN run_id TEXT`); err != nil {
+			return err
+		}
+	}
+
+	// findings recorded before GeoIP enrichment, deduplication and portal
+	// variants lack these columns
+	for _, col := range []struct{ name, typ string }{
+		{"country", "TEXT"},
+		{"asn", "BIGINT"},
+		{"as_org", "TEXT"},
+		{"fingerprint", "TEXT"},
+		{"last_seen", "TIMESTAMPTZ"},
+		{"seen_count", "INTEGER NOT NULL DEFAULT 1"},
+		{"variant", "TEXT"},
+	} {
+		exists, err = d.columnExists("findings", col.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := d.Exec(d.ddl(`ALTER TABLE findings ADD COLUMN ` + col.name + ` ` + col.typ)); err != nil {
+				return err
+			}
+		}
+	}
+	// proxies added before list imports lack their source and fingerprint
+	for _, col := range []string{"source", "fingerprint"} {
+		exists, err = d.columnExists("proxies", col)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := d.Exec(`ALTER TABLE proxies ADD COLUMN ` + col + ` TEXT`); err != nil {
+				return err
+			}
+		}
+	}
+	// targets imported before search engine sourcing lack their provenance
+	for _, col := range []string{"source", "source_ref"} {
+		exists, err = d.columnExists("targets", col)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := d.Exec(`ALTER TABLE targets ADD COLUMN ` + col + ` TEXT`); err != nil {
+				return err
+			}
+		}
+	}
+	if err := d.fingerprintProxies(); err != nil {
+		return err
+	}
+	if _, err := d.Exec(`CREATE INDEX IF NOT EXISTS idx_proxies_fingerprint ON proxies(fingerprint)`); err != nil {
+		return err
+	}
+
+	if err := d.mergeDuplicateFindings(); err != nil {
+		return err
+	}
+	_, err = d.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_findings_fingerprint ON findings(fingerprint)`)
+	return err
+}
+
+// sqliteDDL rewrites the Postgres specific parts of the schema.
+var sqliteDDL = strings.NewReplacer(
+	"SERIAL PRIMARY KEY", "INTEGER PRIMARY KEY AUTOINCREMENT",
+	"DEFAULT NOW()", "DEFAULT CURRENT_TIMESTAMP",
+	// go-sqlite3 only converts columns declared exactly TIMESTAMP to time.Time.
+	"TIMESTAMPTZ", "TIMESTAMP",
+)
+
+// ddl adapts a Postgres schema statement to the backend of d.
+func (d *DB) ddl(q string) string {
+	if d.IsSQLite() {
+		return sqliteDDL.Replace(q)
+	}
+	return q
+}
+
+// columnExists reports whether table has the named column.
+func (d *DB) columnExists(table, column string) (bool, error) {
+	var exists bool
+	if d.IsSQLite() {
+		err := d.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info($1) WHERE name = $2`, table, column).Scan(&exists)
+		return exists, err
+	}
+	err := d.QueryRow(`SELECT EXISTS (
+               SELECT 1 FROM information_schema.columns
+               WHERE table_name=$1 AND column_name=$2
+       )`, table, column).Scan(&exists)
+	return exists, err
 }
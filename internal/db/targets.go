@@ -0,0 +1,220 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Target is a host of the targets table, managed independently of the
+// credentials tried against it. Vendor, Customer and Region are tags used
+// to select targets when generating tasks. Source tells where the target
+// was imported from (api, scan, shodan or censys) and SourceRef the query
+// or file it came from.
+type Target struct {
+	ID        int       `json:"id"`
+	Host      string    `json:"host"`
+	Vendor    string    `json:"vendor,omitempty"`
+	Customer  string    `json:"customer,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	SourceRef string    `json:"source_ref,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TargetFilter selects targets by tag; empty fields match any value.
+type TargetFilter struct {
+	Vendor   string `json:"vendor"`
+	Customer string `json:"customer"`
+	Region   string `json:"region"`
+}
+
+// where returns the SQL condition of f and its arguments, numbered from 1.
+func (f TargetFilter) where() (string, []interface{}) {
+	conds := []string{"1=1"}
+	var args []interface{}
+	for _, c := range []struct{ col, val string }{{"vendor", f.Vendor}, {"customer", f.Customer}, {"region", f.Region}} {
+		if c.val != "" {
+			args = append(args, c.val)
+			conds = append(conds, fmt.Sprintf("%s = $%d", c.col, len(args)))
+		}
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// NormalizeTargetHost returns the form of host used to deduplicate targets.
+func NormalizeTargetHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "/"))
+}
+
+// ImportTargets stores hosts with the tags and provenance of tags,
+// skipping hosts already in the table, and returns how many were added.
+func (d *DB) ImportTargets(hosts []string, tags Target) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	added := 0
+	for _, h := range hosts {
+		if h = NormalizeTargetHost(h); h == "" {
+			continue
+		}
+		res, err := tx.Exec(`INSERT INTO targets(host, vendor, customer, region, source, source_ref) VALUES($1,$2,$3,$4,$5,$6)
+			ON CONFLICT(host) DO NOTHING`, h, nullString(tags.Vendor), nullString(tags.Customer), nullString(tags.Region),
+			nullString(tags.Source), nullString(tags.SourceRef))
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, tx.Commit()
+}
+
+// ListTargets returns page (1-based) of the targets matching f in
+// insertion order and the number of matching targets.
+func (d *DB) ListTargets(f TargetFilter, page, pageSize int) ([]Target, int, error) {
+	if d == nil || d.DB == nil {
+		return nil, 0, fmt.Errorf("database not initialized")
+	}
+	if page < 1 {
+		page = 1
+	}
+	where, args := f.where()
+	var total int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM targets WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	list, err := d.targets(fmt.Sprintf(`%s ORDER BY id LIMIT %d OFFSET %d`, where, pageSize, (page-1)*pageSize), args...)
+	return list, total, err
+}
+
+// targets returns the targets matching the condition where.
+func (d *DB) targets(where string, args ...interface{}) ([]Target, error) {
+	rows, err := d.Query(`SELECT id, host, COALESCE(vendor, ''), COALESCE(customer, ''), COALESCE(region, ''),
+		COALESCE(source, ''), COALESCE(source_ref, ''), created_at FROM targets WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Target{}
+	for rows.Next() {
+		var t Target
+		if err := rows.Scan(&t.ID, &t.Host, &t.Vendor, &t.Customer, &t.Region, &t.Source, &t.SourceRef, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// TagTargets sets the non-empty tags of tags on the targets with ids and
+// returns how many were updated.
+func (d *DB) TagTargets(ids []int, tags Target) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	var sets []string
+	var args []interface{}
+	for _, c := range []struct{ col, val string }{{"vendor", tags.Vendor}, {"customer", tags.Customer}, {"region", tags.Region}} {
+		if c.val != "" {
+			args = append(args, c.val)
+			sets = append(sets, fmt.Sprintf("%s = $%d", c.col, len(args)))
+		}
+	}
+	if len(sets) == 0 || len(ids) == 0 {
+		return 0, nil
+	}
+	marks := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		marks[i] = fmt.Sprintf("$%d", len(args))
+	}
+	res, err := d.Exec(`UPDATE targets SET `+strings.Join(sets, ", ")+` WHERE id IN (`+strings.Join(marks, ",")+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// DeleteTarget removes the target with id.
+func (d *DB) DeleteTarget(id int) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	_, err := d.Exec(`DELETE FROM targets WHERE id=$1`, id)
+	return err
+}
+
+// TaskCredential is a login tried against every selected target.
+type TaskCredential struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// CreateTargetTasks queues a pending task for every pair of a target
+// matching f and a credential of creds, with the target's vendor, and
+// returns how many were created. Targets without a vendor tag use
+// f.Vendor; the call fails if that is empty too.
+func (d *DB) CreateTargetTasks(f TargetFilter, creds []TaskCredential, proxy string) (int, error) {
+	if d == nil || d.DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	if len(creds) == 0 {
+		return 0, fmt.Errorf("no credentials")
+	}
+	where, args := f.where()
+	targets, err := d.targets(where+` ORDER BY id`, args...)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	created := 0
+	for _, t := range targets {
+		vendor := t.Vendor
+		if vendor == "" {
+			vendor = f.Vendor
+		}
+		if vendor == "" {
+			return 0, fmt.Errorf("target %s has no vendor", t.Host)
+		}
+		for _, c := range creds {
+			if _, err := tx.Exec(`INSERT INTO tasks(vendor, url, login, password, proxy, status, updated_at) VALUES($1,$2,$3,$4,$5,$6,$7)`,
+				vendor, t.Host, c.Login, c.Password, nullString(proxy), TaskPending, now); err != nil {
+				return 0, err
+			}
+			created++
+		}
+	}
+	return created, tx.Commit()
+}
+
+// EnsureVendorURL stores url in the vendor_urls table unless it is there
+// already and reports whether it was added.
+func (d *DB) EnsureVendorURL(url string) (bool, error) {
+	if d == nil || d.DB == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+	var id int
+	err := d.QueryRow(`SELECT id FROM vendor_urls WHERE url = $1`, url).Scan(&id)
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+	_, err = d.Exec(`INSERT INTO vendor_urls(url) VALUES($1)`, url)
+	return err == nil, err
+}
@@ -0,0 +1,360 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskProxy is a task of the legacy tasks schema that pins a proxy. A task
+// without a URL sets the default proxy of its vendor.
+type TaskProxy struct {
+	ID     int    `json:"id"`
+	Vendor string `json:"vendor"`
+	URL    string `json:"url"`
+	Proxy  string `json:"proxy"`
+}
+
+// TaskProxies returns the tasks with a proxy. Tasks tables without the
+// proxy column have none.
+func (d *DB) TaskProxies() ([]TaskProxy, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if ok, err := d.columnExists("tasks", "proxy"); err != nil || !ok {
+		return nil, err
+	}
+	rows, err := d.Query(`SELECT id, COALESCE(vendor, ''), COALESCE(url, ''), proxy FROM tasks
+		WHERE proxy IS NOT NULL AND proxy <> '' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TaskProxy
+	for rows.Next() {
+		var t TaskProxy
+		if err := rows.Scan(&t.ID, &t.Vendor, &t.URL, &t.Proxy); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Task states. A task is created pending, assigned to a worker, run and
+// ends done, failed or cancelled; failed and cancelled tasks may be queued
+// again.
+const (
+	TaskPending   = "pending"
+	TaskAssigned  = "assigned"
+	TaskRunning   = "running"
+	TaskDone      = "done"
+	TaskFailed    = "failed"
+	TaskCancelled = "cancelled"
+)
+
+// taskTransitions lists the states each state may move to. A worker may
+// start a pending task without assigning it first, and requeues the tasks
+// it holds when it stops.
+var taskTransitions = map[string][]string{
+	TaskPending:   {TaskAssigned, TaskRunning, TaskCancelled},
+	TaskAssigned:  {TaskRunning, TaskPending, TaskFailed, TaskCancelled},
+	TaskRunning:   {TaskDone, TaskFailed, TaskCancelled, TaskPending},
+	TaskFailed:    {TaskPending},
+	TaskCancelled: {TaskPending},
+	TaskDone:      nil,
+}
+
+// ValidTaskState reports whether state is a task state.
+func ValidTaskState(state string) bool {
+	_, ok := taskTransitions[state]
+	return ok
+}
+
+// ValidTaskTransition reports whether a task may move from one state to
+// another. Staying in the same state is allowed.
+func ValidTaskTransition(from, to string) bool {
+	if from == to {
+		return ValidTaskState(to)
+	}
+	for _, s := range taskTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyTaskStates maps free-text statuses written before the state
+// machine to states; anything else becomes pending.
+var legacyTaskStates = map[string]string{
+	"completed":   TaskDone,
+	"finished":    TaskDone,
+	"success":     TaskDone,
+	"error":       TaskFailed,
+	"canceled":    TaskCancelled,
+	"stopped":     TaskCancelled,
+	"in_progress": TaskRunning,
+	"started":     TaskRunning,
+}
+
+// normalizeTaskStates rewrites free-text statuses as task states.
+func (d *DB) normalizeTaskStates() error {
+	for from, to := range legacyTaskStates {
+		if _, err := d.Exec(`UPDATE tasks SET status = $1 WHERE LOWER(status) = $2`, to, from); err != nil {
+			return err
+		}
+	}
+	_, err := d.Exec(`UPDATE tasks SET status = $1 WHERE status IS NULL OR status NOT IN ($1,$2,$3,$4,$5,$6)`,
+		TaskPending, TaskAssigned, TaskRunning, TaskDone, TaskFailed, TaskCancelled)
+	return err
+}
+
+// TaskState is the state and progress of a task.
+type TaskState struct {
+	ID        int       `json:"id"`
+	Status    string    `json:"status"`
+	Progress  int       `json:"progress"`
+	Error     string    `json:"error,omitempty"`
+	Result    string    `json:"result,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskTransitionError is returned for a state change the state machine
+// does not allow.
+type TaskTransitionError struct {
+	ID       int
+	From, To string
+}
+
+func (e *TaskTransitionError) Error() string {
+	return fmt.Sprintf("task %d cannot move from %s to %s", e.ID, e.From, e.To)
+}
+
+// SetTaskStatus moves task id to state to, recording errMsg for failed
+// tasks. It returns a *TaskTransitionError when the move is not allowed
+// and sql.ErrNoRows when the task does not exist. Done tasks report full
+// progress.
+func (d *DB) SetTaskStatus(id int, to, errMsg string) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if !ValidTaskState(to) {
+		return fmt.Errorf("unknown task state %q", to)
+	}
+	var from string
+	if err := d.QueryRow(`SELECT status FROM tasks WHERE id = $1`, id).Scan(&from); err != nil {
+		return err
+	}
+	if !ValidTaskTransition(from, to) {
+		return &TaskTransitionError{ID: id, From: from, To: to}
+	}
+	query := `UPDATE tasks SET status = $1, error = $2, updated_at = $3 WHERE id = $4 AND status = $5`
+	if to == TaskDone {
+		query = `UPDATE tasks SET status = $1, error = $2, updated_at = $3, progress = 100 WHERE id = $4 AND status = $5`
+	}
+	res, err := d.Exec(query, to, nullString(errMsg), time.Now().UTC(), id, from)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// Another writer moved the task in between.
+		return &TaskTransitionError{ID: id, From: from, To: to}
+	}
+	d.recordTaskActivity(id, to, errMsg)
+	return nil
+}
+
+// SetTaskProgress records the progress (0-100) of a running task.
+func (d *DB) SetTaskProgress(id, progress int) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if progress < 0 || progress > 100 {
+		return fmt.Errorf("task progress %d out of range", progress)
+	}
+	res, err := d.Exec(`UPDATE tasks SET progress = $1, updated_at = $2 WHERE id = $3 AND status = $4`,
+		progress, time.Now().UTC(), id, TaskRunning)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("task %d is not running", id)
+	}
+	return nil
+}
+
+// TaskStatesSince returns the tasks changed at or after since, oldest
+// change first.
+func (d *DB) TaskStatesSince(since time.Time) ([]TaskState, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	rows, err := d.Query(`SELECT id, status, progress, COALESCE(error, ''), COALESCE(result, ''), updated_at FROM tasks
+		WHERE updated_at >= $1 ORDER BY updated_at, id`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TaskState
+	for rows.Next() {
+		var t TaskState
+		if err := rows.Scan(&t.ID, &t.Status, &t.Progress, &t.Error, &t.Result, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Task is a task of the legacy tasks schema: one credential to check
+// against a VPN endpoint.
+type Task struct {
+	ID       int    `json:"id"`
+	Vendor   string `json:"vendor"`
+	URL      string `json:"url"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Proxy    string `json:"proxy"`
+	Attempts int    `json:"attempts"`
+	// Result is the outcome of a done task, e.g. good or bad.
+	Result string `json:"result,omitempty"`
+}
+
+// ClaimTask assigns the oldest pending task (of vendor, if set) to worker
+// for lease and returns it, or nil when no task is pending. Concurrent
+// workers never claim the same task: Postgres skips rows locked by other
+// claims and SQLite serializes the update.
+func (d *DB) ClaimTask(worker, vendor string, lease time.Duration) (*Task, error) {
+	if d == nil || d.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	lock := ` FOR UPDATE SKIP LOCKED`
+	if d.IsSQLite() {
+		lock = ``
+	}
+	now := time.Now().UTC()
+	var t Task
+	err := d.QueryRow(`UPDATE tasks SET status = $1, worker = $2, lease_until = $3, updated_at = $4, attempts = attempts + 1
+		WHERE status = $5 AND id = (SELECT id FROM tasks WHERE status = $5 AND ($6 = '' OR LOWER(vendor) = $6)
+			ORDER BY id LIMIT 1`+lock+`)
+		RETURNING id, COALESCE(vendor, ''), COALESCE(url, ''), COALESCE(login, ''), COALESCE(password, ''), COALESCE(proxy, ''), attempts`,
+		TaskAssigned, worker, now.Add(lease), now, TaskPending, strings.ToLower(vendor)).
+		Scan(&t.ID, &t.Vendor, &t.URL, &t.Login, &t.Password, &t.Proxy, &t.Attempts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.recordTaskActivity(t.ID, TaskAssigned, "claimed by "+worker)
+	return &t, nil
+}
+
+// errNotClaimed is returned when a worker updates a task it no longer
+// holds, typically because its lease expired and the task was reclaimed.
+var errNotClaimed = fmt.Errorf("task is not claimed by this worker")
+
+// RenewTaskLease extends worker's lease on task id.
+func (d *DB) RenewTaskLease(id int, worker string, lease time.Duration) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	res, err := d.Exec(`UPDATE tasks SET lease_until = $1 WHERE id = $2 AND worker = $3 AND status IN ($4, $5)`,
+		time.Now().UTC().Add(lease), id, worker, TaskAssigned, TaskRunning)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("task %d: %w", id, errNotClaimed)
+	}
+	return nil
+}
+
+// SetClaimedTaskStatus is SetTaskStatus for a task claimed by worker; it
+// fails when worker no longer holds the task. Final states release the
+// claim.
+func (d *DB) SetClaimedTaskStatus(id int, worker, to, errMsg string) error {
+	if d == nil || d.DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	var holder sql.NullString
+	if err := d.QueryRow(`SELECT worker FROM tasks WHERE id = $1`, id).Scan(&holder); err != nil {
+		return err
+	}
+	if holder.String != worker {
+		return fmt.Errorf("task %d: %w", id, errNotClaimed)
+	}
+	if err := d.SetTaskStatus(id, to, errMsg); err != nil {
+		return err
+	}
+	if to == TaskRunning || to == TaskAssigned {
+		return nil
+	}
+	_, err := d.Exec(`UPDATE tasks SET worker = NULL, lease_until = NULL WHERE id = $1`, id)
+	return err
+}
+
+// ReclaimExpiredTasks returns the assigned and running tasks whose lease
+// expired to pending so that another worker can claim them. Tasks that
+// already had maxAttempts claims fail instead (0 means no limit). It
+// returns the number of tasks reclaimed and failed.
+func (d *DB) ReclaimExpiredTasks(maxAttempts int) (reclaimed, failed int, err error) {
+	if d == nil || d.DB == nil {
+		return 0, 0, fmt.Errorf("database not initialized")
+	}
+	now := time.Now().UTC()
+	if maxAttempts > 0 {
+		ids, err := d.updateTaskIDs(`UPDATE tasks SET status = $1, error = $2, worker = NULL, lease_until = NULL, updated_at = $3
+			WHERE status IN ($4, $5) AND lease_until < $3 AND attempts >= $6 RETURNING id`,
+			TaskFailed, "lease expired", now, TaskAssigned, TaskRunning, maxAttempts)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, id := range ids {
+			d.recordTaskActivity(id, TaskFailed, "lease expired")
+		}
+		failed = len(ids)
+	}
+	ids, err := d.updateTaskIDs(`UPDATE tasks SET status = $1, worker = NULL, lease_until = NULL, progress = 0, updated_at = $2
+		WHERE status IN ($3, $4) AND lease_until < $2 RETURNING id`,
+		TaskPending, now, TaskAssigned, TaskRunning)
+	if err != nil {
+		return 0, failed, err
+	}
+	for _, id := range ids {
+		d.recordTaskActivity(id, TaskPending, "lease expired")
+	}
+	return len(ids), failed, nil
+}
+
+// updateTaskIDs runs an UPDATE ... RETURNING id and returns the ids.
+func (d *DB) updateTaskIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CompleteClaimedTask ends task id held by worker: done with result, or
+// failed with taskErr when it is not nil.
+func (d *DB) CompleteClaimedTask(id int, worker, result string, taskErr error) error {
+	if taskErr != nil {
+		return d.SetClaimedTaskStatus(id, worker, TaskFailed, taskErr.Error())
+	}
+	if _, err := d.Exec(`UPDATE tasks SET result = $1 WHERE id = $2 AND worker = $3`, nullString(result), id, worker); err != nil {
+		return err
+	}
+	return d.SetClaimedTaskStatus(id, worker, TaskDone, "")
+}
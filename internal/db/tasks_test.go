@@ -0,0 +1,193 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTaskProxies(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	for _, q := range []string{
+		`INSERT INTO tasks(vendor, url, proxy) VALUES('fortinet', 'https://vpn.example', 'socks5://10.0.0.1:1080')`,
+		`INSERT INTO tasks(vendor, url) VALUES('fortinet', 'https://other.example')`,
+		`INSERT INTO tasks(vendor, proxy) VALUES('cisco', '10.0.0.2:3128')`,
+	} {
+		if _, err := d.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tasks, err := d.TaskProxies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].URL != "https://vpn.example" || tasks[1].Vendor != "cisco" || tasks[1].URL != "" {
+		t.Fatalf("TaskProxies = %+v", tasks)
+	}
+}
+
+func TestTaskStateMachine(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	var id int
+	if err := d.QueryRow(`INSERT INTO tasks(vendor, url) VALUES('fortinet', 'https://vpn.example') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Second)
+
+	var terr *TaskTransitionError
+	if err := d.SetTaskStatus(id, TaskDone, ""); !errors.As(err, &terr) {
+		t.Fatalf("pending -> done: %v", err)
+	}
+	if err := d.SetTaskProgress(id, 10); err == nil {
+		t.Fatal("progress accepted for a pending task")
+	}
+	for _, to := range []string{TaskAssigned, TaskRunning} {
+		if err := d.SetTaskStatus(id, to, ""); err != nil {
+			t.Fatalf("-> %s: %v", to, err)
+		}
+	}
+	if err := d.SetTaskProgress(id, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetTaskStatus(id, TaskFailed, "boom"); err != nil {
+		t.Fatal(err)
+	}
+	states, err := d.TaskStatesSince(start)
+	if err != nil || len(states) != 1 || states[0].Status != TaskFailed || states[0].Progress != 40 || states[0].Error != "boom" {
+		t.Fatalf("TaskStatesSince = %+v, %v", states, err)
+	}
+	if err := d.SetTaskStatus(id, "bogus", ""); err == nil {
+		t.Fatal("unknown state accepted")
+	}
+	if err := d.SetTaskStatus(id+1, TaskPending, ""); err != sql.ErrNoRows {
+		t.Fatalf("missing task: %v", err)
+	}
+}
+
+func TestNormalizeTaskStates(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	for _, st := range []string{"Completed", "whatever", "running"} {
+		if _, err := d.Exec(`INSERT INTO tasks(vendor, status) VALUES('x', $1)`, st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.normalizeTaskStates(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := d.Query(`SELECT status FROM tasks ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var s string
+		rows.Scan(&s)
+		got = append(got, s)
+	}
+	if len(got) != 3 || got[0] != TaskDone || got[1] != TaskPending || got[2] != TaskRunning {
+		t.Fatalf("states = %v", got)
+	}
+}
+
+func TestClaimTasks(t *testing.T) {
+	d, err := Connect(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer d.Close()
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		if _, err := d.Exec(`INSERT INTO tasks(vendor, url, login, password) VALUES('fortinet', 'https://vpn.example', 'u', 'p')`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.Exec(`INSERT INTO tasks(vendor, url) VALUES('cisco', 'https://asa.example')`); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[int]string)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < 4; w++ {
+		worker := fmt.Sprintf("w%d", w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				task, err := d.ClaimTask(worker, "Fortinet", time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if task == nil {
+					return
+				}
+				mu.Lock()
+				if prev, dup := claimed[task.ID]; dup {
+					t.Errorf("task %d claimed by %s and %s", task.ID, prev, worker)
+				}
+				claimed[task.ID] = worker
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(claimed) != n {
+		t.Fatalf("claimed %d tasks, want %d", len(claimed), n)
+	}
+
+	// Worker w0 dies: its lease expires and the task is claimed again.
+	var id int
+	for tid, w := range claimed {
+		id = tid
+		if err := d.SetClaimedTaskStatus(tid, w, TaskRunning, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.SetClaimedTaskStatus(id, "intruder", TaskDone, ""); err == nil {
+		t.Fatal("foreign worker completed a task")
+	}
+	if _, err := d.Exec(`UPDATE tasks SET lease_until = $1 WHERE id = $2`, time.Now().UTC().Add(-time.Minute), id); err != nil {
+		t.Fatal(err)
+	}
+	reclaimed, failed, err := d.ReclaimExpiredTasks(3)
+	if err != nil || reclaimed != 1 || failed != 0 {
+		t.Fatalf("ReclaimExpiredTasks = %d, %d, %v", reclaimed, failed, err)
+	}
+	task, err := d.ClaimTask("w9", "fortinet", time.Minute)
+	if err != nil || task == nil || task.ID != id || task.Attempts != 2 {
+		t.Fatalf("reclaimed claim = %+v, %v", task, err)
+	}
+	if err := d.RenewTaskLease(id, claimed[id], time.Minute); err == nil {
+		t.Fatal("old worker renewed a reclaimed task")
+	}
+	if err := d.SetClaimedTaskStatus(id, "w9", TaskRunning, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetClaimedTaskStatus(id, "w9", TaskDone, ""); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,255 @@
+// Package discover reads port scanner output, Nmap XML (-oX) or masscan
+// JSON (-oJ), and returns the hosts with an HTTPS or VPN port open,
+// tagged with the VPN vendor recognised in their service banners.
+package discover
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ports are the ports VPN portals are commonly served on. Other ports are
+// kept when the scanner identified an HTTPS service on them.
+var Ports = map[int]bool{443: true, 4433: true, 4443: true, 8443: true, 10443: true, 11443: true}
+
+// signatures map banner substrings, lower case, to the engine's VPN types.
+var signatures = []struct{ match, vendor string }{
+	{"fortinet", "fortinet"},
+	{"fortigate", "fortinet"},
+	{"globalprotect", "globalprotect"},
+	{"palo alto", "globalprotect"},
+	{"sonicwall", "sonicwall"},
+	{"sophos", "sophos"},
+	{"watchguard", "watchguard"},
+	{"anyconnect", "cisco"},
+	{"cisco", "cisco"},
+	{"netscaler", "citrix"},
+	{"citrix", "citrix"},
+	{"rd web access", "rdweb"},
+	{"outlook web app", "owa"},
+	{"outlook web access", "owa"},
+	{"zywall", "zyxel"},
+	{"zyxel", "zyxel"},
+	{"barracuda", "barracuda"},
+	{"array networks", "array"},
+	{"juniper", "juniper"},
+	{"dana-na", "juniper"},
+	{"routeros", "mikrotik"},
+	{"mikrotik", "mikrotik"},
+	{"edgeos", "edgerouter"},
+	{"unifi", "unifi"},
+}
+
+// Host is an open HTTPS or VPN port. Vendor is empty when no banner
+// identified one.
+type Host struct {
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Hostname string `json:"hostname,omitempty"`
+	Vendor   string `json:"vendor,omitempty"`
+}
+
+// Target returns the address of h as stored in the targets table; the
+// port is left out for 443.
+func (h Host) Target() string {
+	if h.Port == 443 {
+		return h.IP
+	}
+	return net.JoinHostPort(h.IP, strconv.Itoa(h.Port))
+}
+
+// URL returns the HTTPS URL of h.
+func (h Host) URL() string {
+	return "https://" + h.Target()
+}
+
+// Vendor returns the VPN type named in banner, or "".
+func Vendor(banner string) string {
+	banner = strings.ToLower(banner)
+	for _, s := range signatures {
+		if strings.Contains(banner, s.match) {
+			return s.vendor
+		}
+	}
+	return ""
+}
+
+// Parse reads Nmap XML or masscan JSON, told apart by their first
+// character.
+func Parse(data []byte) ([]Host, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	switch data[0] {
+	case '<':
+		return ParseNmap(data)
+	case '[', '{':
+		return ParseMasscan(data)
+	}
+	return nil, fmt.Errorf("unknown scan format (want nmap XML or masscan JSON)")
+}
+
+type nmapRun struct {
+	Hosts []struct {
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Hostnames []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"hostnames>hostname"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name      string `xml:"name,attr"`
+				Product   string `xml:"product,attr"`
+				ExtraInfo string `xml:"extrainfo,attr"`
+				Tunnel    string `xml:"tunnel,attr"`
+			} `xml:"service"`
+			Scripts []struct {
+				Output string `xml:"output,attr"`
+			} `xml:"script"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// ParseNmap reads the XML output of nmap -oX.
+func ParseNmap(data []byte) ([]Host, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("nmap xml: %w", err)
+	}
+	var out []Host
+	for _, h := range run.Hosts {
+		var ip, name string
+		for _, a := range h.Addresses {
+			if a.AddrType == "ipv4" || a.AddrType == "ipv6" {
+				ip = a.Addr
+				break
+			}
+		}
+		if len(h.Hostnames) > 0 {
+			name = h.Hostnames[0].Name
+		}
+		for _, p := range h.Ports {
+			if p.Protocol != "tcp" || p.State.State != "open" {
+				continue
+			}
+			svc := p.Service
+			if !Ports[p.PortID] && svc.Name != "https" && svc.Tunnel != "ssl" {
+				continue
+			}
+			banner := svc.Product + " " + svc.ExtraInfo
+			for _, s := range p.Scripts {
+				banner += " " + s.Output
+			}
+			out = append(out, Host{IP: ip, Port: p.PortID, Hostname: name, Vendor: Vendor(banner)})
+		}
+	}
+	return dedupe(out), nil
+}
+
+type masscanRecord struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port    int    `json:"port"`
+		Proto   string `json:"proto"`
+		Status  string `json:"status"`
+		Service struct {
+			Name   string `json:"name"`
+			Banner string `json:"banner"`
+		} `json:"service"`
+	} `json:"ports"`
+}
+
+// ParseMasscan reads the JSON output of masscan -oJ. Older masscan
+// versions write an object per line with trailing commas, which is
+// accepted too. Banners, when grabbed, tag vendors.
+func ParseMasscan(data []byte) ([]Host, error) {
+	var records []masscanRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		records = records[:0]
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			line := strings.TrimSuffix(strings.TrimSpace(sc.Text()), ",")
+			if line == "" || line == "[" || line == "]" {
+				continue
+			}
+			var rec masscanRecord
+			if err := json.Unmarshal([]byte(line), &rec); err != nil {
+				return nil, fmt.Errorf("masscan json: %w", err)
+			}
+			records = append(records, rec)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Banners come in records of their own, so ports are merged per
+	// address first.
+	type key struct {
+		ip   string
+		port int
+	}
+	open := make(map[key]bool)
+	banners := make(map[key]string)
+	https := make(map[key]bool)
+	for _, rec := range records {
+		for _, p := range rec.Ports {
+			if p.Proto != "" && p.Proto != "tcp" {
+				continue
+			}
+			k := key{rec.IP, p.Port}
+			if p.Status == "open" {
+				open[k] = true
+			}
+			if p.Service.Name != "" {
+				open[k] = true
+				banners[k] += " " + p.Service.Banner
+				if p.Service.Name == "ssl" || p.Service.Name == "https" || p.Service.Name == "X509" {
+					https[k] = true
+				}
+			}
+		}
+	}
+	var out []Host
+	for k := range open {
+		if !Ports[k.port] && !https[k] {
+			continue
+		}
+		out = append(out, Host{IP: k.ip, Port: k.port, Vendor: Vendor(banners[k])})
+	}
+	return dedupe(out), nil
+}
+
+// dedupe sorts hosts by address and port and drops repeated entries.
+func dedupe(hosts []Host) []Host {
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].IP != hosts[j].IP {
+			return hosts[i].IP < hosts[j].IP
+		}
+		return hosts[i].Port < hosts[j].Port
+	})
+	out := hosts[:0]
+	for i, h := range hosts {
+		if i > 0 && h.IP == hosts[i-1].IP && h.Port == hosts[i-1].Port {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
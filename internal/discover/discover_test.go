@@ -0,0 +1,78 @@
+package discover
+
+import (
+	"fmt"
+	"testing"
+)
+
+const nmapXML = `<?xml version="1.0"?>
+<nmaprun scanner="nmap">
+<host><status state="up"/>
+<address addr="198.51.100.10" addrtype="ipv4"/>
+<hostnames><hostname name="vpn.example.com" type="PTR"/></hostnames>
+<ports>
+<port protocol="tcp" portid="22"><state state="open"/><service name="ssh" product="OpenSSH"/></port>
+<port protocol="tcp" portid="10443"><state state="open"/><service name="https" product="Fortinet FortiGate SSL VPN"/></port>
+<port protocol="tcp" portid="8443"><state state="closed"/><service name="https-alt"/></port>
+</ports></host>
+<host><status state="up"/>
+<address addr="198.51.100.20" addrtype="ipv4"/>
+<ports>
+<port protocol="tcp" portid="9443"><state state="open"/><service name="http" tunnel="ssl"/>
+<script id="http-title" output="GlobalProtect Portal"/></port>
+<port protocol="tcp" portid="8080"><state state="open"/><service name="http"/></port>
+</ports></host>
+</nmaprun>`
+
+const masscanJSON = `[
+{   "ip": "203.0.113.5",   "timestamp": "1700000000", "ports": [ {"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 52} ] },
+{   "ip": "203.0.113.5",   "timestamp": "1700000001", "ports": [ {"port": 443, "proto": "tcp", "service": {"name": "http", "banner": "HTTP/1.1 200 OK\r\nServer: SonicWALL SSL-VPN Web Server"} } ] },
+{   "ip": "203.0.113.6",   "timestamp": "1700000002", "ports": [ {"port": 22, "proto": "tcp", "status": "open"} ] },
+{   "ip": "203.0.113.7",   "timestamp": "1700000003", "ports": [ {"port": 4433, "proto": "tcp", "status": "open"} ] },
+]`
+
+func hostList(hosts []Host) string {
+	return fmt.Sprint(hosts)
+}
+
+func TestParseNmap(t *testing.T) {
+	hosts, err := Parse([]byte(nmapXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Host{
+		{IP: "198.51.100.10", Port: 10443, Hostname: "vpn.example.com", Vendor: "fortinet"},
+		{IP: "198.51.100.20", Port: 9443, Vendor: "globalprotect"},
+	}
+	if hostList(hosts) != hostList(want) {
+		t.Fatalf("hosts = %+v", hosts)
+	}
+	if hosts[0].URL() != "https://198.51.100.10:10443" {
+		t.Fatalf("url = %s", hosts[0].URL())
+	}
+}
+
+func TestParseMasscan(t *testing.T) {
+	// masscan's trailing comma makes the array invalid JSON; the line
+	// fallback reads it.
+	hosts, err := Parse([]byte(masscanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Host{
+		{IP: "203.0.113.5", Port: 443, Vendor: "sonicwall"},
+		{IP: "203.0.113.7", Port: 4433},
+	}
+	if hostList(hosts) != hostList(want) {
+		t.Fatalf("hosts = %+v", hosts)
+	}
+	if hosts[0].Target() != "203.0.113.5" || hosts[1].Target() != "203.0.113.7:4433" {
+		t.Fatalf("targets = %s, %s", hosts[0].Target(), hosts[1].Target())
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	if _, err := Parse([]byte("10.0.0.1\n")); err == nil {
+		t.Fatal("plain text accepted")
+	}
+}
@@ -0,0 +1,206 @@
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Search engines.
+const (
+	EngineShodan = "shodan"
+	EngineCensys = "censys"
+)
+
+// Dorks are the built-in search queries per engine and VPN type.
+var Dorks = map[string]map[string]string{
+	EngineShodan: {
+		"fortinet":         `http.html:"/remote/login?lang="`,
+		"globalprotect":    `http.html:"global-protect/login.esp"`,
+		"sonicwall":        `http.title:"SonicWall - Virtual Office"`,
+		"sophos":           `http.title:"Sophos" http.html:"userportal"`,
+		"watchguard":       `http.html:"/auth/login" "WatchGuard"`,
+		"cisco":            `http.html:"/+CSCOE+/logon.html"`,
+		"citrix":           `http.title:"Citrix Gateway"`,
+		"rdweb":            `http.html:"/RDWeb/Pages/"`,
+		"owa":              `http.html:"/owa/auth/"`,
+		"zyxel":            `http.html:"weblogin.cgi" "ZyWALL"`,
+		"barracuda":        `http.title:"Barracuda SSL VPN"`,
+		"array":            `http.html:"/prx/000/http/localhost/login"`,
+		"juniper":          `http.html:"/dana-na/auth/url_default/welcome.cgi"`,
+		"mikrotik":         `http.title:"RouterOS router configuration page"`,
+		"mikrotik_hotspot": `http.html:"mikrotik" http.html:"hotspot"`,
+		"edgerouter":       `http.title:"EdgeOS"`,
+		"unifi":            `http.title:"UniFi Network"`,
+	},
+	EngineCensys: {
+		"fortinet":         `services.http.response.html_title: "FortiGate"`,
+		"globalprotect":    `services.http.response.html_title: "GlobalProtect Portal"`,
+		"sonicwall":        `services.http.response.html_title: "SonicWall - Virtual Office"`,
+		"sophos":           `services.http.response.html_title: "Sophos"`,
+		"watchguard":       `services.http.response.html_title: "WatchGuard"`,
+		"cisco":            `services.http.response.body: "/+CSCOE+/logon.html"`,
+		"citrix":           `services.http.response.html_title: "Citrix Gateway"`,
+		"rdweb":            `services.http.response.html_title: "RD Web Access"`,
+		"owa":              `services.http.response.body: "/owa/auth/"`,
+		"zyxel":            `services.http.response.body: "weblogin.cgi"`,
+		"barracuda":        `services.http.response.html_title: "Barracuda SSL VPN"`,
+		"array":            `services.http.response.body: "/prx/000/http/localhost/login"`,
+		"juniper":          `services.http.response.body: "/dana-na/auth/url_default/"`,
+		"mikrotik":         `services.http.response.html_title: "RouterOS router configuration page"`,
+		"mikrotik_hotspot": `services.http.response.body: "mikrotik" and services.http.response.body: "hotspot"`,
+		"edgerouter":       `services.http.response.html_title: "EdgeOS"`,
+		"unifi":            `services.http.response.html_title: "UniFi Network"`,
+	},
+}
+
+// maxResponseSize bounds one search result page.
+const maxResponseSize = 16 << 20
+
+// Searcher queries a search engine for hosts.
+type Searcher interface {
+	// Search returns the hosts matching query from at most maxPages
+	// result pages.
+	Search(ctx context.Context, query string, maxPages int) ([]Host, error)
+}
+
+// Shodan searches the Shodan host index. Every page has 100 results.
+type Shodan struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+type shodanPage struct {
+	Total   int `json:"total"`
+	Matches []struct {
+		IPStr     string   `json:"ip_str"`
+		Port      int      `json:"port"`
+		Hostnames []string `json:"hostnames"`
+	} `json:"matches"`
+}
+
+// Search implements Searcher.
+func (s *Shodan) Search(ctx context.Context, query string, maxPages int) ([]Host, error) {
+	if s.APIKey == "" {
+		return nil, fmt.Errorf("shodan api key not configured")
+	}
+	var out []Host
+	for page := 1; page <= maxPages; page++ {
+		q := url.Values{"key": {s.APIKey}, "query": {query}, "page": {strconv.Itoa(page)}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.BaseURL, "/")+"/shodan/host/search?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var res shodanPage
+		if err := getJSON(client(s.Client), req, &res); err != nil {
+			return nil, fmt.Errorf("shodan: %w", err)
+		}
+		for _, m := range res.Matches {
+			h := Host{IP: m.IPStr, Port: m.Port}
+			if len(m.Hostnames) > 0 {
+				h.Hostname = m.Hostnames[0]
+			}
+			out = append(out, h)
+		}
+		if len(res.Matches) == 0 || page*100 >= res.Total {
+			break
+		}
+	}
+	return dedupe(out), nil
+}
+
+// Censys searches the Censys hosts index (Search API v2). Only the HTTPS
+// and VPN port services of a matching host are returned.
+type Censys struct {
+	APIID     string
+	APISecret string
+	BaseURL   string
+	Client    *http.Client
+}
+
+type censysPage struct {
+	Result struct {
+		Hits []struct {
+			IP       string `json:"ip"`
+			Services []struct {
+				Port                int    `json:"port"`
+				ExtendedServiceName string `json:"extended_service_name"`
+			} `json:"services"`
+		} `json:"hits"`
+		Links struct {
+			Next string `json:"next"`
+		} `json:"links"`
+	} `json:"result"`
+}
+
+// Search implements Searcher.
+func (c *Censys) Search(ctx context.Context, query string, maxPages int) ([]Host, error) {
+	if c.APIID == "" || c.APISecret == "" {
+		return nil, fmt.Errorf("censys api credentials not configured")
+	}
+	var out []Host
+	cursor := ""
+	for page := 1; page <= maxPages; page++ {
+		q := url.Values{"q": {query}, "per_page": {"100"}}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+"/api/v2/hosts/search?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(c.APIID, c.APISecret)
+		var res censysPage
+		if err := getJSON(client(c.Client), req, &res); err != nil {
+			return nil, fmt.Errorf("censys: %w", err)
+		}
+		for _, hit := range res.Result.Hits {
+			for _, svc := range hit.Services {
+				if Ports[svc.Port] || svc.ExtendedServiceName == "HTTPS" {
+					out = append(out, Host{IP: hit.IP, Port: svc.Port})
+				}
+			}
+		}
+		if cursor = res.Result.Links.Next; cursor == "" {
+			break
+		}
+	}
+	return dedupe(out), nil
+}
+
+func client(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
+
+// getJSON sends req and decodes the JSON response into v. The message of
+// an error response is returned when its body has one.
+func getJSON(c *http.Client, req *http.Request, v interface{}) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,64 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestShodanPaginates(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("key") != "k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "Invalid API key"}`)
+			return
+		}
+		pages = append(pages, q.Get("page"))
+		fmt.Fprintf(w, `{"total": 150, "matches": [{"ip_str": "198.51.100.%s", "port": 10443, "hostnames": ["vpn%s.example"]}]}`,
+			q.Get("page"), q.Get("page"))
+	}))
+	defer srv.Close()
+
+	hosts, err := (&Shodan{APIKey: "k", BaseURL: srv.URL}).Search(context.Background(), "q", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || len(hosts) != 2 || hosts[1] != (Host{IP: "198.51.100.2", Port: 10443, Hostname: "vpn2.example"}) {
+		t.Fatalf("pages %v, hosts %+v", pages, hosts)
+	}
+
+	_, err = (&Shodan{APIKey: "bad", BaseURL: srv.URL}).Search(context.Background(), "q", 1)
+	if err == nil || err.Error() != "shodan: 401 Unauthorized: Invalid API key" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestCensysFollowsCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next, ip := "c2", "203.0.113.1"
+		if r.URL.Query().Get("cursor") == "c2" {
+			next, ip = "", "203.0.113.2"
+		}
+		fmt.Fprintf(w, `{"result": {"hits": [{"ip": %q, "services": [
+			{"port": 22, "extended_service_name": "SSH"},
+			{"port": 443, "extended_service_name": "HTTPS"},
+			{"port": 9443, "extended_service_name": "HTTPS"}]}], "links": {"next": %q}}}`, ip, next)
+	}))
+	defer srv.Close()
+
+	hosts, err := (&Censys{APIID: "id", APISecret: "secret", BaseURL: srv.URL}).Search(context.Background(), "q", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 4 || hosts[0].Target() != "203.0.113.1" || hosts[3].Target() != "203.0.113.2:9443" {
+		t.Fatalf("hosts = %+v", hosts)
+	}
+}
@@ -0,0 +1,182 @@
+// Package exclude implements the global deny-list of targets that must
+// never be attempted, by network, autonomous system or country.
+package exclude
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vpn-bruteforce-client/internal/geoip"
+)
+
+// Rule kinds.
+const (
+	KindCIDR    = "cidr"
+	KindASN     = "asn"
+	KindCountry = "country"
+)
+
+// resolveTimeout bounds the DNS lookup of targets given by host name.
+const resolveTimeout = 2 * time.Second
+
+// Rule excludes the targets matching Value, a CIDR or IP, an ASN (with or
+// without the AS prefix) or an ISO country code.
+type Rule struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// String returns the rule as kind:value, the key used for hit counts.
+func (r Rule) String() string {
+	return r.Kind + ":" + r.Value
+}
+
+// Normalize validates r and returns it in canonical form.
+func Normalize(r Rule) (Rule, error) {
+	r.Kind = strings.ToLower(strings.TrimSpace(r.Kind))
+	v := strings.TrimSpace(r.Value)
+	switch r.Kind {
+	case KindCIDR:
+		if ip := net.ParseIP(v); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			v = fmt.Sprintf("%s/%d", ip, bits)
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return r, fmt.Errorf("invalid cidr %q", r.Value)
+		}
+		r.Value = n.String()
+	case KindASN:
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(v), "AS"), 10, 32)
+		if err != nil || n == 0 {
+			return r, fmt.Errorf("invalid asn %q", r.Value)
+		}
+		r.Value = strconv.FormatUint(n, 10)
+	case KindCountry:
+		if len(v) != 2 {
+			return r, fmt.Errorf("invalid country code %q", r.Value)
+		}
+		r.Value = strings.ToUpper(v)
+	default:
+		return r, fmt.Errorf("unknown exclusion kind %q (want cidr, asn or country)", r.Kind)
+	}
+	return r, nil
+}
+
+// List matches targets against a set of rules. ASN and country rules need
+// a GeoIP reader; without one they never match.
+type List struct {
+	rules     []Rule
+	nets      []*net.IPNet
+	netRules  []Rule
+	asns      map[uint]Rule
+	countries map[string]Rule
+	geo       *geoip.Reader
+
+	// lookupIP resolves host names; replaced in tests.
+	lookupIP func(ctx context.Context, host string) ([]net.IP, error)
+
+	mu    sync.Mutex
+	cache map[string]string // host -> matching rule, "" for none
+}
+
+// New validates rules and returns a list using geo for ASN and country
+// lookups.
+func New(rules []Rule, geo *geoip.Reader) (*List, error) {
+	l := &List{
+		asns:      make(map[uint]Rule),
+		countries: make(map[string]Rule),
+		geo:       geo,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+		cache: make(map[string]string),
+	}
+	for _, r := range rules {
+		r, err := Normalize(r)
+		if err != nil {
+			return nil, err
+		}
+		l.rules = append(l.rules, r)
+		switch r.Kind {
+		case KindCIDR:
+			_, n, _ := net.ParseCIDR(r.Value)
+			l.nets = append(l.nets, n)
+			l.netRules = append(l.netRules, r)
+		case KindASN:
+			n, _ := strconv.ParseUint(r.Value, 10, 32)
+			l.asns[uint(n)] = r
+		case KindCountry:
+			l.countries[r.Value] = r
+		}
+	}
+	return l, nil
+}
+
+// Rules returns the normalized rules.
+func (l *List) Rules() []Rule {
+	if l == nil {
+		return nil
+	}
+	return append([]Rule(nil), l.rules...)
+}
+
+// Match returns the first rule excluding target, an IP, host, host:port
+// or URL. Results are cached per host. A nil or empty list matches nothing.
+func (l *List) Match(target string) (Rule, bool) {
+	if l == nil || len(l.rules) == 0 {
+		return Rule{}, false
+	}
+	host := geoip.Host(target)
+	l.mu.Lock()
+	key, ok := l.cache[host]
+	l.mu.Unlock()
+	if !ok {
+		key = l.match(host)
+		l.mu.Lock()
+		l.cache[host] = key
+		l.mu.Unlock()
+	}
+	if key == "" {
+		return Rule{}, false
+	}
+	kind, value, _ := strings.Cut(key, ":")
+	return Rule{Kind: kind, Value: value}, true
+}
+
+func (l *List) match(host string) string {
+	if len(l.nets) > 0 {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+			ips, err := l.lookupIP(ctx, host)
+			cancel()
+			if err == nil && len(ips) > 0 {
+				ip = ips[0]
+			}
+		}
+		for i, n := range l.nets {
+			if ip != nil && n.Contains(ip) {
+				return l.netRules[i].String()
+			}
+		}
+	}
+	if len(l.asns) > 0 || len(l.countries) > 0 {
+		info := l.geo.Lookup(host)
+		if r, ok := l.asns[info.ASN]; ok && info.ASN != 0 {
+			return r.String()
+		}
+		if r, ok := l.countries[info.Country]; ok && info.Country != "" {
+			return r.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package exclude
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		in   Rule
+		want string
+	}{
+		{Rule{"CIDR", "10.1.2.3/8"}, "cidr:10.0.0.0/8"},
+		{Rule{"cidr", "192.0.2.7"}, "cidr:192.0.2.7/32"},
+		{Rule{"asn", "AS15169"}, "asn:15169"},
+		{Rule{"country", "ru"}, "country:RU"},
+	} {
+		got, err := Normalize(tc.in)
+		if err != nil || got.String() != tc.want {
+			t.Errorf("Normalize(%v) = %v, %v; want %s", tc.in, got, err, tc.want)
+		}
+	}
+	for _, bad := range []Rule{{"cidr", "10.0.0.0/33"}, {"asn", "ASX"}, {"country", "RUS"}, {"port", "22"}} {
+		if _, err := Normalize(bad); err == nil {
+			t.Errorf("Normalize(%v) succeeded", bad)
+		}
+	}
+}
+
+func TestMatchCIDR(t *testing.T) {
+	l, err := New([]Rule{{KindCIDR, "10.0.0.0/8"}, {KindCountry, "RU"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("10.9.9.9")}, nil
+	}
+	for target, want := range map[string]bool{
+		"10.1.1.1":                   true,
+		"https://10.2.2.2:443/login": true,
+		"vpn.internal:8443":          true,
+		"192.0.2.1":                  false,
+	} {
+		r, ok := l.Match(target)
+		if ok != want || (ok && r.String() != "cidr:10.0.0.0/8") {
+			t.Errorf("Match(%q) = %v, %v", target, r, ok)
+		}
+	}
+	var none *List
+	if _, ok := none.Match("10.1.1.1"); ok {
+		t.Fatal("nil list matched")
+	}
+}
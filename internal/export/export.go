@@ -0,0 +1,221 @@
+// Package export writes findings in formats reporting tools read: CSV, a
+// single-sheet XLSX workbook and a JSON document describing every finding
+// as host, service and credential evidence.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"vpn-bruteforce-client/internal/db"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Formats lists the export formats.
+var Formats = []string{FormatCSV, FormatXLSX, FormatJSON}
+
+// MaxFindings bounds the findings of one export.
+const MaxFindings = 100000
+
+// SchemaVersion identifies the layout of the JSON export.
+const SchemaVersion = "vpn-findings/1"
+
+// columns are the CSV and XLSX columns, one row per finding.
+var columns = []string{"id", "run_id", "vpn_type", "host", "port", "username", "password",
+	"found_at", "last_seen", "seen_count", "country", "asn", "as_org"}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/json"
+}
+
+// Write writes findings to w in format.
+func Write(w io.Writer, format string, findings []db.Finding) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, findings)
+	case FormatXLSX:
+		return writeXLSX(w, findings)
+	case FormatJSON:
+		return writeJSON(w, findings)
+	}
+	return fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// hostPort splits the target of f; the port defaults to 443.
+func hostPort(f db.Finding) (string, int) {
+	target := f.IP
+	if i := strings.Index(target, "://"); i >= 0 {
+		target = target[i+3:]
+	}
+	target = strings.TrimSuffix(target, "/")
+	if host, port, err := net.SplitHostPort(target); err == nil {
+		if n, err := strconv.Atoi(port); err == nil {
+			return host, n
+		}
+	}
+	return target, 443
+}
+
+// row returns the column values of f.
+func row(f db.Finding) []string {
+	host, port := hostPort(f)
+	asn := ""
+	if f.ASN != 0 {
+		asn = strconv.FormatUint(uint64(f.ASN), 10)
+	}
+	return []string{strconv.Itoa(f.ID), f.RunID, f.VPNType, host, strconv.Itoa(port), f.Username, f.Password,
+		f.FoundAt.UTC().Format(time.RFC3339), f.LastSeen.UTC().Format(time.RFC3339), strconv.Itoa(f.SeenCount),
+		f.Country, asn, f.ASOrg}
+}
+
+func writeCSV(w io.Writer, findings []db.Finding) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, f := range findings {
+		cw.Write(row(f))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Document is the JSON export.
+type Document struct {
+	Schema      string    `json:"schema"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Findings    []Entry   `json:"findings"`
+}
+
+// Entry is a finding in the JSON export.
+type Entry struct {
+	ID   int `json:"id"`
+	Host struct {
+		Address string `json:"address"`
+		Country string `json:"country,omitempty"`
+		ASN     uint   `json:"asn,omitempty"`
+		ASOrg   string `json:"as_org,omitempty"`
+	} `json:"host"`
+	Service struct {
+		Port     int    `json:"port"`
+		Protocol string `json:"protocol"`
+		Product  string `json:"product"`
+		URL      string `json:"url"`
+	} `json:"service"`
+	Credential struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"credential"`
+	Evidence struct {
+		RunID     string    `json:"run_id"`
+		FoundAt   time.Time `json:"found_at"`
+		LastSeen  time.Time `json:"last_seen"`
+		SeenCount int       `json:"seen_count"`
+	} `json:"evidence"`
+}
+
+func writeJSON(w io.Writer, findings []db.Finding) error {
+	doc := Document{Schema: SchemaVersion, GeneratedAt: time.Now().UTC(), Findings: make([]Entry, 0, len(findings))}
+	for _, f := range findings {
+		var e Entry
+		host, port := hostPort(f)
+		e.ID = f.ID
+		e.Host.Address, e.Host.Country, e.Host.ASN, e.Host.ASOrg = host, f.Country, f.ASN, f.ASOrg
+		e.Service.Port, e.Service.Protocol, e.Service.Product = port, "https", f.VPNType
+		e.Service.URL = "https://" + net.JoinHostPort(host, strconv.Itoa(port))
+		e.Credential.Username, e.Credential.Password = f.Username, f.Password
+		e.Evidence.RunID, e.Evidence.FoundAt, e.Evidence.LastSeen, e.Evidence.SeenCount = f.RunID, f.FoundAt, f.LastSeen, f.SeenCount
+		doc.Findings = append(doc.Findings, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// xlsxParts are the fixed parts of the workbook; the sheet is generated.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Findings" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+// numericColumns are written as numbers rather than text in the XLSX.
+var numericColumns = map[string]bool{"id": true, "port": true, "seen_count": true, "asn": true}
+
+func writeXLSX(w io.Writer, findings []db.Finding) error {
+	zw := zip.NewWriter(w)
+	for _, p := range xlsxParts {
+		fw, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, p.body); err != nil {
+			return err
+		}
+	}
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeRow := func(values []string, header bool) {
+		b.WriteString("<row>")
+		for i, v := range values {
+			if !header && numericColumns[columns[i]] && v != "" {
+				fmt.Fprintf(&b, "<c><v>%s</v></c>", v)
+				continue
+			}
+			b.WriteString(`<c t="inlineStr"><is><t>`)
+			xml.EscapeText(&b, []byte(v))
+			b.WriteString("</t></is></c>")
+		}
+		b.WriteString("</row>")
+	}
+	writeRow(columns, true)
+	for _, f := range findings {
+		writeRow(row(f), false)
+	}
+	b.WriteString("</sheetData></worksheet>")
+	if _, err := fw.Write(b.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/db"
+)
+
+var findings = []db.Finding{
+	{ID: 1, RunID: "r1", VPNType: "fortinet", IP: "198.51.100.1:10443", Username: "admin", Password: "p<&>",
+		FoundAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), LastSeen: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), SeenCount: 2,
+		Country: "DE", ASN: 3320, ASOrg: "Telekom"},
+	{ID: 2, VPNType: "cisco", IP: "https://vpn.example/", Username: "vpn", Password: "x", SeenCount: 1},
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, findings); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("rows = %v, %v", rows, err)
+	}
+	if got := strings.Join(rows[1], ","); got != "1,r1,fortinet,198.51.100.1,10443,admin,p<&>,2026-01-02T03:04:05Z,2026-01-03T00:00:00Z,2,DE,3320,Telekom" {
+		t.Fatalf("row = %s", got)
+	}
+	if rows[2][3] != "vpn.example" || rows[2][4] != "443" {
+		t.Fatalf("url target = %v", rows[2])
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, findings); err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	e := doc.Findings[0]
+	if doc.Schema != SchemaVersion || len(doc.Findings) != 2 || e.Host.Address != "198.51.100.1" || e.Service.Port != 10443 ||
+		e.Service.URL != "https://198.51.100.1:10443" || e.Service.Product != "fortinet" || e.Credential.Password != "p<&>" || e.Evidence.SeenCount != 2 {
+		t.Fatalf("doc = %+v", doc)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatXLSX, findings); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(data)
+		}
+	}
+	if len(zr.File) != 5 || strings.Count(sheet, "<row>") != 3 {
+		t.Fatalf("%d parts, sheet %s", len(zr.File), sheet)
+	}
+	if !strings.Contains(sheet, "<t>p&lt;&amp;&gt;</t>") || !strings.Contains(sheet, "<c><v>10443</v></c>") {
+		t.Fatalf("sheet = %s", sheet)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if err := Write(io.Discard, "pdf", nil); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
@@ -0,0 +1,143 @@
+// Package geoip looks up the country and autonomous system of targets in
+// MaxMind GeoLite2 databases.
+package geoip
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// resolveTimeout bounds the DNS lookup of targets given by host name.
+const resolveTimeout = 2 * time.Second
+
+// Info is the location of a target. Empty fields are unknown.
+type Info struct {
+	Country string `json:"country,omitempty"`
+	ASN     uint   `json:"asn,omitempty"`
+	ASOrg   string `json:"as_org,omitempty"`
+}
+
+// Reader looks up targets in a GeoLite2 Country (or City) database and an
+// ASN database. Either may be missing.
+type Reader struct {
+	country *geoip2.Reader
+	asn     *geoip2.Reader
+
+	// lookupIP resolves host names; replaced in tests.
+	lookupIP func(ctx context.Context, host string) ([]net.IP, error)
+
+	mu    sync.Mutex
+	cache map[string]Info
+}
+
+// Open opens the databases at countryPath and asnPath. An empty path skips
+// that database.
+func Open(countryPath, asnPath string) (*Reader, error) {
+	r := &Reader{
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+		cache: make(map[string]Info),
+	}
+	var err error
+	if countryPath != "" {
+		if r.country, err = geoip2.Open(countryPath); err != nil {
+			return nil, err
+		}
+	}
+	if asnPath != "" {
+		if r.asn, err = geoip2.Open(asnPath); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Close releases the databases.
+func (r *Reader) Close() error {
+	if r == nil {
+		return nil
+	}
+	if r.country != nil {
+		r.country.Close()
+	}
+	if r.asn != nil {
+		r.asn.Close()
+	}
+	return nil
+}
+
+// Lookup returns the location of target, which may be an IP, host,
+// host:port or URL. Host names are resolved; results are cached per host.
+// A nil reader returns an empty Info.
+func (r *Reader) Lookup(target string) Info {
+	if r == nil {
+		return Info{}
+	}
+	host := Host(target)
+	if host == "" {
+		return Info{}
+	}
+	r.mu.Lock()
+	info, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok {
+		return info
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		ips, err := r.lookupIP(ctx, host)
+		cancel()
+		if err == nil && len(ips) > 0 {
+			ip = ips[0]
+		}
+	}
+	if ip != nil {
+		info = r.lookupIPInfo(ip)
+	}
+	r.mu.Lock()
+	r.cache[host] = info
+	r.mu.Unlock()
+	return info
+}
+
+func (r *Reader) lookupIPInfo(ip net.IP) Info {
+	var info Info
+	if r.country != nil {
+		if c, err := r.country.Country(ip); err == nil {
+			info.Country = c.Country.IsoCode
+		}
+	}
+	if r.asn != nil {
+		if a, err := r.asn.ASN(ip); err == nil {
+			info.ASN = a.AutonomousSystemNumber
+			info.ASOrg = a.AutonomousSystemOrganization
+		}
+	}
+	return info
+}
+
+// Host extracts the host from an IP, host, host:port or URL target.
+func Host(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	}
+	if h, _, err := net.SplitHostPort(target); err == nil {
+		return h
+	}
+	return strings.Trim(target, "[]")
+}
@@ -0,0 +1,42 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	for in, want := range map[string]string{
+		"1.2.3.4":     "1.2.3.4",
+		"1.2.3.4:443": "1.2.3.4",
+		"https://vpn.example.com:8443/remote/login": "vpn.example.com",
+		"[2001:db8::1]:443":                         "2001:db8::1",
+		"vpn.example.com":                           "vpn.example.com",
+	} {
+		if got := Host(in); got != want {
+			t.Errorf("Host(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLookupResolvesOnce(t *testing.T) {
+	r, err := Open("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	r.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		calls++
+		return []net.IP{net.ParseIP("192.0.2.1")}, nil
+	}
+	r.Lookup("https://vpn.example.com")
+	r.Lookup("vpn.example.com:443")
+	if calls != 1 {
+		t.Fatalf("resolved %d times, want 1", calls)
+	}
+	var none *Reader
+	if info := none.Lookup("1.2.3.4"); info != (Info{}) {
+		t.Fatalf("nil reader returned %+v", info)
+	}
+}
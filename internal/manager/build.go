@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// sourceVersion returns a short content hash of the script so that a changed
+// source produces a new binary name.
+func sourceVersion(script string) (string, error) {
+	data, err := os.ReadFile(script)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+// binaryPath returns the versioned binary path for a Go script.
+func (m *Manager) binaryPath(s Scanner) (string, error) {
+	version, err := sourceVersion(s.Script)
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimSuffix(filepath.Base(s.Script), filepath.Ext(s.Script))
+	name := fmt.Sprintf("%s-%s", base, version)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(m.binDir, name), nil
+}
+
+// Build compiles a Go scanner into binDir unless the binary for the current
+// source version already exists. It returns the binary path.
+func (m *Manager) Build(s Scanner) (string, error) {
+	bin, err := m.binaryPath(s)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+	if err := os.MkdirAll(m.binDir, 0o755); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("go", "build", "-o", bin, s.Script)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("build %s: %w", s.Script, err)
+	}
+	return bin, nil
+}
+
+// command returns the command line used to run s, building it if needed.
+// Program paths are made absolute so that s.Dir does not affect them.
+func (m *Manager) command(s Scanner) ([]string, error) {
+	var args []string
+	switch {
+	case s.Binary != "":
+		bin, err := filepath.Abs(s.Binary)
+		if err != nil {
+			return nil, err
+		}
+		args = append([]string{bin}, s.Args...)
+	case filepath.Ext(s.Script) == ".py":
+		script, err := filepath.Abs(s.Script)
+		if err != nil {
+			return nil, err
+		}
+		args = append([]string{pythonInterpreter(), script}, s.Args...)
+	default:
+		bin, err := m.Build(s)
+		if err != nil {
+			return nil, err
+		}
+		if bin, err = filepath.Abs(bin); err != nil {
+			return nil, err
+		}
+		args = append([]string{bin}, s.Args...)
+	}
+	if s.CredsFile != "" {
+		args = append(args, "--creds-file", s.CredsFile)
+	}
+	return args, nil
+}
+
+// environ returns the environment for s: the manager's own environment with
+// s.Env applied on top.
+func environ(s Scanner) []string {
+	if len(s.Env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(s.Env))
+	for k := range s.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := os.Environ()
+	for _, k := range keys {
+		env = append(env, k+"="+s.Env[k])
+	}
+	return env
+}
+
+// pythonInterpreter returns python3 when available and falls back to python,
+// which is the usual name on Windows.
+func pythonInterpreter() string {
+	if _, err := exec.LookPath("python3"); err == nil {
+		return "python3"
+	}
+	return "python"
+}
@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the scanner definition file read by cmd/manager.
+const DefaultConfigFile = "scanners.yaml"
+
+type scannerConfig struct {
+	Scanners map[string]Scanner `yaml:"scanners"`
+}
+
+// LoadScanners reads scanner definitions from a YAML file of the form
+//
+//	scanners:
+//	  fortinet:
+//	    script: sers1.go
+//	    creds_file: creds/fortinet.txt
+//
+// Every scanner needs either a script or a binary.
+func LoadScanners(filename string) (map[string]Scanner, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfg scannerConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+	if len(cfg.Scanners) == 0 {
+		return nil, fmt.Errorf("%s: no scanners defined", filename)
+	}
+	for name, s := range cfg.Scanners {
+		if name == "all" {
+			return nil, fmt.Errorf("%s: scanner name %q is reserved", filename, name)
+		}
+		if s.Script == "" && s.Binary == "" {
+			return nil, fmt.Errorf("%s: scanner %s needs a script or binary", filename, name)
+		}
+		if err := s.Limits.validate(); err != nil {
+			return nil, fmt.Errorf("%s: scanner %s: %w", filename, name, err)
+		}
+	}
+	return cfg.Scanners, nil
+}
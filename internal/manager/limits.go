@@ -0,0 +1,44 @@
+package manager
+
+import "fmt"
+
+// DefaultCgroupRoot is the cgroup v2 directory under which every scanner gets
+// its own group.
+const DefaultCgroupRoot = "/sys/fs/cgroup/vpn-scanners"
+
+// Limits constrains the resources a scanner may use. CPU and memory limits
+// need cgroups v2 (Linux); where they cannot be applied the scanner is
+// deprioritised with nice/ionice instead.
+type Limits struct {
+	CPUPercent int    `yaml:"cpu_percent"` // 100 = one full core
+	MemoryMB   int    `yaml:"memory_mb"`
+	Nice       int    `yaml:"nice"`     // -20..19
+	IOClass    string `yaml:"io_class"` // "best-effort" or "idle"
+	IOLevel    int    `yaml:"io_level"` // 0..7 for best-effort
+}
+
+// fallbackNice is applied when a CPU limit is configured but cgroups are not
+// available and no explicit nice value was given.
+const fallbackNice = 10
+
+func (l Limits) empty() bool {
+	return l == Limits{}
+}
+
+func (l Limits) validate() error {
+	if l.CPUPercent < 0 || l.MemoryMB < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if l.Nice < -20 || l.Nice > 19 {
+		return fmt.Errorf("nice must be between -20 and 19")
+	}
+	switch l.IOClass {
+	case "", "best-effort", "idle":
+	default:
+		return fmt.Errorf("unknown io_class %q", l.IOClass)
+	}
+	if l.IOLevel < 0 || l.IOLevel > 7 {
+		return fmt.Errorf("io_level must be between 0 and 7")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+const (
+	cgroupCPUPeriod = 100000 // microseconds
+
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+)
+
+// applyLimits places pid into a per-scanner cgroup and sets its priority.
+func (m *Manager) applyLimits(s Scanner, pid int) {
+	l := s.Limits
+	if l.empty() {
+		return
+	}
+	nice := l.Nice
+	if l.CPUPercent > 0 || l.MemoryMB > 0 {
+		if err := m.joinCgroup(s.Name, pid, l); err != nil {
+			log.Printf("%s: cgroup limits not applied: %v", s.Name, err)
+			if l.MemoryMB > 0 {
+				log.Printf("%s: memory limit needs cgroups v2", s.Name)
+			}
+			if nice == 0 && l.CPUPercent > 0 {
+				nice = fallbackNice
+			}
+		}
+	}
+	if nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice); err != nil {
+			log.Printf("%s: set nice %d: %v", s.Name, nice, err)
+		}
+	}
+	if l.IOClass != "" {
+		if err := setIOPriority(pid, l.IOClass, l.IOLevel); err != nil {
+			log.Printf("%s: set io priority: %v", s.Name, err)
+		}
+	}
+}
+
+// releaseLimits removes the scanner's cgroup once its process has exited.
+func (m *Manager) releaseLimits(s Scanner) {
+	if m.CgroupRoot == "" || (s.Limits.CPUPercent == 0 && s.Limits.MemoryMB == 0) {
+		return
+	}
+	_ = os.Remove(filepath.Join(m.CgroupRoot, s.Name))
+}
+
+// joinCgroup creates CgroupRoot/<name>, writes the limits and moves pid into
+// it. CgroupRoot must live directly in a cgroup v2 hierarchy.
+func (m *Manager) joinCgroup(name string, pid int, l Limits) error {
+	if m.CgroupRoot == "" {
+		return fmt.Errorf("no cgroup root configured")
+	}
+	parent := filepath.Dir(m.CgroupRoot)
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroups v2 not mounted at %s", parent)
+	}
+	dir := filepath.Join(m.CgroupRoot, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Controllers have to be enabled for the children of each level; this
+	// fails harmlessly when they already are.
+	_ = os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+cpu +memory"), 0o644)
+	_ = os.WriteFile(filepath.Join(m.CgroupRoot, "cgroup.subtree_control"), []byte("+cpu +memory"), 0o644)
+
+	if l.CPUPercent > 0 {
+		quota := l.CPUPercent * cgroupCPUPeriod / 100
+		val := fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(val), 0o644); err != nil {
+			return fmt.Errorf("cpu.max: %w", err)
+		}
+	}
+	if l.MemoryMB > 0 {
+		val := strconv.FormatInt(int64(l.MemoryMB)<<20, 10)
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(val), 0o644); err != nil {
+			return fmt.Errorf("memory.max: %w", err)
+		}
+	}
+	return os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0o644)
+}
+
+// setIOPriority is the ionice equivalent for a single process.
+func setIOPriority(pid int, class string, level int) error {
+	var prio int
+	switch class {
+	case "idle":
+		prio = ioprioClassIdle << ioprioClassShift
+	default:
+		prio = ioprioClassBE<<ioprioClassShift | level
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
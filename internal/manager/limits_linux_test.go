@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinCgroupWritesLimits(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0o644)
+
+	m := New(nil, root, root)
+	m.CgroupRoot = filepath.Join(root, "vpn-scanners")
+	if err := m.joinCgroup("fortinet", 1234, Limits{CPUPercent: 50, MemoryMB: 256}); err != nil {
+		t.Fatalf("joinCgroup: %v", err)
+	}
+	dir := filepath.Join(m.CgroupRoot, "fortinet")
+	for file, want := range map[string]string{
+		"cpu.max":      "50000 100000",
+		"memory.max":   "268435456",
+		"cgroup.procs": "1234",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || string(data) != want {
+			t.Fatalf("%s = %q, %v; want %q", file, data, err, want)
+		}
+	}
+
+	m.CgroupRoot = filepath.Join(t.TempDir(), "vpn-scanners")
+	if err := m.joinCgroup("fortinet", 1234, Limits{CPUPercent: 50}); err == nil {
+		t.Fatal("expected error without cgroups v2")
+	}
+}
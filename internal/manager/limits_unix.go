@@ -0,0 +1,31 @@
+//go:build !linux && !windows
+
+package manager
+
+import (
+	"log"
+	"syscall"
+)
+
+// applyLimits lowers the priority of pid. CPU and memory limits and I/O
+// priorities are only supported on Linux, so a CPU limit falls back to nice.
+func (m *Manager) applyLimits(s Scanner, pid int) {
+	l := s.Limits
+	if l.empty() {
+		return
+	}
+	nice := l.Nice
+	if nice == 0 && l.CPUPercent > 0 {
+		nice = fallbackNice
+	}
+	if l.MemoryMB > 0 || l.IOClass != "" {
+		log.Printf("%s: memory and io limits are not supported on this platform", s.Name)
+	}
+	if nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice); err != nil {
+			log.Printf("%s: set nice %d: %v", s.Name, nice, err)
+		}
+	}
+}
+
+func (m *Manager) releaseLimits(s Scanner) {}
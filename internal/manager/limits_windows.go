@@ -0,0 +1,14 @@
+//go:build windows
+
+package manager
+
+import "log"
+
+// applyLimits is not implemented on Windows.
+func (m *Manager) applyLimits(s Scanner, pid int) {
+	if !s.Limits.empty() {
+		log.Printf("%s: resource limits are not supported on Windows", s.Name)
+	}
+}
+
+func (m *Manager) releaseLimits(s Scanner) {}
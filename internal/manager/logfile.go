@@ -0,0 +1,209 @@
+package manager
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxLogSize is the size after which a scanner log is rotated.
+	DefaultMaxLogSize = 10 << 20
+	// DefaultMaxLogFiles is how many logs are kept per scanner.
+	DefaultMaxLogFiles = 5
+
+	logTimeFormat = "20060102-150405"
+)
+
+// logWriter writes scanner output to logs/<name>-<timestamp>.log and starts
+// a new file once the current one grows past maxSize.
+type logWriter struct {
+	mu       sync.Mutex
+	dir      string
+	name     string
+	maxSize  int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func newLogWriter(dir, name string, maxSize int64, maxFiles int) (*logWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &logWriter{dir: dir, name: name, maxSize: maxSize, maxFiles: maxFiles}
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file.
+func (w *logWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// rotate closes the current file, opens a new one and prunes old logs.
+func (w *logWriter) rotate() error {
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+	}
+	stamp := time.Now().Format(logTimeFormat)
+	path := filepath.Join(w.dir, fmt.Sprintf("%s-%s.log", w.name, stamp))
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(w.dir, fmt.Sprintf("%s-%s.%d.log", w.name, stamp, i))
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.f = f
+	w.size = 0
+	w.prune()
+	return nil
+}
+
+// prune removes the oldest logs beyond maxFiles.
+func (w *logWriter) prune() {
+	if w.maxFiles <= 0 {
+		return
+	}
+	files, err := logFiles(w.dir, w.name)
+	if err != nil || len(files) <= w.maxFiles {
+		return
+	}
+	for _, f := range files[:len(files)-w.maxFiles] {
+		os.Remove(f)
+	}
+}
+
+// logFiles returns the logs of one scanner ordered from oldest to newest.
+func logFiles(dir, name string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, name+"-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	infos := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if st, err := os.Stat(f); err == nil {
+			infos[f] = st.ModTime()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		ti, tj := infos[files[i]], infos[files[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i] < files[j]
+	})
+	return files, nil
+}
+
+// LatestLog returns the path of the newest log for the named scanner.
+func (m *Manager) LatestLog(name string) (string, error) {
+	files, err := logFiles(m.LogDir, name)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no logs for %s in %s", name, m.LogDir)
+	}
+	return files[len(files)-1], nil
+}
+
+// TailLog writes the last n lines of the newest log of the named scanner to
+// out. With follow set it keeps printing new output, switching to a fresh
+// file after rotation, until ctx is cancelled.
+func (m *Manager) TailLog(ctx context.Context, out io.Writer, name string, n int, follow bool) error {
+	path, err := m.LatestLog(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	lines, err := lastLines(f, n)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		fmt.Fprintln(out, l)
+	}
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if _, err := io.Copy(out, f); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if latest, err := m.LatestLog(name); err == nil && latest != path {
+			io.Copy(out, f)
+			f.Close()
+			if f, err = os.Open(latest); err != nil {
+				return err
+			}
+			path = latest
+		}
+	}
+}
+
+// lastLines returns up to n trailing lines of r, leaving r at its end.
+func lastLines(r io.Reader, n int) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if n <= 0 {
+		lines = nil
+	}
+	return lines, sc.Err()
+}
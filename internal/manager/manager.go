@@ -0,0 +1,201 @@
+// Package manager builds, starts and supervises the VPN scanner processes
+// used by cmd/manager.
+package manager
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Scanner describes how to run a single VPN scanner.
+type Scanner struct {
+	Name      string            `yaml:"-"`
+	Script    string            `yaml:"script"` // Go source file or Python script
+	Binary    string            `yaml:"binary"` // prebuilt executable, used instead of Script
+	CredsFile string            `yaml:"creds_file"`
+	Args      []string          `yaml:"args"`
+	Env       map[string]string `yaml:"env"`
+	Dir       string            `yaml:"dir"` // working directory of the process
+	Limits    Limits            `yaml:"limits"`
+}
+
+// program returns the file the scanner is started from.
+func (s Scanner) program() string {
+	if s.Binary != "" {
+		return s.Binary
+	}
+	return s.Script
+}
+
+// DefaultScanners mirrors the scanners shipped with the repository.
+var DefaultScanners = map[string]Scanner{
+	"fortinet":   {Script: "sers1.go", CredsFile: "creds/fortinet.txt"},
+	"paloalto":   {Script: "sers2.go", CredsFile: "creds/paloalto.txt"},
+	"sonicwall":  {Script: "sers3.go", CredsFile: "creds/sonicwall.txt"},
+	"cisco":      {Script: "sers4.go", CredsFile: "creds/cisco.txt"},
+	"sophos":     {Script: "test_scanner.go", CredsFile: "creds/sophos.txt", Args: []string{"--vpn-type", "sophos"}},
+	"watchguard": {Script: "test_scanner.go", CredsFile: "creds/watchguard.txt", Args: []string{"--vpn-type", "watchguard"}},
+	"zyxel":      {Script: "test_scanner.go", CredsFile: "creds/zyxel.txt", Args: []string{"--vpn-type", "zyxel"}},
+	"barracuda":  {Script: "test_scanner.go", CredsFile: "creds/barracuda.txt", Args: []string{"--vpn-type", "barracuda"}},
+}
+
+// Manager owns the scanner definitions and the directories used for built
+// binaries and PID files.
+type Manager struct {
+	scanners map[string]Scanner
+	binDir   string
+	runDir   string
+
+	// CgroupRoot is where per-scanner cgroups are created on Linux.
+	CgroupRoot string
+
+	// LogDir receives the output of each scanner; when empty the output goes
+	// to the manager's own stdout/stderr.
+	LogDir      string
+	MaxLogSize  int64
+	MaxLogFiles int
+
+	// Restart backoff for crashed scanners.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// A run lasting at least StableAfter resets the backoff.
+	StableAfter time.Duration
+	// MaxRestarts limits how often a crashed scanner is restarted; zero
+	// means no limit.
+	MaxRestarts int
+	// Events receives restart and give-up events, e.g. the database logs
+	// table. It may be nil.
+	Events EventLogger
+}
+
+// EventLogger records supervision events. *db.DB satisfies it.
+type EventLogger interface {
+	InsertLog(level, message, source string) error
+}
+
+// New returns Manager for the given scanners. Binaries are placed in binDir
+// and PID files in runDir.
+func New(scanners map[string]Scanner, binDir, runDir string) *Manager {
+	m := &Manager{
+		scanners:    make(map[string]Scanner, len(scanners)),
+		binDir:      binDir,
+		runDir:      runDir,
+		CgroupRoot:  DefaultCgroupRoot,
+		LogDir:      "logs",
+		MaxLogSize:  DefaultMaxLogSize,
+		MaxLogFiles: DefaultMaxLogFiles,
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Minute,
+		StableAfter: time.Minute,
+	}
+	for name, s := range scanners {
+		s.Name = name
+		m.scanners[name] = s
+	}
+	return m
+}
+
+// Names returns the configured scanner names in sorted order.
+func (m *Manager) Names() []string {
+	names := make([]string, 0, len(m.scanners))
+	for n := range m.scanners {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve expands "all" or an empty string to every scanner and validates
+// explicit names.
+func (m *Manager) Resolve(name string) ([]string, error) {
+	if name == "" || name == "all" {
+		return m.Names(), nil
+	}
+	if _, ok := m.scanners[name]; !ok {
+		return nil, fmt.Errorf("unknown vpn %s", name)
+	}
+	return []string{name}, nil
+}
+
+// Status describes the state of one scanner.
+type Status struct {
+	Name    string `json:"name"`
+	PIDs    []int  `json:"pids"`
+	Running bool   `json:"running"`
+	// Tracked is false when the process was discovered by its command line
+	// rather than through a PID file.
+	Tracked bool `json:"tracked"`
+	// Restarts counts crash restarts during the current supervision.
+	Restarts int `json:"restarts"`
+}
+
+// Status reports every scanner based on its PID file, falling back to
+// process discovery for scanners started outside the manager.
+func (m *Manager) Status() []Status {
+	var out []Status
+	for _, name := range m.Names() {
+		st := Status{Name: name}
+		st.Restarts, _ = readPID(m.restartsPath(name))
+		if pid, err := readPID(m.pidPath(name)); err == nil && processAlive(pid) {
+			st.PIDs = []int{pid}
+			st.Running = true
+			st.Tracked = true
+		} else if pids := findProcesses(m.scanners[name]); len(pids) > 0 {
+			st.PIDs = pids
+			st.Running = true
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// Stop terminates the given scanners. A stop marker is left for each of them
+// so that a running supervisor does not restart the process. Untracked
+// scanner processes are terminated as well.
+func (m *Manager) Stop(names []string) {
+	for _, name := range names {
+		path := m.pidPath(name)
+		pid, err := readPID(path)
+		if err != nil {
+			for _, p := range findProcesses(m.scanners[name]) {
+				if err := terminate(p, false); err != nil {
+					log.Printf("stop %s (PID %d): %v", name, p, err)
+					continue
+				}
+				fmt.Printf("stopped %s (PID %d)\n", name, p)
+			}
+			continue
+		}
+		if processAlive(pid) {
+			if err := writePID(m.stopPath(name), pid); err != nil {
+				log.Printf("stop %s: write marker: %v", name, err)
+			}
+			if err := terminate(pid, true); err != nil {
+				log.Printf("stop %s (PID %d): %v", name, pid, err)
+				continue
+			}
+			fmt.Printf("stopped %s (PID %d)\n", name, pid)
+		}
+		removePID(path)
+	}
+}
+
+func (m *Manager) pidPath(name string) string {
+	return filepath.Join(m.runDir, name+".pid")
+}
+
+func (m *Manager) stopPath(name string) string {
+	return filepath.Join(m.runDir, name+".stop")
+}
+
+// restartsPath holds the restart counter; it uses the PID file format.
+func (m *Manager) restartsPath(name string) string {
+	return filepath.Join(m.runDir, name+".restarts")
+}
+
+func (m *Manager) supervisorPath() string {
+	return filepath.Join(m.runDir, "manager.pid")
+}
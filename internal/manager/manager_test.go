@@ -0,0 +1,228 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	if got := nextBackoff(time.Second, time.Minute); got != 2*time.Second {
+		t.Fatalf("expected 2s, got %v", got)
+	}
+	if got := nextBackoff(45*time.Second, time.Minute); got != time.Minute {
+		t.Fatalf("expected backoff capped at 1m, got %v", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	m := New(DefaultScanners, t.TempDir(), t.TempDir())
+	all, err := m.Resolve("all")
+	if err != nil || len(all) != len(DefaultScanners) {
+		t.Fatalf("Resolve(all) = %v, %v", all, err)
+	}
+	if _, err := m.Resolve("nope"); err == nil {
+		t.Fatal("expected error for unknown scanner")
+	}
+}
+
+func TestBinaryPathVersioned(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "scan.go")
+	os.WriteFile(script, []byte("package main\nfunc main() {}\n"), 0o644)
+
+	m := New(nil, filepath.Join(dir, "bin"), dir)
+	p1, err := m.binaryPath(Scanner{Script: script})
+	if err != nil {
+		t.Fatalf("binaryPath: %v", err)
+	}
+	os.WriteFile(script, []byte("package main\nfunc main() { println() }\n"), 0o644)
+	p2, _ := m.binaryPath(Scanner{Script: script})
+	if p1 == p2 || !strings.HasPrefix(filepath.Base(p1), "scan-") {
+		t.Fatalf("expected distinct versioned names, got %s and %s", p1, p2)
+	}
+}
+
+// writeCrashScript writes a Go scanner that appends to counter and exits
+// with status 1.
+func writeCrashScript(t *testing.T, dir, counter string) string {
+	t.Helper()
+	script := filepath.Join(dir, "crash.go")
+	src := `package main
+
+import "os"
+
+func main() {
+	f, _ := os.OpenFile(` + "`" + counter + "`" + `, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("x")
+	f.Close()
+	os.Exit(1)
+}
+`
+	if err := os.WriteFile(script, []byte(src), 0o644); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return script
+}
+
+func TestSuperviseRestartsCrashedScanner(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a scanner binary")
+	}
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	script := writeCrashScript(t, dir, counter)
+
+	m := New(map[string]Scanner{"crash": {Script: script}}, filepath.Join(dir, "bin"), filepath.Join(dir, "run"))
+	m.LogDir = filepath.Join(dir, "logs")
+	m.MinBackoff = 10 * time.Millisecond
+	m.MaxBackoff = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Supervise(ctx, []string{"crash"}) }()
+
+	deadline := time.Now().Add(60 * time.Second)
+	for {
+		data, _ := os.ReadFile(counter)
+		if len(data) >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("scanner was not restarted, runs=%d", len(data))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Supervise: %v", err)
+	}
+	if _, err := os.Stat(m.supervisorPath()); !os.IsNotExist(err) {
+		t.Fatalf("supervisor PID file not removed: %v", err)
+	}
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !processAlive(os.Getpid()) {
+		t.Fatal("expected current process to be alive")
+	}
+	if processAlive(-1) {
+		t.Fatal("expected invalid PID to be reported as not running")
+	}
+}
+
+func TestLogWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newLogWriter(dir, "fortinet", 16, 2)
+	if err != nil {
+		t.Fatalf("newLogWriter: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write([]byte("0123456789\n")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	w.Close()
+
+	files, err := logFiles(dir, "fortinet")
+	if err != nil {
+		t.Fatalf("logFiles: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 log files after pruning, got %v", files)
+	}
+
+	m := New(nil, dir, dir)
+	m.LogDir = dir
+	var buf strings.Builder
+	if err := m.TailLog(context.Background(), &buf, "fortinet", 1, false); err != nil {
+		t.Fatalf("TailLog: %v", err)
+	}
+	if buf.String() != "0123456789\n" {
+		t.Fatalf("unexpected tail %q", buf.String())
+	}
+}
+
+func TestLoadScanners(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scanners.yaml")
+	os.WriteFile(path, []byte(`scanners:
+  custom:
+    binary: /opt/scan
+    args: ["-x"]
+    env:
+      SCAN_MODE: fast
+    dir: /tmp
+`), 0o644)
+
+	scanners, err := LoadScanners(path)
+	if err != nil {
+		t.Fatalf("LoadScanners: %v", err)
+	}
+	s := scanners["custom"]
+	if s.Binary != "/opt/scan" || s.Env["SCAN_MODE"] != "fast" || s.Dir != "/tmp" || len(s.Args) != 1 {
+		t.Fatalf("unexpected scanner %+v", s)
+	}
+
+	os.WriteFile(path, []byte("scanners:\n  broken:\n    args: [\"-x\"]\n"), 0o644)
+	if _, err := LoadScanners(path); err == nil {
+		t.Fatal("expected error for scanner without script or binary")
+	}
+}
+
+func TestMatchesScanner(t *testing.T) {
+	s := Scanner{Script: "test_scanner.go", Args: []string{"--vpn-type", "sophos"}}
+	if !matchesScanner(s, []string{"bin/test_scanner-0123456789ab", "--vpn-type", "sophos"}) {
+		t.Fatal("expected versioned binary to match")
+	}
+	if matchesScanner(s, []string{"bin/test_scanner-0123456789ab", "--vpn-type", "watchguard"}) {
+		t.Fatal("expected different args not to match")
+	}
+	if matchesScanner(s, []string{"bash", "-c", "cat test_scanner.go --vpn-type sophos"}) {
+		t.Fatal("expected shell mentioning the script not to match")
+	}
+}
+
+type recordedEvents struct {
+	levels []string
+}
+
+func (r *recordedEvents) InsertLog(level, message, source string) error {
+	r.levels = append(r.levels, level)
+	return nil
+}
+
+func TestSuperviseGivesUpAfterMaxRestarts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a scanner binary")
+	}
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	script := writeCrashScript(t, dir, counter)
+
+	events := &recordedEvents{}
+	m := New(map[string]Scanner{"crash": {Script: script}}, filepath.Join(dir, "bin"), filepath.Join(dir, "run"))
+	m.LogDir = filepath.Join(dir, "logs")
+	m.MinBackoff = time.Millisecond
+	m.MaxBackoff = time.Millisecond
+	m.MaxRestarts = 2
+	m.Events = events
+
+	if err := m.Supervise(context.Background(), []string{"crash"}); err != nil {
+		t.Fatalf("Supervise: %v", err)
+	}
+	if data, _ := os.ReadFile(counter); len(data) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(data))
+	}
+	if st := m.Status(); st[0].Restarts != 2 || st[0].Running {
+		t.Fatalf("unexpected status %+v", st[0])
+	}
+	want := []string{"warning", "warning", "error"}
+	if strings.Join(events.levels, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, events.levels)
+	}
+}
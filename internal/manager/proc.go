@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	ok, err := process.PidExists(int32(pid))
+	return err == nil && ok
+}
+
+// findProcesses returns PIDs of running processes whose command line looks
+// like s. It is used to discover scanners that are not tracked by a PID
+// file, e.g. ones started by hand or by an older manager.
+func findProcesses(s Scanner) []int {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	self := int32(os.Getpid())
+
+	var pids []int
+	for _, p := range procs {
+		if p.Pid == self {
+			continue
+		}
+		argv, err := p.CmdlineSlice()
+		if err != nil || !matchesScanner(s, argv) {
+			continue
+		}
+		pids = append(pids, int(p.Pid))
+	}
+	return pids
+}
+
+// matchesScanner reports whether argv runs the program of s (its script, a
+// versioned binary built from it, or its prebuilt binary) with all of s.Args.
+func matchesScanner(s Scanner, argv []string) bool {
+	prog := s.program()
+	base := strings.TrimSuffix(filepath.Base(prog), filepath.Ext(prog))
+	found := false
+	for _, a := range argv {
+		b := filepath.Base(a)
+		b = strings.TrimSuffix(b, filepath.Ext(b))
+		if b == base || strings.HasPrefix(b, base+"-") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, want := range s.Args {
+		if !containsArg(argv, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsArg(argv []string, want string) bool {
+	for _, a := range argv {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package manager
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the child in its own process group so that helper
+// processes spawned by a scanner are stopped together with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminate sends SIGTERM to pid, or to its whole process group when group
+// is set.
+func terminate(pid int, group bool) error {
+	if group {
+		if err := syscall.Kill(-pid, syscall.SIGTERM); err == nil {
+			return nil
+		}
+	}
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// forceKill sends SIGKILL to pid or its process group.
+func forceKill(pid int, group bool) error {
+	if group {
+		if err := syscall.Kill(-pid, syscall.SIGKILL); err == nil {
+			return nil
+		}
+	}
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
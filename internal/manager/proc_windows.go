@@ -0,0 +1,55 @@
+//go:build windows
+
+package manager
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// setProcessGroup starts the child in a new process group so console
+// interrupts sent to the manager do not reach it directly.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminate stops pid and, when group is set, all of its descendants.
+// Windows has no SIGTERM, so this is the same as forceKill.
+func terminate(pid int, group bool) error {
+	return forceKill(pid, group)
+}
+
+// forceKill kills pid and, when group is set, all of its descendants.
+func forceKill(pid int, group bool) error {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return err
+	}
+	procs := []*process.Process{p}
+	if group {
+		procs = processTree(p)
+	}
+	// Kill children first so they are not re-parented while we iterate.
+	var firstErr error
+	for i := len(procs) - 1; i >= 0; i-- {
+		if err := procs[i].Kill(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// processTree returns p followed by all of its descendants.
+func processTree(p *process.Process) []*process.Process {
+	tree := []*process.Process{p}
+	children, err := p.Children()
+	if err != nil {
+		return tree
+	}
+	for _, c := range children {
+		tree = append(tree, processTree(c)...)
+	}
+	return tree
+}
@@ -0,0 +1,214 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// stopTimeout is how long a scanner gets to exit after SIGTERM before it is
+// killed.
+const stopTimeout = 10 * time.Second
+
+// Supervise starts the named scanners and keeps them running until ctx is
+// cancelled. Crashed scanners are restarted with exponential backoff up to
+// MaxRestarts times; a scanner that exits cleanly is not restarted.
+func (m *Manager) Supervise(ctx context.Context, names []string) error {
+	if pid, err := readPID(m.supervisorPath()); err == nil && pid != os.Getpid() && processAlive(pid) {
+		return fmt.Errorf("manager already running (PID %d)", pid)
+	}
+	if err := writePID(m.supervisorPath(), os.Getpid()); err != nil {
+		return err
+	}
+	defer removePID(m.supervisorPath())
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		s, ok := m.scanners[name]
+		if !ok {
+			log.Printf("unknown vpn %s", name)
+			continue
+		}
+		if pid, err := readPID(m.pidPath(name)); err == nil && processAlive(pid) {
+			fmt.Printf("scanner %s already running (PID %d)\n", name, pid)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.supervise(ctx, s)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Launch starts supervising the named scanner in the background, like
+// Supervise, but returns as soon as the scanner's program is ready so that
+// an unknown name, a scanner that is already running or a failed build is
+// reported to the caller. The returned channel is closed when supervision
+// ends.
+func (m *Manager) Launch(ctx context.Context, name string) (<-chan struct{}, error) {
+	s, ok := m.scanners[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown vpn %s", name)
+	}
+	if pid, err := readPID(m.pidPath(name)); err == nil && processAlive(pid) {
+		return nil, fmt.Errorf("scanner %s already running (PID %d)", name, pid)
+	}
+	if pids := findProcesses(s); len(pids) > 0 {
+		return nil, fmt.Errorf("scanner %s already running (PID %d)", name, pids[0])
+	}
+	if _, err := m.command(s); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.supervise(ctx, s)
+	}()
+	return done, nil
+}
+
+// supervise runs a single scanner until ctx is done or it exits cleanly.
+func (m *Manager) supervise(ctx context.Context, s Scanner) {
+	backoff := m.MinBackoff
+	pidPath := m.pidPath(s.Name)
+	stopPath := m.stopPath(s.Name)
+	restartsPath := m.restartsPath(s.Name)
+	removePID(stopPath)
+	restarts := 0
+	writePID(restartsPath, restarts)
+
+	var output io.Writer
+	if m.LogDir != "" {
+		lw, err := newLogWriter(m.LogDir, s.Name, m.MaxLogSize, m.MaxLogFiles)
+		if err != nil {
+			log.Printf("%s: open log: %v", s.Name, err)
+			return
+		}
+		defer lw.Close()
+		output = lw
+	}
+
+	for {
+		args, err := m.command(s)
+		if err != nil {
+			log.Printf("%s: %v", s.Name, err)
+			return
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = s.Dir
+		cmd.Env = environ(s)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if output != nil {
+			cmd.Stdout = output
+			cmd.Stderr = output
+		}
+		setProcessGroup(cmd)
+
+		started := time.Now()
+		var runErr error
+		if err := cmd.Start(); err != nil {
+			runErr = err
+		} else {
+			pid := cmd.Process.Pid
+			if err := writePID(pidPath, pid); err != nil {
+				log.Printf("%s: write pid file: %v", s.Name, err)
+			}
+			m.applyLimits(s, pid)
+			fmt.Printf("started %s (PID %d)\n", s.Name, pid)
+			if output != nil {
+				fmt.Fprintf(output, "=== %s started %s (PID %d) ===\n", time.Now().Format(time.RFC3339), s.Name, pid)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- cmd.Wait() }()
+
+			select {
+			case runErr = <-done:
+				removePID(pidPath)
+				m.releaseLimits(s)
+			case <-ctx.Done():
+				stopProcess(pid, done)
+				removePID(pidPath)
+				m.releaseLimits(s)
+				fmt.Printf("stopped %s (PID %d)\n", s.Name, pid)
+				return
+			}
+			if runErr == nil {
+				fmt.Printf("%s finished\n", s.Name)
+				return
+			}
+			if _, err := os.Stat(stopPath); err == nil {
+				removePID(stopPath)
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) >= m.StableAfter {
+			backoff = m.MinBackoff
+		}
+		if m.MaxRestarts > 0 && restarts >= m.MaxRestarts {
+			msg := fmt.Sprintf("%s crashed: %v; giving up after %d restarts", s.Name, runErr, restarts)
+			log.Print(msg)
+			m.event("error", msg)
+			return
+		}
+		restarts++
+		writePID(restartsPath, restarts)
+		msg := fmt.Sprintf("%s crashed: %v; restart %d in %v", s.Name, runErr, restarts, backoff)
+		log.Print(msg)
+		m.event("warning", msg)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = nextBackoff(backoff, m.MaxBackoff)
+	}
+}
+
+// event forwards a supervision event to m.Events.
+func (m *Manager) event(level, msg string) {
+	if m.Events == nil {
+		return
+	}
+	if err := m.Events.InsertLog(level, msg, "manager"); err != nil {
+		log.Printf("log insert error: %v", err)
+	}
+}
+
+// stopProcess terminates the process group and waits for the exit reported
+// on done, escalating to a kill after stopTimeout.
+func stopProcess(pid int, done <-chan error) {
+	if err := terminate(pid, true); err != nil {
+		log.Printf("terminate PID %d: %v", pid, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(stopTimeout):
+		if err := forceKill(pid, true); err != nil {
+			log.Printf("kill PID %d: %v", pid, err)
+		}
+		<-done
+	}
+}
+
+func nextBackoff(cur, max time.Duration) time.Duration {
+	next := cur * 2
+	if next > max {
+		return max
+	}
+	return next
+}
@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// maxDigestEvents caps the events kept between two digests; older ones are
+// counted but dropped.
+const maxDigestEvents = 500
+
+// Mailer sends plain-text mail over SMTP. STARTTLS is used when the server
+// offers it.
+type Mailer struct {
+	cfg config.EmailConfig
+	// send is smtp.SendMail, replaced in tests.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer validates cfg and returns a mailer for it.
+func NewMailer(cfg config.EmailConfig) (*Mailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("email: host is required")
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("email: from and to are required")
+	}
+	return &Mailer{cfg: cfg, send: smtp.SendMail}, nil
+}
+
+// Send delivers a message with subject and body to the configured
+// recipients.
+func (m *Mailer) Send(subject, body string) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	return m.send(addr, auth, m.cfg.From, m.cfg.To, m.message(subject, body))
+}
+
+func (m *Mailer) message(subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// Digest collects events and mails them in one message on Flush.
+type Digest struct {
+	mailer *Mailer
+
+	mu      sync.Mutex
+	events  []Event
+	dropped int
+}
+
+// NewDigest returns a digest sending through m.
+func NewDigest(m *Mailer) *Digest {
+	return &Digest{mailer: m}
+}
+
+// Add queues e for the next digest.
+func (d *Digest) Add(e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.events) >= maxDigestEvents {
+		d.dropped++
+		return
+	}
+	d.events = append(d.events, e)
+}
+
+// Flush mails the queued events, if any. On failure the events are kept
+// for the next attempt.
+func (d *Digest) Flush() error {
+	d.mu.Lock()
+	events, dropped := d.events, d.dropped
+	d.events, d.dropped = nil, 0
+	d.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+	subject, body := formatDigest(events, dropped)
+	if err := d.mailer.Send(subject, body); err != nil {
+		d.mu.Lock()
+		d.events = append(events, d.events...)
+		d.dropped += dropped
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+var kindTitles = map[string]string{
+	KindAlert:   "Alerts",
+	KindFinding: "New findings",
+	KindJob:     "Finished runs",
+}
+
+// formatDigest groups events by kind, alerts first.
+func formatDigest(events []Event, dropped int) (string, string) {
+	byKind := make(map[string][]Event)
+	for _, e := range events {
+		byKind[e.Kind] = append(byKind[e.Kind], e)
+	}
+	var counts []string
+	var b strings.Builder
+	for _, kind := range []string{KindAlert, KindFinding, KindJob} {
+		list := byKind[kind]
+		if len(list) == 0 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+		counts = append(counts, fmt.Sprintf("%d %s", len(list), strings.ToLower(kindTitles[kind])))
+		fmt.Fprintf(&b, "%s (%d)\n", kindTitles[kind], len(list))
+		for _, e := range list {
+			fmt.Fprintf(&b, "  %s  %s\n", e.Time.UTC().Format("2006-01-02 15:04:05"), e.Text)
+		}
+		b.WriteString("\n")
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "%d more events were not included.\n", dropped)
+	}
+	return "VPN scanner digest: " + strings.Join(counts, ", "), b.String()
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/alerting"
+	"vpn-bruteforce-client/internal/config"
+)
+
+type sent struct {
+	addr string
+	to   []string
+	msg  string
+}
+
+func testMailer(t *testing.T, out *[]sent, fail *bool) *Mailer {
+	m, err := NewMailer(config.EmailConfig{Host: "smtp.example.com", Port: 587, From: "scan@example.com", To: []string{"ops@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if fail != nil && *fail {
+			return errors.New("connection refused")
+		}
+		*out = append(*out, sent{addr, to, string(msg)})
+		return nil
+	}
+	return m
+}
+
+func TestNewMailerValidates(t *testing.T) {
+	if _, err := NewMailer(config.EmailConfig{Host: "h"}); err == nil {
+		t.Fatal("expected error without from/to")
+	}
+}
+
+func TestDigestGroupsEvents(t *testing.T) {
+	var out []sent
+	d := NewDigest(testMailer(t, &out, nil))
+	if err := d.Flush(); err != nil || len(out) != 0 {
+		t.Fatalf("empty digest sent mail: %v %v", out, err)
+	}
+
+	now := time.Now()
+	d.Add(Event{Kind: KindFinding, Time: now, Text: "fortinet 1.1.1.1 user admin"})
+	d.Add(Event{Kind: KindJob, Time: now, Text: "run r1 finished"})
+	AlertNotifier{Sink: d}.Notify(context.Background(), alerting.Alert{Severity: "critical", Message: "ipblock-spike", Time: now})
+
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0].addr != "smtp.example.com:587" {
+		t.Fatalf("unexpected mail %+v", out)
+	}
+	msg := out[0].msg
+	for _, want := range []string{"Subject: VPN scanner digest: 1 alerts, 1 new findings, 1 finished runs", "[critical] ipblock-spike", "1.1.1.1", "run r1"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, msg)
+		}
+	}
+	if strings.Index(msg, "Alerts") > strings.Index(msg, "New findings") {
+		t.Error("alerts should come first")
+	}
+}
+
+func TestDigestKeepsEventsOnFailure(t *testing.T) {
+	var out []sent
+	fail := true
+	d := NewDigest(testMailer(t, &out, &fail))
+	d.Add(Event{Kind: KindJob, Time: time.Now(), Text: "run r1 finished"})
+	if err := d.Flush(); err == nil {
+		t.Fatal("expected send error")
+	}
+	fail = false
+	if err := d.Flush(); err != nil || len(out) != 1 {
+		t.Fatalf("retry: %v %v", out, err)
+	}
+}
@@ -0,0 +1,46 @@
+// Package notify delivers operator notifications about new findings,
+// finished runs and alerts.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"vpn-bruteforce-client/internal/alerting"
+)
+
+// Event kinds.
+const (
+	KindFinding = "finding"
+	KindJob     = "job"
+	KindAlert   = "alert"
+)
+
+// Event is a single notification-worthy occurrence.
+type Event struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// AlertEvent converts an alert into an event.
+func AlertEvent(a alerting.Alert) Event {
+	return Event{Kind: KindAlert, Time: a.Time, Text: fmt.Sprintf("[%s] %s", a.Severity, a.Message)}
+}
+
+// Sink receives events.
+type Sink interface {
+	Add(e Event)
+}
+
+// AlertNotifier adapts a Sink to alerting.Notifier.
+type AlertNotifier struct {
+	Sink Sink
+}
+
+// Notify passes a to the sink.
+func (n AlertNotifier) Notify(ctx context.Context, a alerting.Alert) error {
+	n.Sink.Add(AlertEvent(a))
+	return nil
+}
@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+// telegramPollTimeout is the long-polling timeout passed to getUpdates.
+const telegramPollTimeout = 30 * time.Second
+
+// Controller is what the bot commands act on.
+type Controller interface {
+	// Status returns a short human-readable summary of the scanners.
+	Status() string
+	StartScanner(vpnType string) error
+	StopScanner(vpnType string) error
+}
+
+// Bot is a Telegram bot that forwards events to the configured chats and
+// answers /status, /start <vendor> and /stop <vendor>.
+type Bot struct {
+	cfg   config.TelegramConfig
+	ctl   Controller
+	chats map[int64]config.TelegramChat
+
+	client *http.Client
+}
+
+// NewBot validates cfg and returns a bot acting on ctl.
+func NewBot(cfg config.TelegramConfig, ctl Controller) (*Bot, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("telegram: token is required")
+	}
+	if len(cfg.Chats) == 0 {
+		return nil, fmt.Errorf("telegram: at least one chat is required")
+	}
+	b := &Bot{
+		cfg:    cfg,
+		ctl:    ctl,
+		chats:  make(map[int64]config.TelegramChat, len(cfg.Chats)),
+		client: &http.Client{Timeout: telegramPollTimeout + 10*time.Second},
+	}
+	for _, c := range cfg.Chats {
+		b.chats[c.ID] = c
+	}
+	return b, nil
+}
+
+// Add sends e to every configured chat. Failures are logged.
+func (b *Bot) Add(e Event) {
+	text := e.Text
+	if title := kindTitles[e.Kind]; title != "" {
+		text = title + ": " + text
+	}
+	for _, c := range b.cfg.Chats {
+		if err := b.send(context.Background(), c.ID, text); err != nil {
+			log.Printf("telegram send error: %v", err)
+		}
+	}
+}
+
+// Run polls for commands until ctx is cancelled.
+func (b *Bot) Run(ctx context.Context) {
+	offset := int64(0)
+	for ctx.Err() == nil {
+		updates, err := b.updates(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("telegram poll error: %v", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil {
+				continue
+			}
+			if reply := b.handle(u.Message.Chat.ID, u.Message.Text); reply != "" {
+				if err := b.send(ctx, u.Message.Chat.ID, reply); err != nil {
+					log.Printf("telegram send error: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// handle executes a command from chat and returns the reply. Unknown chats
+// get no reply.
+func (b *Bot) handle(chat int64, text string) string {
+	c, ok := b.chats[chat]
+	if !ok {
+		log.Printf("telegram: ignoring message from unauthorized chat %d", chat)
+		return ""
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	// Commands may carry the bot name: /status@my_bot.
+	cmd := strings.ToLower(strings.SplitN(strings.TrimPrefix(fields[0], "/"), "@", 2)[0])
+	switch cmd {
+	case "status":
+		return b.ctl.Status()
+	case "start", "stop":
+		if !c.Control {
+			return "This chat may not control scanners."
+		}
+		if len(fields) != 2 {
+			return fmt.Sprintf("Usage: /%s <vendor>", cmd)
+		}
+		vendor := strings.ToLower(fields[1])
+		run, done := b.ctl.StartScanner, "started"
+		if cmd == "stop" {
+			run, done = b.ctl.StopScanner, "stopped"
+		}
+		if err := run(vendor); err != nil {
+			return fmt.Sprintf("%s %s failed: %v", cmd, vendor, err)
+		}
+		return fmt.Sprintf("%s scanner %s.", vendor, done)
+	default:
+		return "Commands: /status, /start <vendor>, /stop <vendor>"
+	}
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+func (b *Bot) updates(ctx context.Context, offset int64) ([]telegramUpdate, error) {
+	var updates []telegramUpdate
+	err := b.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(telegramPollTimeout / time.Second),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+func (b *Bot) send(ctx context.Context, chat int64, text string) error {
+	return b.call(ctx, "sendMessage", map[string]interface{}{"chat_id": chat, "text": text}, nil)
+}
+
+// call invokes a Bot API method and decodes its result into out.
+func (b *Bot) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(b.cfg.APIURL, "/") + "/bot" + b.cfg.Token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// Drop the URL, which contains the token.
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	var res struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !res.OK {
+		return fmt.Errorf("%s: %s", method, res.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(res.Result, out)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+)
+
+type fakeController struct {
+	started, stopped []string
+}
+
+func (f *fakeController) Status() string { return "2 workers" }
+
+func (f *fakeController) StartScanner(v string) error {
+	f.started = append(f.started, v)
+	return nil
+}
+
+func (f *fakeController) StopScanner(v string) error {
+	f.stopped = append(f.stopped, v)
+	return nil
+}
+
+func TestBotAuthorization(t *testing.T) {
+	ctl := &fakeController{}
+	b, err := NewBot(config.TelegramConfig{Token: "t", Chats: []config.TelegramChat{
+		{ID: 1, Control: true},
+		{ID: 2},
+	}}, ctl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.handle(3, "/status"); got != "" {
+		t.Fatalf("unknown chat got reply %q", got)
+	}
+	if got := b.handle(2, "/status@scan_bot"); got != "2 workers" {
+		t.Fatalf("status = %q", got)
+	}
+	if got := b.handle(2, "/stop fortinet"); !strings.Contains(got, "may not") {
+		t.Fatalf("read-only chat could stop: %q", got)
+	}
+	b.handle(1, "/stop Fortinet")
+	b.handle(1, "/start cisco")
+	if len(ctl.stopped) != 1 || ctl.stopped[0] != "fortinet" || len(ctl.started) != 1 || ctl.started[0] != "cisco" {
+		t.Fatalf("controller calls: %+v", ctl)
+	}
+	if got := b.handle(1, "/start"); !strings.HasPrefix(got, "Usage") {
+		t.Fatalf("missing vendor reply %q", got)
+	}
+}
+
+func TestBotSendsEvents(t *testing.T) {
+	var mu sync.Mutex
+	var sentTo []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottok/sendMessage" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var msg struct {
+			ChatID int64  `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		if !strings.HasPrefix(msg.Text, "New findings: ") {
+			t.Errorf("text = %q", msg.Text)
+		}
+		mu.Lock()
+		sentTo = append(sentTo, msg.ChatID)
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	b, err := NewBot(config.TelegramConfig{Token: "tok", APIURL: srv.URL, Chats: []config.TelegramChat{{ID: 1}, {ID: 2}}}, &fakeController{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Add(Event{Kind: KindFinding, Time: time.Now(), Text: "fortinet 1.1.1.1 user admin"})
+	if len(sentTo) != 2 {
+		t.Fatalf("sent to %v", sentTo)
+	}
+}
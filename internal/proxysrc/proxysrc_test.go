@@ -0,0 +1,69 @@
+package proxysrc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	for in, want := range map[string]Proxy{
+		"1.2.3.4:8080":                    {Address: "1.2.3.4:8080"},
+		"1.2.3.4:8080:user:pa:ss":         {Address: "1.2.3.4:8080", Username: "user", Password: "pa:ss"},
+		"SOCKS5://u:p@proxy.example:1080": {Address: "socks5://proxy.example:1080", Username: "u", Password: "p"},
+		"[2001:db8::1]:3128:u:p":          {Address: "[2001:db8::1]:3128", Username: "u", Password: "p"},
+	} {
+		got, err := ParseLine(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLine(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"1.2.3.4", "1.2.3.4:0", "1.2.3.4:80:user", "ftp://1.2.3.4:21", ":8080"} {
+		if _, err := ParseLine(in); err == nil {
+			t.Errorf("ParseLine(%q) accepted", in)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# free proxies\n1.2.3.4:8080\n\n1.2.3.4:8080\nbogus\nhttp://5.6.7.8:3128\n"))
+	}))
+	defer ts.Close()
+
+	proxies, invalid, err := Fetch(context.Background(), ts.Client(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proxies) != 2 || invalid != 1 {
+		t.Fatalf("Fetch = %+v, %d invalid", proxies, invalid)
+	}
+	if _, _, err := Fetch(context.Background(), ts.Client(), ts.URL+"/%zz"); err == nil {
+		t.Fatal("invalid URL accepted")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	alive := Check(context.Background(), []Proxy{
+		{Address: closed.Addr().String()},
+		{Address: "http://" + ln.Addr().String()},
+	}, time.Second)
+	if len(alive) != 1 || !strings.HasSuffix(alive[0].Address, ln.Addr().String()) {
+		t.Fatalf("Check = %+v", alive)
+	}
+}
@@ -0,0 +1,259 @@
+// Package report writes the run report produced when a scan finishes:
+// reports/<run-id>.json for tooling and the dashboard, and a standalone
+// reports/<run-id>.html for people.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+// DefaultDir is the directory reports are written to and served from.
+const DefaultDir = "reports"
+
+// maxTopErrors is the number of error classes kept in a report.
+const maxTopErrors = 10
+
+// ConfigSnapshot is the part of the configuration that shaped the run.
+// Database settings and proxy addresses are left out on purpose.
+type ConfigSnapshot struct {
+	InputFile     string `json:"input_file"`
+	OutputFile    string `json:"output_file"`
+	VPNType       string `json:"vpn_type"`
+	Threads       int    `json:"threads"`
+	Timeout       string `json:"timeout"`
+	MaxRetries    int    `json:"max_retries"`
+	RateLimit     int    `json:"rate_limit"`
+	AutoScale     bool   `json:"auto_scale"`
+	ProxyEnabled  bool   `json:"proxy_enabled"`
+	ProxyCount    int    `json:"proxy_count"`
+	StreamingMode bool   `json:"streaming_mode"`
+}
+
+// Report is the summary of a single scan run.
+type Report struct {
+	RunID           string                          `json:"run_id"`
+	VPNType         string                          `json:"vpn_type"`
+	StartedAt       time.Time                       `json:"started_at"`
+	FinishedAt      time.Time                       `json:"finished_at"`
+	DurationSeconds float64                         `json:"duration_seconds"`
+	Interrupted     bool                            `json:"interrupted"`
+	Totals          stats.Snapshot                  `json:"totals"`
+	SuccessRate     float64                         `json:"success_rate"`
+	TopErrors       []stats.ErrorClass              `json:"top_errors"`
+	Vendors         map[string]stats.VendorCounters `json:"vendors"`
+	Exclusions      map[string]int64                `json:"exclusions,omitempty"`
+	Config          ConfigSnapshot                  `json:"config"`
+}
+
+// Summary is the listing entry of a stored report.
+type Summary struct {
+	RunID       string    `json:"run_id"`
+	VPNType     string    `json:"vpn_type"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Interrupted bool      `json:"interrupted"`
+	Goods       int64     `json:"goods"`
+	Processed   int64     `json:"processed"`
+}
+
+var runIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidRunID reports whether id is safe to use as a file name.
+func ValidRunID(id string) bool {
+	return runIDRe.MatchString(id) && !strings.Contains(id, "..")
+}
+
+// Build collects the report of a run from its stats and configuration.
+func Build(runID string, cfg *config.Config, st *stats.Stats, started time.Time, interrupted bool) *Report {
+	finished := time.Now().UTC()
+	top := st.ErrorClasses()
+	if len(top) > maxTopErrors {
+		top = top[:maxTopErrors]
+	}
+	return &Report{
+		RunID:           runID,
+		VPNType:         cfg.VPNType,
+		StartedAt:       started.UTC(),
+		FinishedAt:      finished,
+		DurationSeconds: finished.Sub(started).Round(time.Millisecond).Seconds(),
+		Interrupted:     interrupted,
+		Totals:          st.Snapshot(),
+		SuccessRate:     st.GetSuccessRate(),
+		TopErrors:       top,
+		Vendors:         st.Vendors(),
+		Exclusions:      st.Exclusions(),
+		Config: ConfigSnapshot{
+			InputFile:     cfg.InputFile,
+			OutputFile:    cfg.OutputFile,
+			VPNType:       cfg.VPNType,
+			Threads:       cfg.Threads,
+			Timeout:       cfg.Timeout.String(),
+			MaxRetries:    cfg.MaxRetries,
+			RateLimit:     cfg.RateLimit,
+			AutoScale:     cfg.AutoScale,
+			ProxyEnabled:  cfg.ProxyEnabled,
+			ProxyCount:    len(cfg.ProxyList),
+			StreamingMode: cfg.StreamingMode,
+		},
+	}
+}
+
+// Write stores the report as <dir>/<run-id>.json and .html and returns the
+// path of the JSON file.
+func (r *Report) Write(dir string) (string, error) {
+	if !ValidRunID(r.RunID) {
+		return "", fmt.Errorf("invalid run id %q", r.RunID)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	jsonPath := filepath.Join(dir, r.RunID+".json")
+	if err := writeFile(jsonPath, data); err != nil {
+		return "", err
+	}
+	var html strings.Builder
+	if err := htmlTemplate.Execute(&html, r); err != nil {
+		return "", err
+	}
+	if err := writeFile(filepath.Join(dir, r.RunID+".html"), []byte(html.String())); err != nil {
+		return "", err
+	}
+	return jsonPath, nil
+}
+
+// writeFile writes through a temporary file so readers never see a
+// partial report.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the report with the given run id from dir.
+func Load(dir, runID string) (*Report, error) {
+	if !ValidRunID(runID) {
+		return nil, fmt.Errorf("invalid run id %q", runID)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, runID+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", runID, err)
+	}
+	return &r, nil
+}
+
+// List returns the reports in dir, newest first. Unreadable files are
+// skipped. A missing directory yields an empty list.
+func List(dir string) ([]Summary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Summary, 0, len(files))
+	for _, f := range files {
+		r, err := Load(dir, strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			continue
+		}
+		out = append(out, Summary{
+			RunID:       r.RunID,
+			VPNType:     r.VPNType,
+			StartedAt:   r.StartedAt,
+			FinishedAt:  r.FinishedAt,
+			Interrupted: r.Interrupted,
+			Goods:       r.Totals.Goods,
+			Processed:   r.Totals.Processed,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	return out, nil
+}
+
+// HTMLPath returns the path of the HTML rendering of a report.
+func HTMLPath(dir, runID string) string {
+	return filepath.Join(dir, runID+".html")
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"vendorNames": func(m map[string]stats.VendorCounters) []string {
+		names := make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return names
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Run {{.RunID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>Run {{.RunID}}</h1>
+<p>{{.VPNType}} · {{.StartedAt.Format "2006-01-02 15:04:05"}} – {{.FinishedAt.Format "2006-01-02 15:04:05"}} UTC · {{printf "%.0f" .DurationSeconds}}s{{if .Interrupted}} · <strong>interrupted</strong>{{end}}</p>
+
+<h2>Totals</h2>
+<table>
+<tr><th>Processed</th><th>Valid</th><th>Invalid</th><th>Errors</th><th>Offline</th><th>Blocked</th><th>Success</th><th>Avg RPS</th><th>Peak RPS</th></tr>
+<tr><td>{{.Totals.Processed}}</td><td>{{.Totals.Goods}}</td><td>{{.Totals.Bads}}</td><td>{{.Totals.Errors}}</td><td>{{.Totals.Offline}}</td><td>{{.Totals.IPBlock}}</td><td>{{printf "%.2f" .SuccessRate}}%</td><td>{{.Totals.AvgRPS}}</td><td>{{.Totals.PeakRPS}}</td></tr>
+</table>
+
+{{if .Vendors}}<h2>Vendors</h2>
+<table>
+<tr><th>Vendor</th><th>Processed</th><th>Valid</th><th>Invalid</th><th>Errors</th><th>Offline</th><th>Blocked</th></tr>
+{{range $name := vendorNames .Vendors}}{{with index $.Vendors $name}}<tr><td>{{$name}}</td><td>{{.Processed}}</td><td>{{.Goods}}</td><td>{{.Bads}}</td><td>{{.Errors}}</td><td>{{.Offline}}</td><td>{{.IPBlock}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+{{if .TopErrors}}<h2>Top errors</h2>
+<table>
+<tr><th>Class</th><th>Count</th></tr>
+{{range .TopErrors}}<tr><td>{{.Class}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Exclusions}}<h2>Excluded targets</h2>
+<table>
+<tr><th>Rule</th><th>Skipped</th></tr>
+{{range $rule, $n := .Exclusions}}<tr><td>{{$rule}}</td><td>{{$n}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Configuration</h2>
+<table>
+<tr><td>Input file</td><td>{{.Config.InputFile}}</td></tr>
+<tr><td>Output file</td><td>{{.Config.OutputFile}}</td></tr>
+<tr><td>Threads</td><td>{{.Config.Threads}}</td></tr>
+<tr><td>Timeout</td><td>{{.Config.Timeout}}</td></tr>
+<tr><td>Max retries</td><td>{{.Config.MaxRetries}}</td></tr>
+<tr><td>Rate limit</td><td>{{.Config.RateLimit}}</td></tr>
+<tr><td>Auto-scale</td><td>{{.Config.AutoScale}}</td></tr>
+<tr><td>Proxies</td><td>{{if .Config.ProxyEnabled}}{{.Config.ProxyCount}}{{else}}off{{end}}</td></tr>
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"vpn-bruteforce-client/internal/config"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+func TestWriteLoadList(t *testing.T) {
+	dir := t.TempDir()
+	st := stats.New()
+	st.IncrementGoods()
+	st.IncrementErrors()
+	st.RecordResult("fortinet", stats.ResultGood)
+	st.RecordErrorClass("timeout")
+
+	cfg := config.Default()
+	cfg.VPNType = "fortinet"
+	cfg.DBPassword = "secret"
+	started := time.Now().Add(-time.Minute)
+	rep := Build(stats.NewRunID(started), cfg, st, started, false)
+
+	path, err := rep.Write(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "secret") {
+		t.Fatal("report contains the database password")
+	}
+	html, err := os.ReadFile(HTMLPath(dir, rep.RunID))
+	if err != nil || !strings.Contains(string(html), "timeout") {
+		t.Fatalf("html report missing or incomplete: %v", err)
+	}
+
+	got, err := Load(dir, rep.RunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Totals.Goods != 1 || got.Vendors["fortinet"].Goods != 1 || len(got.TopErrors) != 1 {
+		t.Fatalf("unexpected report %+v", got)
+	}
+
+	list, err := List(dir)
+	if err != nil || len(list) != 1 || list[0].RunID != rep.RunID || list[0].Processed != 2 {
+		t.Fatalf("List() = %+v, %v", list, err)
+	}
+}
+
+func TestValidRunID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"20260101T000000-abcdef": true,
+		"../etc/passwd":          false,
+		"a/b":                    false,
+		"":                       false,
+		"x..y":                   false,
+	} {
+		if got := ValidRunID(id); got != want {
+			t.Errorf("ValidRunID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestListMissingDir(t *testing.T) {
+	list, err := List("does-not-exist")
+	if err != nil || len(list) != 0 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+}
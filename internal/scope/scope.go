@@ -0,0 +1,199 @@
+// Package scope enforces the authorized scope of an engagement. A scope
+// file lists the networks and domains that may be tested and the dates of
+// the engagement, and is signed with an Ed25519 key so that it cannot be
+// widened without the key holder.
+package scope
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"vpn-bruteforce-client/internal/geoip"
+)
+
+// File is the on-disk scope file.
+type File struct {
+	Engagement string    `yaml:"engagement" json:"engagement"`
+	NotBefore  time.Time `yaml:"not_before" json:"not_before"`
+	NotAfter   time.Time `yaml:"not_after" json:"not_after"`
+	// CIDRs are the networks (or single IPs) IP targets must be in.
+	CIDRs []string `yaml:"cidrs" json:"cidrs"`
+	// Domains are the domains host name targets must be in; subdomains
+	// are included.
+	Domains []string `yaml:"domains" json:"domains"`
+	// Signature is the base64 Ed25519 signature of the other fields.
+	Signature string `yaml:"signature,omitempty" json:"signature,omitempty"`
+}
+
+// payload returns the signed bytes: the JSON encoding of f without its
+// signature. Empty lists and time zones are normalized so that the payload
+// survives a YAML round trip.
+func (f File) payload() []byte {
+	f.Signature = ""
+	f.NotBefore, f.NotAfter = f.NotBefore.UTC(), f.NotAfter.UTC()
+	if len(f.CIDRs) == 0 {
+		f.CIDRs = nil
+	}
+	if len(f.Domains) == 0 {
+		f.Domains = nil
+	}
+	data, _ := json.Marshal(f)
+	return data
+}
+
+// Sign sets f's signature using key.
+func (f *File) Sign(key ed25519.PrivateKey) {
+	f.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, f.payload()))
+}
+
+// Verify checks f's signature against key.
+func (f *File) Verify(key ed25519.PublicKey) error {
+	if f.Signature == "" {
+		return fmt.Errorf("scope file is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(f.Signature)
+	if err != nil || !ed25519.Verify(key, f.payload(), sig) {
+		return fmt.Errorf("scope file signature is invalid")
+	}
+	return nil
+}
+
+// ReadFile parses a scope file without verifying it.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// WriteFile writes f as YAML.
+func (f *File) WriteFile(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ParsePublicKey decodes a base64 Ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid scope public key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey decodes a base64 Ed25519 private key.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid scope private key")
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+// Scope is a verified scope file ready for checking targets.
+type Scope struct {
+	File
+	nets    []*net.IPNet
+	domains []string
+}
+
+// Load reads the scope file at path, verifies its signature with key and
+// validates its contents. It does not check the engagement dates; use
+// Active for that.
+func Load(path string, key ed25519.PublicKey) (*Scope, error) {
+	f, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Verify(key); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return New(*f)
+}
+
+// New validates f and returns its scope. The signature is not checked.
+func New(f File) (*Scope, error) {
+	if f.NotBefore.IsZero() || f.NotAfter.IsZero() || !f.NotAfter.After(f.NotBefore) {
+		return nil, fmt.Errorf("scope: not_before and not_after must be set and ordered")
+	}
+	if len(f.CIDRs) == 0 && len(f.Domains) == 0 {
+		return nil, fmt.Errorf("scope: no cidrs or domains")
+	}
+	s := &Scope{File: f}
+	for _, c := range f.CIDRs {
+		if ip := net.ParseIP(c); ip != nil {
+			if ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("scope: invalid cidr %q", c)
+		}
+		s.nets = append(s.nets, n)
+	}
+	for _, d := range f.Domains {
+		d = strings.ToLower(strings.Trim(strings.TrimSpace(d), "."))
+		if d == "" {
+			return nil, fmt.Errorf("scope: empty domain")
+		}
+		s.domains = append(s.domains, d)
+	}
+	return s, nil
+}
+
+// Active returns an error unless now is within the engagement dates.
+func (s *Scope) Active(now time.Time) error {
+	switch {
+	case now.Before(s.NotBefore):
+		return fmt.Errorf("engagement %q starts %s", s.Engagement, s.NotBefore.Format(time.RFC3339))
+	case now.After(s.NotAfter):
+		return fmt.Errorf("engagement %q ended %s", s.Engagement, s.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Check returns an error unless target (IP, host, host:port or URL) is in
+// scope at now. IP targets must be in one of the CIDRs, host names in one
+// of the domains.
+func (s *Scope) Check(target string, now time.Time) error {
+	if err := s.Active(now); err != nil {
+		return err
+	}
+	host := strings.ToLower(strings.TrimSuffix(geoip.Host(target), "."))
+	if host == "" {
+		return fmt.Errorf("%q has no host", target)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, n := range s.nets {
+			if n.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is outside the scope networks", host)
+	}
+	for _, d := range s.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is outside the scope domains", host)
+}
@@ -0,0 +1,69 @@
+package scope
+
+import (
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testFile() File {
+	return File{
+		Engagement: "acme-q4",
+		NotBefore:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:   time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+		CIDRs:      []string{"192.0.2.0/24", "198.51.100.7"},
+		Domains:    []string{"vpn.example.com"},
+	}
+}
+
+func TestSignedRoundTrip(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	f := testFile()
+	f.Sign(priv)
+	path := filepath.Join(t.TempDir(), "scope.yaml")
+	if err := f.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, pub); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Widening the scope breaks the signature.
+	f.CIDRs = append(f.CIDRs, "0.0.0.0/0")
+	f.WriteFile(path)
+	if _, err := Load(path, pub); err == nil {
+		t.Fatal("modified scope file accepted")
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	f = testFile()
+	f.Sign(priv)
+	f.WriteFile(path)
+	if _, err := Load(path, other); err == nil {
+		t.Fatal("scope file accepted with the wrong key")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s, err := New(testFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	during := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	for target, ok := range map[string]bool{
+		"192.0.2.10":                      true,
+		"https://198.51.100.7:443/remote": true,
+		"198.51.100.8":                    false,
+		"https://VPN.example.com/login":   true,
+		"portal.vpn.example.com:8443":     true,
+		"evilvpn.example.com":             false,
+		"example.com":                     false,
+	} {
+		if err := s.Check(target, during); (err == nil) != ok {
+			t.Errorf("Check(%q) = %v, want in scope %v", target, err, ok)
+		}
+	}
+	if err := s.Check("192.0.2.10", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("target accepted after the engagement ended")
+	}
+}
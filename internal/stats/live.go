@@ -0,0 +1,259 @@
+package stats
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"vpn-bruteforce-client/internal/breaker"
+)
+
+// maxRPSHistory is the number of one-second RPS samples kept.
+const maxRPSHistory = 60
+
+// maxHits is the number of recent hits kept for display.
+const maxHits = 20
+
+// Result classes accepted by RecordResult.
+const (
+	ResultGood    = "good"
+	ResultBad     = "bad"
+	ResultError   = "error"
+	ResultOffline = "offline"
+	ResultIPBlock = "ipblock"
+)
+
+// VendorCounters are the result counters of a single VPN vendor.
+type VendorCounters struct {
+	Goods     int64 `json:"goods"`
+	Bads      int64 `json:"bads"`
+	Errors    int64 `json:"errors"`
+	Offline   int64 `json:"offline"`
+	IPBlock   int64 `json:"ipblock"`
+	Processed int64 `json:"processed"`
+	// LatencyMs is the total duration of the Timed checks that reached the
+	// target, in milliseconds.
+	LatencyMs int64 `json:"latency_ms"`
+	Timed     int64 `json:"timed"`
+}
+
+// AvgLatencyMs returns the average check duration in milliseconds, zero
+// when no check was timed.
+func (c VendorCounters) AvgLatencyMs() float64 {
+	if c.Timed == 0 {
+		return 0
+	}
+	return float64(c.LatencyMs) / float64(c.Timed)
+}
+
+// Hit is a recently found valid credential. The password is not kept.
+type Hit struct {
+	Time     time.Time `json:"time"`
+	Vendor   string    `json:"vendor"`
+	Target   string    `json:"target"`
+	Username string    `json:"username"`
+}
+
+// SetQuiet disables the single-line console status, e.g. while a TUI owns
+// the terminal.
+func (s *Stats) SetQuiet(quiet bool) {
+	s.quiet.Store(quiet)
+}
+
+// RecordResult counts a result of class for vendor. It complements the
+// global Increment* counters.
+func (s *Stats) RecordResult(vendor, class string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vc := s.vendor(vendor)
+	switch class {
+	case ResultGood:
+		vc.Goods++
+	case ResultBad:
+		vc.Bads++
+	case ResultError:
+		vc.Errors++
+	case ResultOffline:
+		vc.Offline++
+	case ResultIPBlock:
+		vc.IPBlock++
+	}
+	vc.Processed++
+}
+
+// RecordLatency adds the duration of a check against a vendor's target.
+func (s *Stats) RecordLatency(vendor string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vc := s.vendor(vendor)
+	vc.LatencyMs += d.Milliseconds()
+	vc.Timed++
+}
+
+// vendor returns the counters of vendor, creating them. The caller holds
+// s.mu.
+func (s *Stats) vendor(vendor string) *VendorCounters {
+	if s.vendors == nil {
+		s.vendors = make(map[string]*VendorCounters)
+	}
+	vc := s.vendors[vendor]
+	if vc == nil {
+		vc = &VendorCounters{}
+		s.vendors[vendor] = vc
+	}
+	return vc
+}
+
+// RecordErrorClass counts an error of the given class (timeout, refused,
+// ssl_error, ...).
+func (s *Stats) RecordErrorClass(class string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.errorClasses == nil {
+		s.errorClasses = make(map[string]int64)
+	}
+	s.errorClasses[class]++
+}
+
+// RecordExcluded counts a target skipped because of the exclusion rule.
+func (s *Stats) RecordExcluded(rule string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exclusions == nil {
+		s.exclusions = make(map[string]int64)
+	}
+	s.exclusions[rule]++
+}
+
+// Exclusions returns a copy of the skipped-target counts per exclusion
+// rule.
+func (s *Stats) Exclusions() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64, len(s.exclusions))
+	for k, v := range s.exclusions {
+		out[k] = v
+	}
+	return out
+}
+
+// SetBreakers makes the stats file list the tripped circuit breakers
+// returned by fn.
+func (s *Stats) SetBreakers(fn func() []breaker.Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakers = fn
+}
+
+// RecordHit remembers a valid credential for the recent hits list.
+func (s *Stats) RecordHit(vendor, target, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits = append(s.hits, Hit{Time: time.Now(), Vendor: vendor, Target: target, Username: username})
+	if len(s.hits) > maxHits {
+		s.hits = s.hits[len(s.hits)-maxHits:]
+	}
+}
+
+// pushRPS appends a sample to the RPS history and returns a copy of it.
+func (s *Stats) pushRPS(rps int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rpsHistory = append(s.rpsHistory, rps)
+	if len(s.rpsHistory) > maxRPSHistory {
+		s.rpsHistory = s.rpsHistory[len(s.rpsHistory)-maxRPSHistory:]
+	}
+	return append([]int64(nil), s.rpsHistory...)
+}
+
+// RPSHistory returns up to the last minute of per-second RPS samples,
+// oldest first.
+func (s *Stats) RPSHistory() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.rpsHistory...)
+}
+
+// Vendors returns a copy of the per-vendor counters.
+func (s *Stats) Vendors() map[string]VendorCounters {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]VendorCounters, len(s.vendors))
+	for k, v := range s.vendors {
+		out[k] = *v
+	}
+	return out
+}
+
+// ErrorClass is an error class with its count.
+type ErrorClass struct {
+	Class string `json:"class"`
+	Count int64  `json:"count"`
+}
+
+// ErrorClasses returns the error classes sorted by count, largest first.
+func (s *Stats) ErrorClasses() []ErrorClass {
+	s.mu.Lock()
+	out := make([]ErrorClass, 0, len(s.errorClasses))
+	for k, v := range s.errorClasses {
+		out = append(out, ErrorClass{k, v})
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Class < out[j].Class
+	})
+	return out
+}
+
+// RecentHits returns the most recent hits, newest first.
+func (s *Stats) RecentHits() []Hit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Hit, len(s.hits))
+	for i, h := range s.hits {
+		out[len(s.hits)-1-i] = h
+	}
+	return out
+}
+
+// Elapsed returns the time since the stats were created.
+func (s *Stats) Elapsed() time.Duration {
+	return time.Since(s.startTime)
+}
+
+// Snapshot is a consistent-enough copy of the global counters.
+type Snapshot struct {
+	Goods     int64 `json:"goods"`
+	Bads      int64 `json:"bads"`
+	Errors    int64 `json:"errors"`
+	Offline   int64 `json:"offline"`
+	IPBlock   int64 `json:"ipblock"`
+	Processed int64 `json:"processed"`
+	RPS       int64 `json:"rps"`
+	AvgRPS    int64 `json:"avg_rps"`
+	PeakRPS   int64 `json:"peak_rps"`
+	Threads   int64 `json:"threads"`
+	Memory    int64 `json:"memory_mb"`
+	CPUUsage  int64 `json:"cpu_usage"`
+}
+
+// Snapshot returns the current global counters.
+func (s *Stats) Snapshot() Snapshot {
+	return Snapshot{
+		Goods:     atomic.LoadInt64(&s.Goods),
+		Bads:      atomic.LoadInt64(&s.Bads),
+		Errors:    atomic.LoadInt64(&s.Errors),
+		Offline:   atomic.LoadInt64(&s.Offline),
+		IPBlock:   atomic.LoadInt64(&s.IPBlock),
+		Processed: atomic.LoadInt64(&s.Processed),
+		RPS:       atomic.LoadInt64(&s.RPS),
+		AvgRPS:    atomic.LoadInt64(&s.AvgRPS),
+		PeakRPS:   atomic.LoadInt64(&s.PeakRPS),
+		Threads:   atomic.LoadInt64(&s.Threads),
+		Memory:    atomic.LoadInt64(&s.Memory),
+		CPUUsage:  atomic.LoadInt64(&s.CPUUsage),
+	}
+}
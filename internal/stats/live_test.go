@@ -0,0 +1,57 @@
+package stats
+
+import "testing"
+
+func TestRecordResultPerVendor(t *testing.T) {
+	s := New()
+	s.RecordResult("fortinet", ResultGood)
+	s.RecordResult("fortinet", ResultBad)
+	s.RecordResult("cisco", ResultError)
+
+	v := s.Vendors()
+	if got := v["fortinet"]; got.Goods != 1 || got.Bads != 1 || got.Processed != 2 {
+		t.Fatalf("fortinet = %+v", got)
+	}
+	if got := v["cisco"]; got.Errors != 1 || got.Processed != 1 {
+		t.Fatalf("cisco = %+v", got)
+	}
+}
+
+func TestErrorClassesSorted(t *testing.T) {
+	s := New()
+	s.RecordErrorClass("refused")
+	s.RecordErrorClass("timeout")
+	s.RecordErrorClass("timeout")
+
+	got := s.ErrorClasses()
+	if len(got) != 2 || got[0].Class != "timeout" || got[0].Count != 2 {
+		t.Fatalf("ErrorClasses() = %+v", got)
+	}
+}
+
+func TestRecentHitsBounded(t *testing.T) {
+	s := New()
+	for i := 0; i < maxHits+5; i++ {
+		s.RecordHit("fortinet", "1.1.1.1", "admin")
+	}
+	s.RecordHit("cisco", "2.2.2.2", "root")
+
+	hits := s.RecentHits()
+	if len(hits) != maxHits {
+		t.Fatalf("len = %d, want %d", len(hits), maxHits)
+	}
+	if hits[0].Vendor != "cisco" {
+		t.Fatalf("newest hit = %+v", hits[0])
+	}
+}
+
+func TestRPSHistoryBounded(t *testing.T) {
+	s := New()
+	for i := 0; i < maxRPSHistory+10; i++ {
+		s.pushRPS(int64(i))
+	}
+	h := s.RPSHistory()
+	if len(h) != maxRPSHistory || h[len(h)-1] != maxRPSHistory+9 {
+		t.Fatalf("history len=%d last=%d", len(h), h[len(h)-1])
+	}
+}
@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// DefaultStaleAge is how long a stats file may go without updates before
+// CleanupStale removes it. Running scanners rewrite theirs every second.
+const DefaultStaleAge = 24 * time.Hour
+
+// fileSnapshot is the subset of a stats file needed to resume a run.
+type fileSnapshot struct {
+	Goods        int64                     `json:"goods"`
+	Bads         int64                     `json:"bads"`
+	Errors       int64                     `json:"errors"`
+	Offline      int64                     `json:"offline"`
+	IPBlock      int64                     `json:"ipblock"`
+	Processed    int64                     `json:"processed"`
+	PeakRPS      int64                     `json:"peak_rps"`
+	Uptime       float64                   `json:"uptime"`
+	Vendors      map[string]VendorCounters `json:"vendors"`
+	ErrorClasses map[string]int64          `json:"error_classes"`
+	Exclusions   map[string]int64          `json:"exclusions"`
+}
+
+// NewRunID returns a sortable identifier for a run started at t.
+func NewRunID(t time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return t.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// SetRunID keys the stats file by run id (stats_<id>.json) instead of the
+// process id, so a restarted scanner keeps writing to the same file.
+func (s *Stats) SetRunID(id string) {
+	s.mu.Lock()
+	s.runID = id
+	s.mu.Unlock()
+}
+
+// RunID returns the run id set with SetRunID.
+func (s *Stats) RunID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runID
+}
+
+// FileName returns the name of the stats file written every second.
+func (s *Stats) FileName() string {
+	if id := s.RunID(); id != "" {
+		return "stats_" + id + ".json"
+	}
+	return fmt.Sprintf("stats_%d.json", os.Getpid())
+}
+
+// LoadSnapshot restores counters from a stats file written by an earlier
+// process of the same run. It must be called before Start.
+func (s *Stats) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snap fileSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	atomic.StoreInt64(&s.Goods, snap.Goods)
+	atomic.StoreInt64(&s.Bads, snap.Bads)
+	atomic.StoreInt64(&s.Errors, snap.Errors)
+	atomic.StoreInt64(&s.Offline, snap.Offline)
+	atomic.StoreInt64(&s.IPBlock, snap.IPBlock)
+	atomic.StoreInt64(&s.Processed, snap.Processed)
+	atomic.StoreInt64(&s.PeakRPS, snap.PeakRPS)
+	// Uptime continues from the previous process.
+	s.startTime = time.Now().Add(-time.Duration(snap.Uptime * float64(time.Second)))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vendors = make(map[string]*VendorCounters, len(snap.Vendors))
+	for k, v := range snap.Vendors {
+		v := v
+		s.vendors[k] = &v
+	}
+	s.errorClasses = snap.ErrorClasses
+	s.exclusions = snap.Exclusions
+	return nil
+}
+
+// CleanupStale removes stats_*.json files in dir that belong to processes
+// which are no longer running (stats_<pid>.json) or that have not been
+// updated for maxAge. keep is never removed. It returns the removed paths.
+func CleanupStale(dir string, maxAge time.Duration, keep string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "stats_*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, f := range files {
+		if filepath.Base(f) == keep {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		stale := maxAge > 0 && time.Since(fi.ModTime()) > maxAge
+		if !stale {
+			id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "stats_"), ".json")
+			if pid, err := strconv.Atoi(id); err == nil && pid > 0 {
+				if alive, err := process.PidExists(int32(pid)); err == nil && !alive {
+					stale = true
+				}
+			}
+		}
+		if stale {
+			if err := os.Remove(f); err != nil {
+				return removed, err
+			}
+			removed = append(removed, f)
+		}
+	}
+	return removed, nil
+}
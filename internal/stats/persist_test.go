@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoadSnapshotResumesRun(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	s := New()
+	s.SetRunID("run1")
+	s.IncrementGoods()
+	s.IncrementBads()
+	s.RecordResult("fortinet", ResultGood)
+	s.RecordErrorClass("timeout")
+	if err := s.saveToFile(); err != nil {
+		t.Fatal(err)
+	}
+	if s.FileName() != "stats_run1.json" {
+		t.Fatalf("FileName() = %q", s.FileName())
+	}
+
+	r := New()
+	if err := r.LoadSnapshot("stats_run1.json"); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetGoods() != 1 || r.GetProcessed() != 2 {
+		t.Fatalf("goods=%d processed=%d", r.GetGoods(), r.GetProcessed())
+	}
+	if r.Vendors()["fortinet"].Goods != 1 || len(r.ErrorClasses()) != 1 {
+		t.Fatalf("vendors=%v errors=%v", r.Vendors(), r.ErrorClasses())
+	}
+}
+
+func TestCleanupStale(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, age time.Duration) string {
+		p := filepath.Join(dir, name)
+		os.WriteFile(p, []byte("{}"), 0o644)
+		mt := time.Now().Add(-age)
+		os.Chtimes(p, mt, mt)
+		return p
+	}
+	live := write("stats_"+strconv.Itoa(os.Getpid())+".json", 0)
+	old := write("stats_oldrun.json", 48*time.Hour)
+	recent := write("stats_newrun.json", time.Minute)
+	keep := write("stats_mine.json", 48*time.Hour)
+	dead := write("stats_2147483000.json", 0)
+
+	removed, err := CleanupStale(dir, 24*time.Hour, "stats_mine.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0] != dead || removed[1] != old {
+		t.Fatalf("removed %v", removed)
+	}
+	for _, p := range []string{live, recent, keep} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s removed: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Progress output formats of the scanner.
+const (
+	ProgressText = "text"
+	ProgressJSON = "json"
+)
+
+// Progress event types.
+const (
+	EventProgress = "progress"
+	EventDone     = "done"
+)
+
+// ProgressEvent is one line of the line-delimited JSON progress stream.
+type ProgressEvent struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id,omitempty"`
+	Snapshot
+	Total          int64   `json:"total,omitempty"`
+	Percent        float64 `json:"percent,omitempty"`
+	ElapsedSeconds int64   `json:"elapsed_seconds"`
+	// ETASeconds is omitted until the total and a rate are known.
+	ETASeconds  int64  `json:"eta_seconds,omitempty"`
+	CurrentFile string `json:"current_file,omitempty"`
+}
+
+// SetTotal sets the number of credentials the run will process, used for
+// the percentage and ETA.
+func (s *Stats) SetTotal(total int64) {
+	atomic.StoreInt64(&s.total, total)
+}
+
+// SetCurrentFile records the input file being processed.
+func (s *Stats) SetCurrentFile(name string) {
+	s.mu.Lock()
+	s.currentFile = name
+	s.mu.Unlock()
+}
+
+// SetProgressWriter makes Start write a progress event to w every second.
+// Writing stops at the first error, e.g. when the reader of a named pipe
+// goes away.
+// It waits for an event being written to the previous writer.
+func (s *Stats) SetProgressWriter(w io.Writer) {
+	s.progressMu.Lock()
+	s.progress = w
+	s.progressMu.Unlock()
+}
+
+// Progress returns the current progress as an event of type typ.
+func (s *Stats) Progress(typ string) ProgressEvent {
+	s.mu.Lock()
+	file := s.currentFile
+	s.mu.Unlock()
+
+	elapsed := time.Since(s.startTime)
+	ev := ProgressEvent{
+		Type:           typ,
+		Time:           time.Now().UTC(),
+		RunID:          s.RunID(),
+		Snapshot:       s.Snapshot(),
+		Total:          atomic.LoadInt64(&s.total),
+		ElapsedSeconds: int64(elapsed.Seconds()),
+		CurrentFile:    file,
+	}
+	if ev.Total > 0 {
+		ev.Percent = float64(ev.Processed) / float64(ev.Total) * 100
+		rate := float64(ev.AvgRPS)
+		if rate == 0 && elapsed >= time.Second {
+			rate = float64(ev.Processed) / elapsed.Seconds()
+		}
+		if remaining := ev.Total - ev.Processed; remaining > 0 && rate > 0 {
+			ev.ETASeconds = int64(float64(remaining) / rate)
+		}
+	}
+	return ev
+}
+
+// WriteProgress writes the current progress to w as a single JSON line.
+func (s *Stats) WriteProgress(w io.Writer, typ string) error {
+	data, err := json.Marshal(s.Progress(typ))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// emitProgress writes a progress event to the configured writer, if any.
+func (s *Stats) emitProgress() {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	if s.progress == nil {
+		return
+	}
+	if err := s.WriteProgress(s.progress, EventProgress); err != nil {
+		log.Printf("progress output stopped: %v", err)
+		s.progress = nil
+	}
+}
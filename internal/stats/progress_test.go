@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteProgressJSONLine(t *testing.T) {
+	s := New()
+	s.SetTotal(10)
+	s.SetCurrentFile("creds.txt")
+	for i := 0; i < 4; i++ {
+		s.IncrementBads()
+	}
+	s.IncrementGoods()
+
+	var buf bytes.Buffer
+	if err := s.WriteProgress(&buf, EventProgress); err != nil {
+		t.Fatal(err)
+	}
+	line := buf.Bytes()
+	if bytes.Count(line, []byte("\n")) != 1 || line[len(line)-1] != '\n' {
+		t.Fatalf("expected a single JSON line, got %q", line)
+	}
+	var ev ProgressEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != EventProgress || ev.Processed != 5 || ev.Goods != 1 || ev.Total != 10 || ev.Percent != 50 || ev.CurrentFile != "creds.txt" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestProgressETA(t *testing.T) {
+	s := New()
+	s.SetTotal(100)
+	s.Processed = 20
+	s.AvgRPS = 10
+	if ev := s.Progress(EventProgress); ev.ETASeconds != 8 {
+		t.Fatalf("ETASeconds = %d, want 8", ev.ETASeconds)
+	}
+}
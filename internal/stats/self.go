@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// sampleSelf updates Memory (MB obtained from the OS by the Go runtime) and
+// CPUUsage (percent of one core since the previous sample) and checks the
+// memory cap.
+func (s *Stats) sampleSelf() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	memMB := int64(ms.Sys >> 20)
+	atomic.StoreInt64(&s.Memory, memMB)
+
+	if s.proc == nil {
+		p, err := process.NewProcess(int32(os.Getpid()))
+		if err != nil {
+			return
+		}
+		s.proc = p
+	}
+	if cpu, err := s.proc.Percent(0); err == nil {
+		atomic.StoreInt64(&s.CPUUsage, int64(cpu+0.5))
+	}
+
+	s.checkMemoryCap(memMB)
+}
+
+// SetMemoryCap makes the stats loop call alert once memory crosses capMB,
+// and again only after it has dropped below the cap. A nil alert logs a
+// warning. capMB <= 0 disables the check.
+func (s *Stats) SetMemoryCap(capMB int64, alert func(memMB, capMB int64)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memCap = capMB
+	s.memAlert = alert
+}
+
+func (s *Stats) checkMemoryCap(memMB int64) {
+	s.mu.Lock()
+	capMB, alert := s.memCap, s.memAlert
+	over := capMB > 0 && memMB > capMB
+	fire := over && !s.memOver
+	s.memOver = over
+	s.mu.Unlock()
+	if !fire {
+		return
+	}
+	if alert == nil {
+		log.Printf("⚠️ memory usage %d MB exceeds cap of %d MB", memMB, capMB)
+		return
+	}
+	alert(memMB, capMB)
+}
+
+// GetMemory returns the memory obtained from the OS in MB.
+func (s *Stats) GetMemory() int64 {
+	return atomic.LoadInt64(&s.Memory)
+}
+
+// GetCPUUsage returns the process CPU usage in percent of one core.
+func (s *Stats) GetCPUUsage() int64 {
+	return atomic.LoadInt64(&s.CPUUsage)
+}
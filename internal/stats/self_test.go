@@ -0,0 +1,27 @@
+package stats
+
+import "testing"
+
+func TestSampleSelf(t *testing.T) {
+	s := New()
+	s.sampleSelf()
+	if s.GetMemory() <= 0 {
+		t.Fatalf("memory not sampled: %d", s.GetMemory())
+	}
+	if snap := s.Snapshot(); snap.Memory != s.GetMemory() {
+		t.Fatalf("snapshot memory %d, want %d", snap.Memory, s.GetMemory())
+	}
+}
+
+func TestMemoryCapAlertsOncePerCrossing(t *testing.T) {
+	s := New()
+	var alerts int
+	s.SetMemoryCap(100, func(memMB, capMB int64) { alerts++ })
+
+	for _, mem := range []int64{50, 150, 200, 80, 120} {
+		s.checkMemoryCap(mem)
+	}
+	if alerts != 2 {
+		t.Fatalf("alerts = %d, want 2", alerts)
+	}
+}
@@ -3,10 +3,16 @@ package stats
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"vpn-bruteforce-client/internal/breaker"
 )
 
 type Stats struct {
@@ -29,6 +35,29 @@ type Stats struct {
 	Threads  int64 `json:"threads"`
 	Memory   int64 `json:"memory_mb"`
 	CPUUsage int64 `json:"cpu_usage"`
+
+	// Live details for the TUI and progress output, guarded by mu.
+	mu           sync.Mutex
+	vendors      map[string]*VendorCounters
+	errorClasses map[string]int64
+	exclusions   map[string]int64
+	breakers     func() []breaker.Status
+	hits         []Hit
+	rpsHistory   []int64
+	currentFile  string
+	runID        string
+	memCap       int64
+	memAlert     func(memMB, capMB int64)
+	memOver      bool
+
+	progressMu sync.Mutex
+	progress   io.Writer
+
+	total int64
+	quiet atomic.Bool
+
+	// proc is only used by the stats loop.
+	proc *process.Process
 }
 
 func New() *Stats {
@@ -43,8 +72,6 @@ func (s *Stats) Start() {
 	defer ticker.Stop()
 
 	var lastProcessed int64
-	var rpsHistory []int64
-	maxHistory := 60 // Keep 60 seconds of history
 
 	for {
 		select {
@@ -55,12 +82,10 @@ func (s *Stats) Start() {
 			lastProcessed = currentProcessed
 
 			atomic.StoreInt64(&s.RPS, currentRPS)
+			s.sampleSelf()
 
 			// Update RPS history
-			rpsHistory = append(rpsHistory, currentRPS)
-			if len(rpsHistory) > maxHistory {
-				rpsHistory = rpsHistory[1:]
-			}
+			rpsHistory := s.pushRPS(currentRPS)
 
 			// Calculate average RPS
 			var totalRPS int64
@@ -74,7 +99,10 @@ func (s *Stats) Start() {
 				atomic.StoreInt64(&s.AvgRPS, totalRPS/int64(len(rpsHistory)))
 			}
 
-			s.display()
+			if !s.quiet.Load() {
+				s.display()
+			}
+			s.emitProgress()
 			if err := s.saveToFile(); err != nil {
 				// errors are already logged in saveToFile
 			}
@@ -154,9 +182,39 @@ func (s *Stats) saveToFile() error {
 		"avg_rps":   atomic.LoadInt64(&s.AvgRPS),
 		"peak_rps":  atomic.LoadInt64(&s.PeakRPS),
 		"threads":   atomic.LoadInt64(&s.Threads),
+		"memory_mb": atomic.LoadInt64(&s.Memory),
+		"cpu_usage": atomic.LoadInt64(&s.CPUUsage),
 		"uptime":    time.Since(s.startTime).Seconds(),
 		"timestamp": time.Now().Unix(),
 	}
+	s.mu.Lock()
+	if s.runID != "" {
+		data["run_id"] = s.runID
+	}
+	vendors := make(map[string]VendorCounters, len(s.vendors))
+	for k, v := range s.vendors {
+		vendors[k] = *v
+	}
+	data["vendors"] = vendors
+	errorClasses := make(map[string]int64, len(s.errorClasses))
+	for k, v := range s.errorClasses {
+		errorClasses[k] = v
+	}
+	data["error_classes"] = errorClasses
+	if len(s.exclusions) > 0 {
+		exclusions := make(map[string]int64, len(s.exclusions))
+		for k, v := range s.exclusions {
+			exclusions[k] = v
+		}
+		data["exclusions"] = exclusions
+	}
+	breakers := s.breakers
+	s.mu.Unlock()
+	if breakers != nil {
+		if tripped := breakers(); len(tripped) > 0 {
+			data["breakers"] = tripped
+		}
+	}
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -164,7 +222,7 @@ func (s *Stats) saveToFile() error {
 		return err
 	}
 
-	if err := os.WriteFile(fmt.Sprintf("stats_%d.json", os.Getpid()), jsonData, 0644); err != nil {
+	if err := os.WriteFile(s.FileName(), jsonData, 0644); err != nil {
 		log.Printf("failed to write stats file: %v", err)
 		return err
 	}
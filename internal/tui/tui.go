@@ -0,0 +1,191 @@
+// Package tui renders live scan statistics in the terminal with bubbletea.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"vpn-bruteforce-client/internal/aggregator"
+	"vpn-bruteforce-client/internal/stats"
+)
+
+// refreshInterval matches the one-second stats ticker.
+const refreshInterval = time.Second
+
+// maxRows limits the hits, error classes and workers shown.
+const maxRows = 8
+
+// Options configures the monitor.
+type Options struct {
+	// Title is shown in the header, usually the VPN type.
+	Title string
+	// Servers returns the worker table; nil hides it.
+	Servers func() []aggregator.ServerInfo
+	// Output is the terminal to draw on (default stdout).
+	Output io.Writer
+}
+
+type tickMsg time.Time
+
+// Model is the bubbletea model of the monitor.
+type Model struct {
+	stats   *stats.Stats
+	opts    Options
+	servers []aggregator.ServerInfo
+	width   int
+}
+
+// New returns a monitor model for st.
+func New(st *stats.Stats, opts Options) Model {
+	return Model{stats: st, opts: opts, width: 100}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// Init starts the refresh ticker.
+func (m Model) Init() tea.Cmd {
+	return tick()
+}
+
+// Update handles key presses, resizes and refresh ticks.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tickMsg:
+		if m.opts.Servers != nil {
+			m.servers = m.opts.Servers()
+		}
+		return m, tick()
+	}
+	return m, nil
+}
+
+// View renders the screen.
+func (m Model) View() string {
+	var b strings.Builder
+	snap := m.stats.Snapshot()
+	fmt.Fprintf(&b, " VPN scan: %s   elapsed %v   threads %d   mem %d MB   cpu %d%%   (q to stop)\n\n",
+		m.opts.Title, m.stats.Elapsed().Truncate(time.Second), snap.Threads, snap.Memory, snap.CPUUsage)
+
+	var rate float64
+	if snap.Processed > 0 {
+		rate = float64(snap.Goods) / float64(snap.Processed) * 100
+	}
+	fmt.Fprintf(&b, " Total  goods %-8d bads %-8d errors %-8d offline %-8d ipblock %-8d processed %d (%.1f%%)\n",
+		snap.Goods, snap.Bads, snap.Errors, snap.Offline, snap.IPBlock, snap.Processed, rate)
+
+	width := m.width - 40
+	if width < 10 {
+		width = 10
+	}
+	fmt.Fprintf(&b, " RPS    %-6d avg %-6d peak %-6d %s\n\n", snap.RPS, snap.AvgRPS, snap.PeakRPS,
+		Sparkline(m.stats.RPSHistory(), width))
+
+	vendors := m.stats.Vendors()
+	if len(vendors) > 0 {
+		names := make([]string, 0, len(vendors))
+		for name := range vendors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, " %-14s %9s %9s %9s %9s %9s %10s\n", "VENDOR", "GOODS", "BADS", "ERRORS", "OFFLINE", "IPBLOCK", "PROCESSED")
+		for _, name := range names {
+			v := vendors[name]
+			fmt.Fprintf(&b, " %-14s %9d %9d %9d %9d %9d %10d\n", name, v.Goods, v.Bads, v.Errors, v.Offline, v.IPBlock, v.Processed)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.servers) > 0 {
+		fmt.Fprintf(&b, " %-16s %-8s %5s %5s %9s %7s %7s %s\n", "WORKER", "STATUS", "CPU", "MEM", "PROCESSED", "GOODS", "ERRORS", "TASK")
+		for i, s := range m.servers {
+			if i == maxRows {
+				fmt.Fprintf(&b, " ... %d more\n", len(m.servers)-maxRows)
+				break
+			}
+			fmt.Fprintf(&b, " %-16s %-8s %4d%% %4d%% %9d %7d %7d %s\n", s.IP, s.Status, s.CPU, s.Memory, s.Processed, s.Goods, s.Errors, s.Task)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(" Recent hits\n")
+	hits := m.stats.RecentHits()
+	if len(hits) == 0 {
+		b.WriteString("   none yet\n")
+	}
+	for i, h := range hits {
+		if i == maxRows {
+			break
+		}
+		fmt.Fprintf(&b, "   %s  %-10s %s  %s\n", h.Time.Format("15:04:05"), h.Vendor, h.Target, h.Username)
+	}
+
+	b.WriteString("\n Error classes\n")
+	classes := m.stats.ErrorClasses()
+	if len(classes) == 0 {
+		b.WriteString("   none\n")
+	}
+	for i, c := range classes {
+		if i == maxRows {
+			break
+		}
+		fmt.Fprintf(&b, "   %-14s %d\n", c.Class, c.Count)
+	}
+	return b.String()
+}
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the last width values scaled to the largest one.
+func Sparkline(values []int64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	var max int64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		idx := 0
+		if max > 0 && v > 0 {
+			idx = int(v * int64(len(sparkRunes)-1) / max)
+		}
+		out[i] = sparkRunes[idx]
+	}
+	return string(out)
+}
+
+// Run shows the monitor until ctx is cancelled or the user quits. It
+// returns true when the user asked to stop.
+func Run(ctx context.Context, st *stats.Stats, opts Options) (bool, error) {
+	teaOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithContext(ctx)}
+	if opts.Output != nil {
+		teaOpts = append(teaOpts, tea.WithOutput(opts.Output))
+	}
+	_, err := tea.NewProgram(New(st, opts), teaOpts...).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ctx.Err() == nil, nil
+}
@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"vpn-bruteforce-client/internal/stats"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int64{0, 4, 8}, 3); got != "▁▄█" {
+		t.Fatalf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]int64{1, 2, 3, 4}, 2); len([]rune(got)) != 2 {
+		t.Fatalf("Sparkline width = %q", got)
+	}
+}
+
+func TestViewShowsVendorsAndHits(t *testing.T) {
+	st := stats.New()
+	st.RecordResult("fortinet", stats.ResultGood)
+	st.RecordHit("fortinet", "1.2.3.4:443", "admin")
+	st.RecordErrorClass("timeout")
+
+	view := New(st, Options{Title: "fortinet"}).View()
+	for _, want := range []string{"fortinet", "1.2.3.4:443", "admin", "timeout"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not contain %q:\n%s", want, view)
+		}
+	}
+}
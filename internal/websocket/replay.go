@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// topicHistory is how many broadcast messages are kept per message type
+// for clients resuming after a reconnect. Types listed in
+// snapshotTopics replace their previous message, so only the latest one is
+// worth replaying.
+const topicHistory = 100
+
+var snapshotTopics = map[string]int{
+	"stats_update": 1,
+}
+
+// ring holds the last broadcast messages of one topic, oldest first.
+type ring struct {
+	msgs []message
+	size int
+	// evicted is the sequence number of the newest message dropped from
+	// the ring, zero when nothing was dropped yet.
+	evicted uint64
+}
+
+func (r *ring) add(m message) {
+	if len(r.msgs) == r.size {
+		r.evicted = r.msgs[0].Seq
+		r.msgs = append(r.msgs[:0], r.msgs[1:]...)
+	}
+	r.msgs = append(r.msgs, m)
+}
+
+// newEpoch identifies this server's sequence numbers; they restart from
+// one with every process.
+func newEpoch() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// record assigns the next sequence number to m and stores it in the
+// topic's ring. The caller holds s.mu.
+func (s *Server) record(m message) message {
+	s.seq++
+	m.Seq = s.seq
+	r := s.history[m.Type]
+	if r == nil {
+		size := topicHistory
+		if n, ok := snapshotTopics[m.Type]; ok {
+			size = n
+		}
+		r = &ring{size: size}
+		s.history[m.Type] = r
+	}
+	r.add(m)
+	return m
+}
+
+// resumeResult describes a replay to the resuming client.
+type resumeResult struct {
+	Epoch string `json:"epoch"`
+	// Reset is set when the client's epoch is not the server's, e.g. after
+	// a server restart; everything kept is replayed then.
+	Reset bool `json:"reset"`
+	// Replayed is the number of messages sent and Seq the newest sequence
+	// number the client has seen after the replay.
+	Replayed int    `json:"replayed"`
+	Seq      uint64 `json:"seq"`
+	// Truncated lists the topics whose missed messages were partly
+	// dropped from history; clients should reload their state for them.
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// resume replays the broadcast messages newer than the client's last
+// sequence number, in order, followed by a resumed message.
+func (s *Server) resume(id string, data json.RawMessage, send func(message)) error {
+	var req struct {
+		Epoch string `json:"epoch"`
+		Since uint64 `json:"since"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errors.New("invalid resume request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := resumeResult{Epoch: s.epoch, Seq: s.seq}
+	if req.Epoch != s.epoch {
+		res.Reset = true
+		req.Since = 0
+	} else if req.Since > s.seq {
+		return fmt.Errorf("sequence %d is ahead of the server (%d)", req.Since, s.seq)
+	}
+	var missed []message
+	for topic, r := range s.history {
+		if r.evicted > req.Since && r.size > 1 {
+			res.Truncated = append(res.Truncated, topic)
+		}
+		for _, m := range r.msgs {
+			if m.Seq > req.Since {
+				missed = append(missed, m)
+			}
+		}
+	}
+	sort.Slice(missed, func(i, j int) bool { return missed[i].Seq < missed[j].Seq })
+	sort.Strings(res.Truncated)
+	for _, m := range missed {
+		send(m)
+	}
+	res.Replayed = len(missed)
+	send(message{Type: "resumed", Data: res, ReplyTo: id})
+	return nil
+}
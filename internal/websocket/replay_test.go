@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type received struct {
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	Seq     uint64          `json:"seq"`
+	ReplyTo string          `json:"reply_to"`
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	return c
+}
+
+// readUntil returns the messages received up to and including the first
+// one of type typ.
+func readUntil(t *testing.T, c *websocket.Conn, typ string) []received {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var out []received
+	for {
+		var m received
+		if err := c.ReadJSON(&m); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		out = append(out, m)
+		if m.Type == typ {
+			return out
+		}
+	}
+}
+
+func TestResumeReplaysMissedBroadcasts(t *testing.T) {
+	s := NewServer(nil, nil)
+	ts := httptest.NewServer(http.HandlerFunc(s.HandleWebSocket))
+	defer ts.Close()
+	defer s.Stop()
+
+	c := dial(t, ts.URL)
+	msgs := readUntil(t, c, "session")
+	var session struct {
+		Epoch string `json:"epoch"`
+		Seq   uint64 `json:"seq"`
+	}
+	json.Unmarshal(msgs[len(msgs)-1].Data, &session)
+
+	s.BroadcastMessage("task_state", 1)
+	first := readUntil(t, c, "task_state")
+	last := first[len(first)-1].Seq
+	c.Close()
+
+	// Missed while disconnected.
+	s.BroadcastMessage("task_state", 2)
+	s.BroadcastMessage("stats_update", "old")
+	s.BroadcastMessage("stats_update", "new")
+	s.BroadcastMessage("alert", 3)
+
+	c = dial(t, ts.URL)
+	defer c.Close()
+	readUntil(t, c, "session")
+	c.WriteJSON(map[string]interface{}{"id": "r1", "type": "resume",
+		"data": map[string]interface{}{"epoch": session.Epoch, "since": last}})
+	msgs = readUntil(t, c, "resumed")
+
+	var types []string
+	for _, m := range msgs[:len(msgs)-1] {
+		types = append(types, m.Type+":"+string(m.Data))
+	}
+	if got, want := strings.Join(types, " "), `task_state:2 stats_update:"new" alert:3`; got != want {
+		t.Fatalf("replayed %s, want %s", got, want)
+	}
+	var res resumeResult
+	json.Unmarshal(msgs[len(msgs)-1].Data, &res)
+	if res.Reset || res.Replayed != 3 || res.Seq != last+4 || msgs[len(msgs)-1].ReplyTo != "r1" {
+		t.Fatalf("resumed = %+v", res)
+	}
+
+	// A client of another server instance gets everything kept.
+	c.WriteJSON(map[string]interface{}{"type": "resume", "data": map[string]interface{}{"epoch": "other", "since": last}})
+	msgs = readUntil(t, c, "resumed")
+	json.Unmarshal(msgs[len(msgs)-1].Data, &res)
+	if !res.Reset || res.Replayed != 4 {
+		t.Fatalf("resumed after reset = %+v", res)
+	}
+}
+
+func TestResumeReportsTruncatedTopics(t *testing.T) {
+	s := NewServer(nil, nil)
+	for i := 0; i < topicHistory+5; i++ {
+		s.BroadcastMessage("task_state", i)
+	}
+	var replayed []message
+	var res resumeResult
+	data, _ := json.Marshal(map[string]interface{}{"epoch": s.epoch, "since": 2})
+	err := s.resume("", data, func(m message) {
+		if m.Type == "resumed" {
+			res = m.Data.(resumeResult)
+			return
+		}
+		replayed = append(replayed, m)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != topicHistory || len(res.Truncated) != 1 || res.Truncated[0] != "task_state" {
+		t.Fatalf("replayed %d, result %+v", len(replayed), res)
+	}
+}
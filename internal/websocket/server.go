@@ -2,6 +2,8 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -20,15 +22,36 @@ type message struct {
 	Type      string      `json:"type"`
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp"`
+	RunID     string      `json:"run_id,omitempty"`
+	// ReplyTo is the id of the client request the message answers.
+	ReplyTo string `json:"reply_to,omitempty"`
+	// Seq numbers broadcast messages so that a reconnecting client can
+	// resume after the last one it received.
+	Seq uint64 `json:"seq,omitempty"`
 }
 
+// CommandHandler carries out a scanner command received from a client.
+// action is "start" or "stop"; the returned error is reported back to the
+// client that sent the command.
+type CommandHandler func(action, vpnType string) error
+
 // Server provides a simple WebSocket implementation used by the API server.
 type Server struct {
 	stats    *stats.Stats
 	db       *db.DB
+	aggr     *aggregator.Service
+	commands CommandHandler
 	mu       sync.Mutex
 	clients  map[*websocket.Conn]bool
 	upgrader websocket.Upgrader
+
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// Broadcast history for resuming clients, guarded by mu.
+	epoch   string
+	seq     uint64
+	history map[string]*ring
 }
 
 // NewServer creates a new Server instance.
@@ -37,23 +60,58 @@ func NewServer(s *stats.Stats, database *db.DB) *Server {
 		stats:   s,
 		db:      database,
 		clients: make(map[*websocket.Conn]bool),
+		done:    make(chan struct{}),
+		epoch:   newEpoch(),
+		history: make(map[string]*ring),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
 	}
 }
 
+// SetAggregator makes the server read server info from svc and push changed
+// entries to clients as soon as the service reports them.
+func (s *Server) SetAggregator(svc *aggregator.Service) {
+	s.aggr = svc
+	svc.Subscribe(func(changed []aggregator.ServerInfo) {
+		s.BroadcastMessage("server_info", changed)
+	})
+}
+
+// SetCommandHandler makes start_scanner and stop_scanner messages run h.
+// Without a handler the commands are rejected.
+func (s *Server) SetCommandHandler(h CommandHandler) {
+	s.commands = h
+}
+
 // Start begins periodic broadcasting of stats to connected clients.
 func (s *Server) Start() {
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
-		for range ticker.C {
-			s.BroadcastMessage("stats_update", s.collectStats())
+		for {
+			select {
+			case <-ticker.C:
+				s.BroadcastMessage("stats_update", s.collectStats())
+			case <-s.done:
+				return
+			}
 		}
 	}()
 }
 
+// Stop ends the stats broadcast and closes all client connections.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		for c := range s.clients {
+			c.Close()
+		}
+		s.mu.Unlock()
+	})
+}
+
 // HandleWebSocket upgrades the connection and listens for messages.
 func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
@@ -62,15 +120,19 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.mu.Lock()
-	s.clients[conn] = true
-	s.mu.Unlock()
+	initial := s.collectStats()
+	info := s.collectServerInfo()
 
-	// Send initial stats and server info.
-	s.write(conn, "initial_stats", s.collectStats())
-	if info := s.collectServerInfo(); len(info) > 0 {
+	// Send the initial stats and server info before any broadcast,
+	// followed by the session the client can resume later.
+	s.mu.Lock()
+	s.write(conn, "initial_stats", initial)
+	if len(info) > 0 {
 		s.write(conn, "server_info", info)
 	}
+	s.write(conn, "session", map[string]interface{}{"epoch": s.epoch, "seq": s.seq})
+	s.clients[conn] = true
+	s.mu.Unlock()
 
 	go s.readLoop(conn)
 }
@@ -89,60 +151,114 @@ func (s *Server) readLoop(c *websocket.Conn) {
 		if err != nil {
 			return
 		}
-		var msg struct {
-			Type string          `json:"type"`
-			Data json.RawMessage `json:"data"`
-		}
+		var msg request
 		if err := json.Unmarshal(data, &msg); err != nil {
-			s.write(c, "error", map[string]string{"message": "invalid message"})
+			s.reply(c, "", "error", map[string]string{"message": "invalid message"})
 			continue
 		}
-		switch msg.Type {
-		case "ping":
-			s.write(c, "pong", map[string]interface{}{})
-		case "start_scanner":
-			var payload struct {
-				VPNType string `json:"vpn_type"`
-			}
-			json.Unmarshal(msg.Data, &payload)
-			s.BroadcastMessage("scanner_started", map[string]interface{}{
-				"vpn_type": payload.VPNType,
-				"status":   "success",
-			})
-		case "stop_scanner":
-			var payload struct {
-				VPNType string `json:"vpn_type"`
-			}
-			json.Unmarshal(msg.Data, &payload)
-			s.BroadcastMessage("scanner_stopped", map[string]interface{}{
-				"vpn_type": payload.VPNType,
-				"status":   "success",
-			})
-		case "get_logs":
-			var req struct {
-				Limit int `json:"limit"`
-			}
-			if err := json.Unmarshal(msg.Data, &req); err != nil || req.Limit <= 0 {
-				req.Limit = 100
-			}
-			logs := s.getLogs(req.Limit)
-			s.write(c, "logs_data", logs)
+		if err := s.handle(c, msg); err != nil {
+			s.reply(c, msg.ID, "error", map[string]string{"type": msg.Type, "message": err.Error()})
+		} else if msg.ID != "" {
+			s.reply(c, msg.ID, "ack", map[string]string{"type": msg.Type})
 		}
 	}
 }
 
-// BroadcastMessage sends a message to all connected clients.
+// request is a message sent by a client. A client that sets ID gets an
+// ack or error reply with reply_to set to it once the request is handled;
+// direct results such as pong or logs_data carry the same reply_to.
+type request struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// handle carries out a client request.
+func (s *Server) handle(c *websocket.Conn, msg request) error {
+	switch msg.Type {
+	case "ping":
+		s.reply(c, msg.ID, "pong", map[string]interface{}{})
+	case "start_scanner":
+		return s.runCommand("start", "scanner_started", msg)
+	case "stop_scanner":
+		return s.runCommand("stop", "scanner_stopped", msg)
+	case "resume":
+		return s.resume(msg.ID, msg.Data, func(m message) { s.send(c, m) })
+	case "get_logs":
+		var req struct {
+			Limit int `json:"limit"`
+		}
+		if err := json.Unmarshal(msg.Data, &req); err != nil || req.Limit <= 0 {
+			req.Limit = 100
+		}
+		s.reply(c, msg.ID, "logs_data", s.getLogs(req.Limit))
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+	return nil
+}
+
+// runCommand carries out a scanner command. On success all clients are
+// told with a message of type done carrying the command id.
+func (s *Server) runCommand(action, done string, msg request) error {
+	var payload struct {
+		VPNType string `json:"vpn_type"`
+	}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil || payload.VPNType == "" {
+		return errors.New("vpn_type required")
+	}
+	if s.commands == nil {
+		return errors.New("scanner control unavailable")
+	}
+	if err := s.commands(action, payload.VPNType); err != nil {
+		return err
+	}
+	s.BroadcastMessage(done, map[string]interface{}{
+		"id":       msg.ID,
+		"vpn_type": payload.VPNType,
+		"status":   "success",
+	})
+	return nil
+}
+
+// reply sends a message answering the client request id to c.
+func (s *Server) reply(c *websocket.Conn, id, t string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.send(c, message{Type: t, Data: data, ReplyTo: id})
+}
+
+// BroadcastMessage sends a message to all connected clients and keeps it
+// for clients that resume later.
 func (s *Server) BroadcastMessage(t string, data interface{}) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	m := s.record(s.stamp(message{Type: t, Data: data}))
 	for c := range s.clients {
-		s.write(c, t, data)
+		s.send(c, m)
 	}
 }
 
 // write sends a single message to a connection.
 func (s *Server) write(c *websocket.Conn, t string, data interface{}) {
-	msg, _ := json.Marshal(message{Type: t, Data: data, Timestamp: time.Now().UnixMilli()})
+	s.send(c, message{Type: t, Data: data})
+}
+
+// stamp sets the time and run id of m.
+func (s *Server) stamp(m message) message {
+	m.Timestamp = time.Now().UnixMilli()
+	if s.stats != nil {
+		m.RunID = s.stats.RunID()
+	}
+	return m
+}
+
+// send writes m to c, stamping it unless it was stamped when broadcast.
+func (s *Server) send(c *websocket.Conn, m message) {
+	if m.Timestamp == 0 {
+		m = s.stamp(m)
+	}
+	msg, _ := json.Marshal(m)
 	c.WriteMessage(websocket.TextMessage, msg)
 }
 
@@ -163,10 +279,15 @@ func (s *Server) collectStats() map[string]interface{} {
 		"threads":      s.stats.GetThreads(),
 		"uptime":       s.stats.GetUptime(),
 		"success_rate": s.stats.GetSuccessRate(),
+		"memory_mb":    s.stats.GetMemory(),
+		"cpu_usage":    s.stats.GetCPUUsage(),
 	}
 }
 
 func (s *Server) collectServerInfo() []aggregator.ServerInfo {
+	if s.aggr != nil {
+		return s.aggr.Current()
+	}
 	dir := os.Getenv("STATS_DIR")
 	ag := aggregator.New(dir)
 	infos, err := ag.GetServerInfo()
@@ -334,6 +334,8 @@ export function TaskCreator() {
                   <option value="sophos">Sophos</option>
                   <option value="watchguard">WatchGuard</option>
                   <option value="cisco">Cisco</option>
+                  <option value="zyxel">Zyxel</option>
+                  <option value="barracuda">Barracuda</option>
                 </select>
               </div>
             </div>
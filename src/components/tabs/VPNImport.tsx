@@ -98,6 +98,8 @@ export function VPNImport() {
     if (lowerUrl.includes('sonicwall')) return 'sonicwall';
     if (lowerUrl.includes('sophos')) return 'sophos';
     if (lowerUrl.includes('watchguard')) return 'watchguard';
+    if (lowerUrl.includes('zyxel') || lowerUrl.includes('zywall')) return 'zyxel';
+    if (lowerUrl.includes('barracuda')) return 'barracuda';
     if (lowerUrl.includes('cisco') || lowerUrl.includes('asa')) return 'cisco';
     return 'unknown';
   };
@@ -436,6 +438,8 @@ export function VPNImport() {
                         <option value="sophos">Sophos</option>
                         <option value="watchguard">WatchGuard</option>
                         <option value="cisco">Cisco</option>
+                        <option value="zyxel">Zyxel</option>
+                        <option value="barracuda">Barracuda</option>
                         <option value="unknown">Unknown</option>
                       </select>
                     ) : (
@@ -159,6 +159,47 @@ const vpnTypes = [
       'https://67.202.240.148:443:test:test:ANYCONNECT',
       'https://72.23.123.187:443:test:test:AnyConnect_HVAC'
     ]
+  },
+  {
+    id: 'zyxel',
+    name: 'Zyxel VPN',
+    description: 'Zyxel ZyWALL SSL VPN scanner',
+    script: 'test_scanner.go',
+    color: 'bg-sky-500',
+    status: 'idle',
+    lastRun: 'Never',
+    successRate: 0,
+    totalAttempts: 0,
+    validFound: 0,
+    successIndicators: [
+      'authtok cookie on redirect',
+      'logout.cgi',
+      '/ext-js/app/'
+    ],
+    realCredentials: [
+      'https://203.0.113.20:443;user1;pass1',
+      'https://203.0.113.21:10443;user2;pass2'
+    ]
+  },
+  {
+    id: 'barracuda',
+    name: 'Barracuda VPN',
+    description: 'Barracuda SSL VPN scanner',
+    script: 'test_scanner.go',
+    color: 'bg-slate-500',
+    status: 'idle',
+    lastRun: 'Never',
+    successRate: 0,
+    totalAttempts: 0,
+    validFound: 0,
+    successIndicators: [
+      'redirect to showHome.do',
+      'logoff.do'
+    ],
+    realCredentials: [
+      'https://203.0.113.30:443;user1;pass1',
+      'https://203.0.113.31:443;user2;pass2'
+    ]
   }
 ];
 
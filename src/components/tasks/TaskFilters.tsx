@@ -77,6 +77,8 @@ export function TaskFilters({ filters, onFilterChange, onSearch, onReset }: Task
             <option value="sophos">Sophos</option>
             <option value="watchguard">WatchGuard</option>
             <option value="cisco">Cisco</option>
+            <option value="zyxel">Zyxel</option>
+            <option value="barracuda">Barracuda</option>
           </select>
         </div>
 
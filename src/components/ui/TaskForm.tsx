@@ -183,6 +183,8 @@ export function TaskForm({ onSubmit, onCancel, initialValues, isEdit = false }:
               <option value="sophos">Sophos</option>
               <option value="watchguard">WatchGuard</option>
               <option value="cisco">Cisco</option>
+              <option value="zyxel">Zyxel</option>
+              <option value="barracuda">Barracuda</option>
             </select>
           </div>
         </div>
@@ -8,11 +8,18 @@ import { getAuthToken } from '../lib/auth';
 import { addNotification } from '../store/slices/notificationsSlice';
 
 interface WebSocketMessage {
+  id?: string;
   type: string;
   data: any;
   timestamp: number;
+  reply_to?: string;
+  seq?: number;
 }
 
+// Commands whose ack or error reply has not arrived yet are dropped after
+// this long.
+const PENDING_TIMEOUT = 30000;
+
 export function useWebSocket(url?: string) {
   const dispatch = useAppDispatch();
   const [logs, setLogs] = useState<string[]>([]);
@@ -27,6 +34,11 @@ export function useWebSocket(url?: string) {
   const mockDataIntervalRef = useRef<NodeJS.Timeout>();
   const lastPongTimeRef = useRef<number>(Date.now());
   const pingTimeoutRef = useRef<NodeJS.Timeout>();
+  const nextIdRef = useRef(1);
+  // Epoch and last broadcast sequence number seen, used to replay the
+  // broadcasts missed while reconnecting.
+  const sessionRef = useRef<{ epoch: string; seq: number } | null>(null);
+  const pendingRef = useRef(new Map<string, { type: string; timer: NodeJS.Timeout }>());
 
   // Determine WebSocket URL based on environment
   const getWebSocketUrl = useCallback(() => {
@@ -318,8 +330,42 @@ export function useWebSocket(url?: string) {
   };
 
   const handleMessage = (message: WebSocketMessage) => {
+    const pending = message.reply_to ? pendingRef.current.get(message.reply_to) : undefined;
+    if (pending && (message.type === 'ack' || message.type === 'error')) {
+      clearTimeout(pending.timer);
+      pendingRef.current.delete(message.reply_to!);
+    }
+    if (message.seq && sessionRef.current) {
+      sessionRef.current.seq = Math.max(sessionRef.current.seq, message.seq);
+    }
     try {
       switch (message.type) {
+        case 'ack':
+          break;
+
+        case 'session': {
+          const prev = sessionRef.current;
+          if (prev && wsRef.current?.readyState === WebSocket.OPEN) {
+            wsRef.current.send(JSON.stringify({
+              type: 'resume',
+              data: { epoch: prev.epoch, since: prev.seq },
+              timestamp: Date.now()
+            }));
+          } else {
+            sessionRef.current = { epoch: message.data.epoch, seq: message.data.seq };
+          }
+          break;
+        }
+
+        case 'resumed':
+          console.log('🔁 Resumed WebSocket session:', message.data);
+          sessionRef.current = { epoch: message.data.epoch, seq: message.data.seq };
+          if (message.data.reset || message.data.truncated?.length) {
+            toast('Some live events were missed while disconnected');
+          }
+          break;
+
+
         case 'initial_stats':
         case 'stats_update':
           dispatch(setStats(message.data as StatsData));
@@ -369,7 +415,7 @@ export function useWebSocket(url?: string) {
           toast.error(message.data.message || 'Server error occurred');
           dispatch(addNotification({
             type: 'error',
-            title: 'Server Error',
+            title: pending ? `Command failed: ${pending.type}` : 'Server Error',
             message: message.data.message || 'An error occurred on the server'
           }));
           break;
@@ -435,11 +481,19 @@ export function useWebSocket(url?: string) {
   const sendMessage = useCallback((type: string, data: any) => {
     if (wsRef.current && wsRef.current.readyState === WebSocket.OPEN) {
       try {
+        const id = String(nextIdRef.current++);
         const message: WebSocketMessage = {
+          id,
           type,
           data,
           timestamp: Date.now()
         };
+        const timer = setTimeout(() => {
+          if (pendingRef.current.delete(id)) {
+            console.warn(`No reply to ${type} command ${id}`);
+          }
+        }, PENDING_TIMEOUT);
+        pendingRef.current.set(id, { type, timer });
         wsRef.current.send(JSON.stringify(message));
         return true;
       } catch (err) {
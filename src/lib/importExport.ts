@@ -109,6 +109,8 @@ export function guessVPNType(url: string): string {
   if (lowerUrl.includes('sonicwall')) return 'sonicwall';
   if (lowerUrl.includes('sophos')) return 'sophos';
   if (lowerUrl.includes('watchguard')) return 'watchguard';
+  if (lowerUrl.includes('zyxel') || lowerUrl.includes('zywall')) return 'zyxel';
+  if (lowerUrl.includes('barracuda')) return 'barracuda';
   if (lowerUrl.includes('cisco') || lowerUrl.includes('asa')) return 'cisco';
   return 'unknown';
 }